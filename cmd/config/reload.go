@@ -0,0 +1,38 @@
+package config
+
+import (
+	"github.com/markusressel/fan2go/internal/api"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var reloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Reloads the configuration of a running fan2go daemon",
+	Long: `Asks a running fan2go daemon to re-read its configuration file and apply all changes
+to sensors, curves and fans without restarting. This requires the REST api to be enabled,
+otherwise send a SIGHUP signal to the daemon instead (f.ex. "systemctl reload fan2go").`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configPath := configuration.DetectAndReadConfigFile()
+		ui.Info("Using configuration file at: %s", configPath)
+		configuration.LoadConfig()
+
+		client, err := api.NewClient(configuration.CurrentConfig.Api)
+		if err != nil {
+			return err
+		}
+
+		if err = client.ReloadConfig(); err != nil {
+			return err
+		}
+
+		ui.Success("Configuration reloaded!")
+		return nil
+	},
+}
+
+func init() {
+	Command.AddCommand(reloadCmd)
+}
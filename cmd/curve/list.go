@@ -47,6 +47,10 @@ var curveCmd = &cobra.Command{
 				printPidCurveInfo(curve, curveConf.PID)
 			case *curves.FunctionSpeedCurve:
 				printFunctionCurveInfo(curve, curveConf.Function)
+			case *curves.DerivativeSpeedCurve:
+				printDerivativeCurveInfo(curve, curveConf.Derivative)
+			case *curves.ExpressionSpeedCurve:
+				printExpressionCurveInfo(curve, curveConf.Expression)
 			}
 		}
 
@@ -60,22 +64,25 @@ func printLinearCurveInfo(curve curves.SpeedCurve, config *configuration.LinearC
 	sensorId := config.Sensor
 
 	if config.Steps != nil {
-		stepMappings := map[int]float64{}
-		sortedStepKeys := util.SortedKeys(config.Steps)
-		for _, x := range sortedStepKeys {
-			y := config.Steps[x]
-			stepMappings[int(y)] = float64(x)
+		interpolationType := config.Interpolation
+		if len(interpolationType) <= 0 {
+			interpolationType = util.InterpolationTypeLinear
 		}
 
-		headers := []string{"ID", "Type", "Sensor"}
+		headers := []string{"ID", "Type", "Sensor", "Interpolation"}
 		rows := [][]string{
-			{curve.GetId(), curveType, sensorId},
+			{curve.GetId(), curveType, sensorId, interpolationType},
 		}
 
 		printInfoTable(headers, rows)
 
-		interpolated := util.InterpolateLinearly(&stepMappings, 0, 255)
-		drawGraph(interpolated, "Temp / Curve Value")
+		sortedStepKeys := util.SortedKeys(config.Steps)
+		graphValues := map[int]float64{}
+		for temp := sortedStepKeys[0]; temp <= sortedStepKeys[len(sortedStepKeys)-1]; temp++ {
+			value := util.CalculateInterpolatedCurveValue(config.Steps, interpolationType, float64(temp))
+			graphValues[temp] = util.Coerce(value, 0, 255)
+		}
+		drawGraph(graphValues, fmt.Sprintf("Curve Value / Temp (%d°C - %d°C)", sortedStepKeys[0], sortedStepKeys[len(sortedStepKeys)-1]))
 	} else {
 		headers := []string{"ID", "Type", "Sensor", "Min", "Max"}
 		rows := [][]string{
@@ -84,40 +91,10 @@ func printLinearCurveInfo(curve curves.SpeedCurve, config *configuration.LinearC
 
 		printInfoTable(headers, rows)
 
-		/*
-			var keys map[int]float64
-			// TODO: to draw a graph of what the PWM curve would look like, we would need to
-			//  calculate the target pwm for each input pwm for a given curve.
-			//
-			//  with the current architecture this isn't possible, because the curve instance is hardwired
-			//  to a specific sensor.
-			//  So to calculate curve targets we would need to set fake sensor values
-
-			// creates a virtual sensor, to simulate inputs and calculate the output of the curve
-			sensor := sensors.VirtualSensor{
-				Name:  sensorId,
-				Value: 0,
-			}
-			sensors.SensorMap[sensorId] = &sensor
-
-			keys = map[int]float64{}
-
-			for i := config.Min; i <= config.Max; i++ {
-				sensor.Value = float64(i * 1000)
-				v, _ := curve.Evaluate()
-				keys[i] = float64(v)
-			}
-
-			start := 0
-			stop := 100
-
-			var graphValues map[int]float64 = nil
-			graphValues = util.InterpolateLinearly(&keys, start, stop)
-
-			if graphValues != nil {
-				drawGraph(graphValues, "RPM / PWM")
-			}
-		*/
+		if config.Max > config.Min {
+			steps := map[int]float64{config.Min: 0, config.Max: 255}
+			drawGraph(util.InterpolateLinearly(&steps, config.Min, config.Max), fmt.Sprintf("Curve Value / Temp (%d°C - %d°C)", config.Min, config.Max))
+		}
 	}
 
 }
@@ -150,15 +127,56 @@ func printFunctionCurveInfo(curve curves.SpeedCurve, config *configuration.Funct
 		{curve.GetId(), curveType, t, curveIdsText},
 	}
 
+	if t == configuration.FunctionWeighted {
+		var weights []string
+		for _, weight := range config.Weights {
+			weights = append(weights, fmt.Sprint(weight))
+		}
+		headers = append(headers, "Weights")
+		rows[0] = append(rows[0], strings.Join(weights, ", "))
+	}
+
 	printInfoTable(headers, rows)
 }
 
 func printPidCurveInfo(curve curves.SpeedCurve, config *configuration.PidCurveConfig) {
 	curveType := "PID"
 
-	headers := []string{"ID", "Type", "P", "I", "D", "Set Point"}
+	outputMin, outputMax := config.GetOutputRange()
+
+	headers := []string{"ID", "Type", "P", "I", "D", "Set Point", "Output", "Derivative Filter", "Reverse"}
+	rows := [][]string{
+		{
+			curve.GetId(), curveType, fmt.Sprint(config.P), fmt.Sprint(config.I), fmt.Sprint(config.D), fmt.Sprint(config.SetPoint),
+			fmt.Sprintf("%d..%d", outputMin, outputMax), config.DerivativeFilter.String(), fmt.Sprint(config.Reverse),
+		},
+	}
+
+	printInfoTable(headers, rows)
+}
+
+func printDerivativeCurveInfo(curve curves.SpeedCurve, config *configuration.DerivativeCurveConfig) {
+	curveType := "Derivative"
+
+	window := config.Window
+	if window <= 0 {
+		window = configuration.DefaultDerivativeWindow
+	}
+
+	headers := []string{"ID", "Type", "Sensor", "Window", "Min (°C/s)", "Max (°C/s)"}
+	rows := [][]string{
+		{curve.GetId(), curveType, config.Sensor, window.String(), fmt.Sprint(config.Min), fmt.Sprint(config.Max)},
+	}
+
+	printInfoTable(headers, rows)
+}
+
+func printExpressionCurveInfo(curve curves.SpeedCurve, config *configuration.ExpressionCurveConfig) {
+	curveType := "Expression"
+
+	headers := []string{"ID", "Type", "Formula", "Sensor/Curve IDs"}
 	rows := [][]string{
-		{curve.GetId(), curveType, fmt.Sprint(config.P), fmt.Sprint(config.I), fmt.Sprint(config.D), fmt.Sprint(config.SetPoint)},
+		{curve.GetId(), curveType, config.Formula, strings.Join(config.GetReferencedIds(), ", ")},
 	}
 
 	printInfoTable(headers, rows)
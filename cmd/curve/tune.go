@@ -0,0 +1,122 @@
+package curve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/markusressel/fan2go/internal"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/tuning"
+	"github.com/markusressel/fan2go/internal/ui"
+	"github.com/spf13/cobra"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+var (
+	tuneRule       string
+	tuneHysteresis float64
+	tuneCycles     int
+	tuneInterval   time.Duration
+	tuneTimeout    time.Duration
+	tuneWrite      bool
+)
+
+var tuneCmd = &cobra.Command{
+	Use:   "tune",
+	Short: "Suggests the constants of a PID curve using a relay feedback experiment",
+	Long: `Switches all fans using the given PID curve between the lowest and highest output of the curve,
+whenever the temperature of its sensor crosses the setPoint. The resulting oscillation of the temperature is used
+to calculate suitable p, i and d constants, which can optionally be written back to the configuration file.
+
+Make sure the fan2go daemon is not running while tuning, since the experiment controls the fans directly.
+The temperature needs to oscillate around the setPoint, so keep the load of the system steady while tuning.`,
+	Example: `  fan2go curve tune --id cpu_pid_curve
+  fan2go curve tune --id cpu_pid_curve --rule no-overshoot --write`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(curveId) <= 0 {
+			return errors.New("the id of the curve is required")
+		}
+
+		configPath := configuration.DetectAndReadConfigFile()
+		ui.Info("Using configuration file at: %s", configPath)
+		configuration.LoadConfig()
+		if err := configuration.Validate(configPath); err != nil {
+			ui.Fatal(err.Error())
+		}
+
+		var curveConfig *configuration.CurveConfig
+		for idx, config := range configuration.CurrentConfig.Curves {
+			if config.ID == curveId {
+				curveConfig = &configuration.CurrentConfig.Curves[idx]
+			}
+		}
+		if curveConfig == nil {
+			return fmt.Errorf("no curve with id found: %s", curveId)
+		}
+		if curveConfig.PID == nil {
+			return fmt.Errorf("curve %s is not a pid curve", curveId)
+		}
+
+		outputMin, outputMax := curveConfig.PID.GetOutputRange()
+		options := tuning.RelayOptions{
+			SetPoint:       curveConfig.PID.SetPoint,
+			Low:            float64(outputMin) / 255,
+			High:           float64(outputMax) / 255,
+			Hysteresis:     tuneHysteresis,
+			Cycles:         tuneCycles,
+			SampleInterval: tuneInterval,
+			Timeout:        tuneTimeout,
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		ui.Info("Running relay feedback experiment around %.1f°C, this may take a while...", options.SetPoint)
+		result, err := internal.TuneCurve(ctx, *curveConfig, options)
+		if err != nil {
+			return err
+		}
+
+		gains, err := result.Gains(tuneRule)
+		if err != nil {
+			return err
+		}
+		if !curveConfig.PID.Reverse {
+			// without reverse action the error is negative above the setPoint
+			gains = gains.Negate()
+		}
+
+		printInfoTable(
+			[]string{"Ultimate Gain", "Ultimate Period", "Amplitude (°C)"},
+			[][]string{{fmt.Sprintf("%.4f", result.UltimateGain), result.UltimatePeriod.String(), fmt.Sprintf("%.2f", result.Amplitude)}},
+		)
+		ui.Printfln("")
+		printInfoTable(
+			[]string{"Rule", "P", "I", "D"},
+			[][]string{{tuneRule, fmt.Sprintf("%.4g", gains.P), fmt.Sprintf("%.4g", gains.I), fmt.Sprintf("%.4g", gains.D)}},
+		)
+
+		if !tuneWrite {
+			return nil
+		}
+		if err = configuration.UpdatePidCurveConstants(configPath, curveId, gains.P, gains.I, gains.D); err != nil {
+			return err
+		}
+		ui.Success("Updated the constants of curve '%s' in %s, reload the configuration to apply them.", curveId, configPath)
+		return nil
+	},
+}
+
+func init() {
+	tuneCmd.Flags().StringVar(&tuneRule, "rule", tuning.RuleClassic, fmt.Sprintf("Tuning rule used to calculate the constants, one of: %s | %s", tuning.RuleClassic, tuning.RuleNoOvershoot))
+	tuneCmd.Flags().Float64Var(&tuneHysteresis, "hysteresis", 0.5, "Temperature difference (in °C) around the setPoint, which is ignored to prevent switching because of sensor noise")
+	tuneCmd.Flags().IntVar(&tuneCycles, "cycles", 3, "Number of oscillations to measure")
+	tuneCmd.Flags().DurationVar(&tuneInterval, "interval", 1*time.Second, "Time between two sensor measurements")
+	tuneCmd.Flags().DurationVar(&tuneTimeout, "timeout", 30*time.Minute, "Maximum duration of the experiment")
+	tuneCmd.Flags().BoolVar(&tuneWrite, "write", false, "Write the suggested constants to the configuration file")
+	Command.AddCommand(tuneCmd)
+}
@@ -1,19 +1,28 @@
 package fan
 
 import (
+	"context"
 	"github.com/markusressel/fan2go/internal/configuration"
 	"github.com/markusressel/fan2go/internal/controller"
 	"github.com/markusressel/fan2go/internal/persistence"
 	"github.com/markusressel/fan2go/internal/ui"
 	"github.com/markusressel/fan2go/internal/util"
 	"github.com/spf13/cobra"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
+var initResume bool
+
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Runs the initialization sequence for a fan",
-	Long:  ``,
-	Args:  cobra.NoArgs,
+	Long: `Measures the characteristics of a fan, replacing any existing data.
+
+The fan is restored to its original state if the initialization is aborted (f.ex. using Ctrl+C).
+Use --resume to continue an aborted initialization instead of starting over.`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		//pterm.DisableOutput()
 
@@ -39,14 +48,33 @@ var initCmd = &cobra.Command{
 
 		ui.Info("Deleting existing data for fan '%s'...", fan.GetId())
 
+		if !initResume {
+			if err = p.DeleteFanInitializationCheckpoint(fan.GetId()); err != nil {
+				return err
+			}
+		}
+
 		if err = p.DeleteFanPwmData(fan); err != nil {
 			return err
 		}
 		if err = p.DeleteFanPwmMap(fan.GetId()); err != nil {
 			return err
 		}
+		if err = p.DeleteFanControlLoop(fan.GetId()); err != nil {
+			return err
+		}
+		if err = p.DeleteFanPwmBoundaries(fan.GetId()); err != nil {
+			return err
+		}
 
-		err = fanController.RunInitializationSequence()
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		err = fanController.RunInitializationSequence(ctx)
+		if ctx.Err() != nil {
+			ui.Warning("Initialization of fan '%s' aborted, use --resume to continue it later on", fan.GetId())
+			return nil
+		}
 
 		if err == nil {
 			ui.Success("Done!")
@@ -59,5 +87,6 @@ var initCmd = &cobra.Command{
 }
 
 func init() {
+	initCmd.Flags().BoolVar(&initResume, "resume", false, "Continue an aborted initialization instead of starting over")
 	Command.AddCommand(initCmd)
 }
@@ -0,0 +1,71 @@
+package fan
+
+import (
+	"errors"
+	"github.com/markusressel/fan2go/internal/api"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/ui"
+	"github.com/spf13/cobra"
+	"time"
+)
+
+var (
+	overridePwm      int
+	overrideCurveId  string
+	overrideDuration time.Duration
+	overrideClear    bool
+)
+
+var overrideCmd = &cobra.Command{
+	Use:   "override",
+	Short: "Temporarily override the curve of a fan in the running daemon",
+	Long: `Makes the running fan2go daemon set a fixed PWM value or use an alternative curve for a fan,
+until the given duration has passed or the override is cleared. This requires the REST api to be enabled.`,
+	Example: `  fan2go fan --id cpu override --pwm 255 --for 10m
+  fan2go fan --id cpu override --curve quiet_curve --for 1h
+  fan2go fan --id cpu override --clear`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configPath := configuration.DetectAndReadConfigFile()
+		ui.Info("Using configuration file at: %s", configPath)
+		configuration.LoadConfig()
+
+		client, err := api.NewClient(configuration.CurrentConfig.Api)
+		if err != nil {
+			return err
+		}
+
+		if overrideClear {
+			if err = client.ClearFanOverride(fanId); err == nil {
+				ui.Success("Override of fan '%s' cleared", fanId)
+			}
+			return err
+		}
+
+		request := api.OverrideRequest{
+			Curve:    overrideCurveId,
+			Duration: overrideDuration.String(),
+		}
+		if cmd.Flags().Changed("pwm") {
+			request.Pwm = &overridePwm
+		}
+		if request.Pwm == nil && len(request.Curve) <= 0 {
+			return errors.New("either --pwm or --curve is required")
+		}
+
+		override, err := client.SetFanOverride(fanId, request)
+		if err != nil {
+			return err
+		}
+		ui.Success("Override of fan '%s' active until %s", fanId, override.Expires.Local().Format(time.RFC1123))
+		return nil
+	},
+}
+
+func init() {
+	overrideCmd.Flags().IntVarP(&overridePwm, "pwm", "p", 0, "Fixed PWM value [0..255] to set on the fan instead of the curve value, which is not mapped to the minPwm..maxPwm range of the fan")
+	overrideCmd.Flags().StringVar(&overrideCurveId, "curve", "", "ID of a curve to use instead of the fan curve")
+	overrideCmd.Flags().DurationVar(&overrideDuration, "for", 10*time.Minute, "Duration after which the override expires")
+	overrideCmd.Flags().BoolVar(&overrideClear, "clear", false, "Clear the currently active override")
+	Command.AddCommand(overrideCmd)
+}
@@ -0,0 +1,47 @@
+package profile
+
+import (
+	"errors"
+	"github.com/spf13/cobra"
+)
+
+var forceClear bool
+
+var forceCmd = &cobra.Command{
+	Use:   "force [id]",
+	Short: "Activates a profile in the running daemon regardless of triggers and the schedule",
+	Long: `Makes the running fan2go daemon use the given profile until the forced profile is cleared
+or the daemon is restarted. This requires the REST api to be enabled.`,
+	Example: `  fan2go profile force silent
+  fan2go profile force --clear`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !forceClear && len(args) <= 0 {
+			return errors.New("either a profile id or --clear is required")
+		}
+
+		client, err := newClient()
+		if err != nil {
+			return err
+		}
+
+		if forceClear {
+			state, err := client.ClearForcedProfile()
+			if err == nil {
+				printActiveProfile(state)
+			}
+			return err
+		}
+
+		state, err := client.ForceProfile(args[0])
+		if err == nil {
+			printActiveProfile(state)
+		}
+		return err
+	},
+}
+
+func init() {
+	forceCmd.Flags().BoolVar(&forceClear, "clear", false, "Hand control over the active profile back to the triggers and the schedule")
+	Command.AddCommand(forceCmd)
+}
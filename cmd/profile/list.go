@@ -0,0 +1,37 @@
+package profile
+
+import (
+	"github.com/markusressel/fan2go/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists all profiles and the active profile of the running daemon",
+	Long:  `Lists all profiles and the active profile of the running daemon. This requires the REST api to be enabled.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := newClient()
+		if err != nil {
+			return err
+		}
+
+		state, err := client.GetProfiles()
+		if err != nil {
+			return err
+		}
+
+		for _, profile := range state.Profiles {
+			ui.Printfln("%s:", profile.ID)
+			for _, profileFan := range profile.Fans {
+				ui.Printfln("  %s -> %s", profileFan.Fan, profileFan.Curve)
+			}
+		}
+		printActiveProfile(state)
+		return nil
+	},
+}
+
+func init() {
+	Command.AddCommand(listCmd)
+}
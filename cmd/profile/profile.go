@@ -0,0 +1,36 @@
+package profile
+
+import (
+	"github.com/markusressel/fan2go/internal/api"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var Command = &cobra.Command{
+	Use:              "profile",
+	Short:            "Profile related commands",
+	Long:             ``,
+	TraverseChildren: true,
+}
+
+// newClient reads the configuration file and creates a client for the REST api of the running daemon
+func newClient() (*api.Client, error) {
+	configPath := configuration.DetectAndReadConfigFile()
+	ui.Info("Using configuration file at: %s", configPath)
+	configuration.LoadConfig()
+
+	return api.NewClient(configuration.CurrentConfig.Api)
+}
+
+func printActiveProfile(state api.ProfileState) {
+	if len(state.Active) <= 0 {
+		ui.Success("No profile active")
+	} else if state.Active == state.Forced {
+		ui.Success("Active profile: %s (forced)", state.Active)
+	} else if state.Active == state.Triggered {
+		ui.Success("Active profile: %s (triggered)", state.Active)
+	} else {
+		ui.Success("Active profile: %s", state.Active)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/markusressel/fan2go/cmd/curve"
 	"github.com/markusressel/fan2go/cmd/fan"
 	"github.com/markusressel/fan2go/cmd/global"
+	"github.com/markusressel/fan2go/cmd/profile"
 	"github.com/markusressel/fan2go/cmd/sensor"
 	"github.com/markusressel/fan2go/internal"
 	"github.com/markusressel/fan2go/internal/configuration"
@@ -50,6 +51,7 @@ func init() {
 	rootCmd.AddCommand(fan.Command)
 	rootCmd.AddCommand(curve.Command)
 	rootCmd.AddCommand(sensor.Command)
+	rootCmd.AddCommand(profile.Command)
 }
 
 func setupUi() {
@@ -20,6 +20,7 @@ require (
 	github.com/tomlazar/table v0.1.2
 	go.etcd.io/bbolt v1.3.6
 	golang.org/x/exp v0.0.0-20220328175248-053ad81199eb
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -67,5 +68,4 @@ require (
 	google.golang.org/protobuf v1.28.1 // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
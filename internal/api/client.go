@@ -0,0 +1,113 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/controller"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Client talks to the REST api of a running fan2go daemon
+type Client struct {
+	baseUrl    string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the REST api described by the given configuration
+func NewClient(config configuration.ApiConfig) (*Client, error) {
+	if !config.Enabled {
+		return nil, errors.New("the REST api is disabled in the configuration, enable it to use this command")
+	}
+
+	return &Client{
+		baseUrl: fmt.Sprintf("http://%s:%d", config.Host, config.Port),
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}, nil
+}
+
+// ReloadConfig asks the daemon to re-read and apply its configuration file
+func (c *Client) ReloadConfig() error {
+	return c.do(http.MethodPost, "/config/reload/", nil, nil)
+}
+
+// SetFanOverride overrides the curve of the given fan
+func (c *Client) SetFanOverride(fanId string, request OverrideRequest) (override controller.Override, err error) {
+	err = c.do(http.MethodPost, "/fan/"+url.PathEscape(fanId)+"/override/", request, &override)
+	return override, err
+}
+
+// ClearFanOverride removes the currently active override of the given fan
+func (c *Client) ClearFanOverride(fanId string) error {
+	return c.do(http.MethodDelete, "/fan/"+url.PathEscape(fanId)+"/override/", nil, nil)
+}
+
+// GetProfiles returns the currently active profile and all known profiles
+func (c *Client) GetProfiles() (state ProfileState, err error) {
+	err = c.do(http.MethodGet, "/profile/", nil, &state)
+	return state, err
+}
+
+// ForceProfile activates the given profile regardless of triggers and the schedule
+func (c *Client) ForceProfile(profileId string) (state ProfileState, err error) {
+	err = c.do(http.MethodPost, "/profile/"+url.PathEscape(profileId)+"/force/", nil, &state)
+	return state, err
+}
+
+// ClearForcedProfile hands control over the active profile back to the triggers and the schedule
+func (c *Client) ClearForcedProfile() (state ProfileState, err error) {
+	err = c.do(http.MethodDelete, "/profile/force/", nil, &state)
+	return state, err
+}
+
+// do sends a request with the given body to the given path and decodes the response body into result, if given
+func (c *Client) do(method string, path string, body interface{}, result interface{}) error {
+	var requestBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		requestBody = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequest(method, c.baseUrl+path, requestBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("unable to reach fan2go daemon: %v", err)
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		var r Result
+		if err := json.Unmarshal(responseBody, &r); err == nil && len(r.Message) > 0 {
+			return errors.New(r.Message)
+		}
+		return fmt.Errorf("request failed with status %d", response.StatusCode)
+	}
+
+	if result != nil && len(responseBody) > 0 {
+		return json.Unmarshal(responseBody, result)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"net/http"
+)
+
+func registerConfigEndpoints(rest *echo.Echo, daemon Daemon) {
+	group := rest.Group("/config")
+
+	group.POST("/reload/", func(c echo.Context) error {
+		return reloadConfig(c, daemon)
+	})
+}
+
+// re-reads the configuration file and applies all changes to the running daemon
+func reloadConfig(c echo.Context, daemon Daemon) error {
+	err := daemon.Reload()
+	if err != nil {
+		return returnBadRequest(c, err)
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// validateUpdatedConfig validates a configuration that has been modified using the api
+func validateUpdatedConfig(config *configuration.Configuration) error {
+	err := configuration.ValidateConfig(config, configuration.GetFilePath())
+	if err != nil {
+		return newBadRequestError("Invalid configuration: %v", err)
+	}
+	return nil
+}
@@ -1,40 +1,116 @@
 package api
 
 import (
-	"errors"
 	"github.com/labstack/echo/v4"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/controller"
 	"github.com/markusressel/fan2go/internal/curves"
 	"net/http"
+	"strings"
 )
 
-func registerCurveEndpoints(rest *echo.Echo) {
+func registerCurveEndpoints(rest *echo.Echo, daemon Daemon) {
 	group := rest.Group("/curve")
 
 	group.GET("/", getCurves)
 	group.GET("/:"+urlParamId+"/", getCurve)
-	group.POST("/", createCurve)
-	group.DELETE("/:"+urlParamId+"/", deleteCurve)
+	group.POST("/", func(c echo.Context) error {
+		return createCurve(c, daemon)
+	})
+	group.DELETE("/:"+urlParamId+"/", func(c echo.Context) error {
+		return deleteCurve(c, daemon)
+	})
 }
 
 func getCurves(c echo.Context) error {
-	data := curves.SpeedCurveMap
+	data := map[string]interface{}{}
+	for id, curve := range curves.SnapshotSpeedCurveMap() {
+		curveData, err := withValue(curve)
+		if err != nil {
+			return returnError(c, err)
+		}
+		data[id] = curveData
+	}
 	return c.JSONPretty(http.StatusOK, data, indentationChar)
 }
 
 func getCurve(c echo.Context) error {
 	id := c.Param(urlParamId)
-	data, exists := curves.SpeedCurveMap[id]
+	curve, exists := curves.GetSpeedCurve(id)
 	if !exists {
 		return returnNotFound(c, id)
+	}
+
+	data, err := withValue(curve)
+	if err != nil {
+		return returnError(c, err)
+	}
+	return c.JSONPretty(http.StatusOK, data, indentationChar)
+}
+
+// adds the value of the given curve as of the last iteration of the scheduler to its json representation
+func withValue(curve curves.SpeedCurve) (map[string]interface{}, error) {
+	data, err := toJsonMap(curve)
+	if err != nil {
+		return nil, err
+	}
+	if value, exists := controller.GetSnapshot().Curves[curve.GetId()]; exists {
+		data["value"] = value
 	} else {
-		return c.JSONPretty(http.StatusOK, data, indentationChar)
+		data["value"] = nil
 	}
+	return data, nil
 }
 
-func deleteCurve(c echo.Context) error {
-	return returnError(c, errors.New("not yet supported"))
+// removes the curve with the given id, if it is not used by any fan or other curve
+func deleteCurve(c echo.Context, daemon Daemon) error {
+	id := c.Param(urlParamId)
+
+	err := daemon.UpdateConfig(func(config *configuration.Configuration) error {
+		if usages := configuration.GetCurveUsages(config, id); len(usages) > 0 {
+			return newConflictError("Curve '%s' is still used by: %s", id, strings.Join(usages, ", "))
+		}
+		for idx, curveConfig := range config.Curves {
+			if curveConfig.ID == id {
+				config.Curves = append(config.Curves[:idx:idx], config.Curves[idx+1:]...)
+				return validateUpdatedConfig(config)
+			}
+		}
+		return newNotFoundError(id)
+	})
+	if err != nil {
+		return returnError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
 }
 
-func createCurve(c echo.Context) error {
-	return returnError(c, errors.New("not yet supported"))
+// adds a new curve
+func createCurve(c echo.Context, daemon Daemon) error {
+	var curveConfig configuration.CurveConfig
+	if err := c.Bind(&curveConfig); err != nil {
+		return returnBadRequest(c, err)
+	}
+
+	err := daemon.UpdateConfig(func(config *configuration.Configuration) error {
+		if len(curveConfig.ID) <= 0 {
+			return newBadRequestError("Missing curve id")
+		}
+		for _, existing := range config.Curves {
+			if existing.ID == curveConfig.ID {
+				return newConflictError("Curve with id '%s' already exists", curveConfig.ID)
+			}
+		}
+		config.Curves = append(config.Curves, curveConfig)
+		return validateUpdatedConfig(config)
+	})
+	if err != nil {
+		return returnError(c, err)
+	}
+
+	curve, _ := curves.GetSpeedCurve(curveConfig.ID)
+	data, err := withValue(curve)
+	if err != nil {
+		return returnError(c, err)
+	}
+	return c.JSONPretty(http.StatusCreated, data, indentationChar)
 }
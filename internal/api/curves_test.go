@@ -0,0 +1,85 @@
+package api
+
+import (
+	"github.com/markusressel/fan2go/internal/curves"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestCreateCurve(t *testing.T) {
+	// GIVEN
+	daemon := newFakeDaemon(t, createTestConfig(t))
+
+	// WHEN
+	rec := performRequest(daemon, http.MethodPost, "/curve/", `{"id": "api_new_curve", "linear": {"sensor": "api_sensor", "min": 30, "max": 70}}`)
+
+	// THEN
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), "api_new_curve")
+	_, exists := curves.GetSpeedCurve("api_new_curve")
+	assert.True(t, exists)
+	assert.Len(t, daemon.config.Curves, 2)
+}
+
+func TestCreateCurveRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{name: "malformed body", body: `{"id": `, expected: http.StatusBadRequest},
+		{name: "missing id", body: `{"linear": {"sensor": "api_sensor", "min": 30, "max": 70}}`, expected: http.StatusBadRequest},
+		{name: "unknown sensor", body: `{"id": "api_new_curve", "linear": {"sensor": "unknown", "min": 30, "max": 70}}`, expected: http.StatusBadRequest},
+		{name: "duplicate id", body: `{"id": "api_curve", "linear": {"sensor": "api_sensor", "min": 30, "max": 70}}`, expected: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			daemon := newFakeDaemon(t, createTestConfig(t))
+
+			// WHEN
+			rec := performRequest(daemon, http.MethodPost, "/curve/", tt.body)
+
+			// THEN
+			assert.Equal(t, tt.expected, rec.Code)
+			assert.Equal(t, 0, daemon.updates)
+			assert.Len(t, daemon.config.Curves, 1)
+		})
+	}
+}
+
+func TestDeleteCurve(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		expected int
+		// number of curves that remain
+		remaining int
+	}{
+		{name: "unused", id: "api_unused_curve", expected: http.StatusNoContent, remaining: 1},
+		{name: "used by a fan", id: "api_curve", expected: http.StatusConflict, remaining: 2},
+		{name: "unknown", id: "unknown", expected: http.StatusNotFound, remaining: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			config := createTestConfig(t)
+			unused := config.Curves[0]
+			unused.ID = "api_unused_curve"
+			config.Curves = append(config.Curves, unused)
+			daemon := newFakeDaemon(t, config)
+
+			// WHEN
+			rec := performRequest(daemon, http.MethodDelete, "/curve/"+tt.id+"/", "")
+
+			// THEN
+			assert.Equal(t, tt.expected, rec.Code)
+			assert.Len(t, daemon.config.Curves, tt.remaining)
+			_, exists := curves.GetSpeedCurve("api_curve")
+			assert.True(t, exists)
+		})
+	}
+}
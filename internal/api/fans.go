@@ -1,41 +1,121 @@
 package api
 
 import (
-	"errors"
 	"github.com/labstack/echo/v4"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/controller"
 	"github.com/markusressel/fan2go/internal/fans"
 	"net/http"
 )
 
-func registerFanEndpoints(rest *echo.Echo) {
+func registerFanEndpoints(rest *echo.Echo, daemon Daemon) {
 	group := rest.Group("/fan")
 
 	group.GET("/", getFans)
 	group.GET("/:"+urlParamId+"/", getFan)
-	group.POST("/", createFan)
-	group.DELETE("/:"+urlParamId+"/", deleteFan)
+	group.POST("/", func(c echo.Context) error {
+		return createFan(c, daemon)
+	})
+	group.DELETE("/:"+urlParamId+"/", func(c echo.Context) error {
+		return deleteFan(c, daemon)
+	})
+	group.POST("/:"+urlParamId+"/override/", setFanOverride)
+	group.DELETE("/:"+urlParamId+"/override/", clearFanOverride)
 }
 
 // returns a list of all currently configured fans
 func getFans(c echo.Context) error {
-	data := fans.FanMap
+	data := map[string]interface{}{}
+	for id, fan := range fans.SnapshotFanMap() {
+		fanData, err := withControllerState(fan)
+		if err != nil {
+			return returnError(c, err)
+		}
+		data[id] = fanData
+	}
 	return c.JSONPretty(http.StatusOK, data, indentationChar)
 }
 
 func getFan(c echo.Context) error {
 	id := c.Param(urlParamId)
-	data, exists := fans.FanMap[id]
+	fan, exists := fans.GetFan(id)
 	if !exists {
 		return returnNotFound(c, id)
-	} else {
-		return c.JSONPretty(http.StatusOK, data, indentationChar)
 	}
+
+	data, err := withControllerState(fan)
+	if err != nil {
+		return returnError(c, err)
+	}
+	return c.JSONPretty(http.StatusOK, data, indentationChar)
+}
+
+// withControllerState returns the json representation of the given fan, including the state of its controller
+// as of the last iteration of the scheduler
+func withControllerState(fan fans.Fan) (map[string]interface{}, error) {
+	var state interface{} = controller.FanState{Config: fan.GetConfig()}
+	if s, exists := controller.GetSnapshot().Fans[fan.GetId()]; exists {
+		state = s
+	}
+	data, err := toJsonMap(state)
+	if err != nil {
+		return nil, err
+	}
+
+	if c, exists := controller.GetFanController(fan.GetId()); exists {
+		data["override"] = c.GetOverride()
+		data["stalled"] = c.GetStatistics().Stalled
+		data["initialization"] = c.GetInitializationProgress()
+	}
+	return data, nil
 }
 
-func deleteFan(c echo.Context) error {
-	return returnError(c, errors.New("not yet supported"))
+// removes the fan with the given id and restores its original pwm_enable state
+func deleteFan(c echo.Context, daemon Daemon) error {
+	id := c.Param(urlParamId)
+
+	err := daemon.UpdateConfig(func(config *configuration.Configuration) error {
+		for idx, fanConfig := range config.Fans {
+			if fanConfig.ID == id {
+				config.Fans = append(config.Fans[:idx:idx], config.Fans[idx+1:]...)
+				return validateUpdatedConfig(config)
+			}
+		}
+		return newNotFoundError(id)
+	})
+	if err != nil {
+		return returnError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
 }
 
-func createFan(c echo.Context) error {
-	return returnError(c, errors.New("not yet supported"))
+// adds a new fan and starts its fan controller
+func createFan(c echo.Context, daemon Daemon) error {
+	var fanConfig configuration.FanConfig
+	if err := c.Bind(&fanConfig); err != nil {
+		return returnBadRequest(c, err)
+	}
+
+	err := daemon.UpdateConfig(func(config *configuration.Configuration) error {
+		if len(fanConfig.ID) <= 0 {
+			return newBadRequestError("Missing fan id")
+		}
+		for _, existing := range config.Fans {
+			if existing.ID == fanConfig.ID {
+				return newConflictError("Fan with id '%s' already exists", fanConfig.ID)
+			}
+		}
+		config.Fans = append(config.Fans, fanConfig)
+		return validateUpdatedConfig(config)
+	})
+	if err != nil {
+		return returnError(c, err)
+	}
+
+	fan, _ := fans.GetFan(fanConfig.ID)
+	data, err := withControllerState(fan)
+	if err != nil {
+		return returnError(c, err)
+	}
+	return c.JSONPretty(http.StatusCreated, data, indentationChar)
 }
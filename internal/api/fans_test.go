@@ -0,0 +1,81 @@
+package api
+
+import (
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateFan(t *testing.T) {
+	// GIVEN
+	daemon := newFakeDaemon(t, createTestConfig(t))
+	fanPath := filepath.Join(t.TempDir(), "fan")
+	assert.NoError(t, os.WriteFile(fanPath, []byte("100"), 0644))
+
+	// WHEN
+	rec := performRequest(daemon, http.MethodPost, "/fan/", `{"id": "api_new_fan", "curve": "api_curve", "file": {"path": "`+fanPath+`"}}`)
+
+	// THEN
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), "api_new_fan")
+	_, exists := fans.GetFan("api_new_fan")
+	assert.True(t, exists)
+	assert.Len(t, daemon.config.Fans, 2)
+}
+
+func TestCreateFanRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{name: "malformed body", body: `{"id": `, expected: http.StatusBadRequest},
+		{name: "missing id", body: `{"curve": "api_curve", "file": {"path": "/tmp/fan"}}`, expected: http.StatusBadRequest},
+		{name: "unknown curve", body: `{"id": "api_new_fan", "curve": "unknown", "file": {"path": "/tmp/fan"}}`, expected: http.StatusBadRequest},
+		{name: "duplicate id", body: `{"id": "api_fan", "curve": "api_curve", "file": {"path": "/tmp/fan"}}`, expected: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			daemon := newFakeDaemon(t, createTestConfig(t))
+
+			// WHEN
+			rec := performRequest(daemon, http.MethodPost, "/fan/", tt.body)
+
+			// THEN
+			assert.Equal(t, tt.expected, rec.Code)
+			assert.Equal(t, 0, daemon.updates)
+			assert.Len(t, daemon.config.Fans, 1)
+		})
+	}
+}
+
+func TestDeleteFan(t *testing.T) {
+	// GIVEN
+	daemon := newFakeDaemon(t, createTestConfig(t))
+
+	// WHEN
+	rec := performRequest(daemon, http.MethodDelete, "/fan/api_fan/", "")
+
+	// THEN
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	_, exists := fans.GetFan("api_fan")
+	assert.False(t, exists)
+	assert.Empty(t, daemon.config.Fans)
+}
+
+func TestDeleteFanNotFound(t *testing.T) {
+	// GIVEN
+	daemon := newFakeDaemon(t, createTestConfig(t))
+
+	// WHEN
+	rec := performRequest(daemon, http.MethodDelete, "/fan/unknown/", "")
+
+	// THEN
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, 0, daemon.updates)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/markusressel/fan2go/internal/controller"
+	"net/http"
+)
+
+func registerFanGroupEndpoints(rest *echo.Echo) {
+	group := rest.Group("/group")
+
+	group.GET("/", getFanGroups)
+	group.GET("/:"+urlParamId+"/", getFanGroup)
+}
+
+// returns a list of all currently running fan groups
+func getFanGroups(c echo.Context) error {
+	data := map[string]interface{}{}
+	for id, g := range controller.SnapshotFanGroupMap() {
+		groupData, err := withGroupState(g)
+		if err != nil {
+			return returnError(c, err)
+		}
+		data[id] = groupData
+	}
+	return c.JSONPretty(http.StatusOK, data, indentationChar)
+}
+
+func getFanGroup(c echo.Context) error {
+	id := c.Param(urlParamId)
+	g, exists := controller.GetFanGroup(id)
+	if !exists {
+		return returnNotFound(c, id)
+	}
+
+	data, err := withGroupState(g)
+	if err != nil {
+		return returnError(c, err)
+	}
+	return c.JSONPretty(http.StatusOK, data, indentationChar)
+}
+
+// withGroupState adds the decision of the given group and the state of its member fans
+// as of the last iteration of the scheduler to its json representation
+func withGroupState(g *controller.FanGroup) (map[string]interface{}, error) {
+	data, err := toJsonMap(g.GetConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := controller.GetSnapshot()
+	if decision, exists := snapshot.Groups[g.GetId()]; exists {
+		data["decision"] = decision
+	} else {
+		data["decision"] = nil
+	}
+
+	members := map[string]interface{}{}
+	for _, fanId := range g.GetFanIds() {
+		fan, exists := snapshot.Fans[fanId]
+		if !exists {
+			// the fan is not controlled yet
+			continue
+		}
+		member := map[string]interface{}{
+			"pwm":     fan.Pwm,
+			"rpm":     fan.Rpm,
+			"stalled": fan.Statistics.Stalled,
+		}
+		if fc, exists := controller.GetFanController(fanId); exists {
+			member["override"] = fc.GetOverride()
+		}
+		members[fanId] = member
+	}
+	data["members"] = members
+	return data, nil
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/markusressel/fan2go/internal/controller"
+	"github.com/markusressel/fan2go/internal/curves"
+	"github.com/markusressel/fan2go/internal/fans"
+	"net/http"
+	"time"
+)
+
+// OverrideRequest describes a temporary override of the curve of a fan
+type OverrideRequest struct {
+	// Pwm is a fixed pwm value [0..255] that is set on the fan instead of the curve value, without mapping it
+	// to the pwm range of the fan
+	Pwm *int `json:"pwm,omitempty"`
+	// Curve is the id of a curve that is used instead of the fan curve
+	Curve string `json:"curve,omitempty"`
+	// Duration after which the override expires, f.ex. "10m"
+	Duration string `json:"duration"`
+}
+
+// overrides the curve of the given fan until the requested duration has passed
+func setFanOverride(c echo.Context) error {
+	id := c.Param(urlParamId)
+	fanController, exists := controller.GetFanController(id)
+	if !exists {
+		return returnNotFound(c, id)
+	}
+
+	var request OverrideRequest
+	if err := c.Bind(&request); err != nil {
+		return returnBadRequest(c, err)
+	}
+
+	override, err := parseOverrideRequest(request)
+	if err != nil {
+		return returnError(c, err)
+	}
+
+	fanController.SetOverride(override)
+	return c.JSONPretty(http.StatusOK, fanController.GetOverride(), indentationChar)
+}
+
+// removes the currently active override of the given fan, if any
+func clearFanOverride(c echo.Context) error {
+	id := c.Param(urlParamId)
+	fanController, exists := controller.GetFanController(id)
+	if !exists {
+		return returnNotFound(c, id)
+	}
+
+	fanController.ClearOverride()
+	return c.NoContent(http.StatusNoContent)
+}
+
+func parseOverrideRequest(request OverrideRequest) (controller.Override, error) {
+	if (request.Pwm == nil) == (len(request.Curve) <= 0) {
+		return controller.Override{}, newBadRequestError("Exactly one of pwm or curve must be specified")
+	}
+	if request.Pwm != nil && (*request.Pwm < fans.MinPwmValue || *request.Pwm > fans.MaxPwmValue) {
+		return controller.Override{}, newBadRequestError("Invalid pwm value %d, must be in [%d..%d]", *request.Pwm, fans.MinPwmValue, fans.MaxPwmValue)
+	}
+	if len(request.Curve) > 0 {
+		if _, exists := curves.GetSpeedCurve(request.Curve); !exists {
+			return controller.Override{}, newBadRequestError("No curve with id '%s' found", request.Curve)
+		}
+	}
+
+	duration, err := time.ParseDuration(request.Duration)
+	if err != nil {
+		return controller.Override{}, newBadRequestError("Invalid duration '%s': %v", request.Duration, err)
+	}
+	if duration <= 0 {
+		return controller.Override{}, newBadRequestError("Duration must be positive")
+	}
+
+	return controller.Override{
+		Pwm:     request.Pwm,
+		CurveId: request.Curve,
+		Expires: time.Now().Add(duration),
+	}, nil
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/profiles"
+	"net/http"
+	"sort"
+)
+
+// ProfileState describes the currently active profile and all known profiles
+type ProfileState struct {
+	// Active is the id of the currently active profile, empty if none
+	Active string `json:"active"`
+	// Forced is the id of the manually selected profile, empty if none
+	Forced string `json:"forced"`
+	// Triggered is the id of the profile activated by its trigger, empty if none
+	Triggered string                        `json:"triggered"`
+	Profiles  []configuration.ProfileConfig `json:"profiles"`
+}
+
+func registerProfileEndpoints(rest *echo.Echo, daemon Daemon) {
+	group := rest.Group("/profile")
+
+	group.GET("/", getProfiles)
+	group.POST("/:"+urlParamId+"/force/", func(c echo.Context) error {
+		return forceProfile(c, daemon)
+	})
+	group.DELETE("/force/", func(c echo.Context) error {
+		return clearForcedProfile(c, daemon)
+	})
+}
+
+func getProfileState() ProfileState {
+	profileConfigs := profiles.GetProfiles()
+	sort.Slice(profileConfigs, func(i, j int) bool {
+		return profileConfigs[i].ID < profileConfigs[j].ID
+	})
+	return ProfileState{
+		Active:    profiles.GetActiveProfile(),
+		Forced:    profiles.GetForcedProfile(),
+		Triggered: profiles.GetTriggeredProfile(),
+		Profiles:  profileConfigs,
+	}
+}
+
+// returns the currently active profile and all known profiles
+func getProfiles(c echo.Context) error {
+	return c.JSONPretty(http.StatusOK, getProfileState(), indentationChar)
+}
+
+// activates the given profile regardless of triggers and the schedule
+func forceProfile(c echo.Context, daemon Daemon) error {
+	id := c.Param(urlParamId)
+	if err := daemon.ForceProfile(id); err != nil {
+		return returnNotFound(c, id)
+	}
+	return c.JSONPretty(http.StatusOK, getProfileState(), indentationChar)
+}
+
+// hands control over the active profile back to the triggers and the schedule
+func clearForcedProfile(c echo.Context, daemon Daemon) error {
+	daemon.ClearForcedProfile()
+	return c.JSONPretty(http.StatusOK, getProfileState(), indentationChar)
+}
@@ -1,7 +1,11 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/labstack/echo/v4"
+	"github.com/markusressel/fan2go/internal/configuration"
 	"net/http"
 )
 
@@ -17,21 +21,71 @@ type (
 	}
 )
 
-func CreateRestService() *echo.Echo {
+// Daemon provides access to the running fan2go daemon
+type Daemon interface {
+	// Reload re-reads the configuration file and applies all changes
+	Reload() error
+	// UpdateConfig applies the given modification to a copy of the running configuration
+	// and applies the result. If modify returns an error, nothing is changed.
+	UpdateConfig(modify func(config *configuration.Configuration) error) error
+	// ForceProfile activates the profile with the given id regardless of triggers and the schedule
+	ForceProfile(id string) error
+	// ClearForcedProfile hands control over the active profile back to the triggers and the schedule
+	ClearForcedProfile()
+}
+
+// httpError is an error which is reported to the client using a specific status code
+type httpError struct {
+	code    int
+	name    string
+	message string
+}
+
+func (e httpError) Error() string {
+	return e.message
+}
+
+func newBadRequestError(format string, a ...interface{}) error {
+	return httpError{code: http.StatusBadRequest, name: "Bad Request", message: fmt.Sprintf(format, a...)}
+}
+
+func newNotFoundError(id string) error {
+	return httpError{code: http.StatusNotFound, name: "Not found", message: "No item with id '" + id + "' found"}
+}
+
+func newConflictError(format string, a ...interface{}) error {
+	return httpError{code: http.StatusConflict, name: "Conflict", message: fmt.Sprintf(format, a...)}
+}
+
+func CreateRestService(daemon Daemon) *echo.Echo {
 	echoRest := CreateWebserver()
 
 	echoRest.GET("/alive/", isAlive)
 
 	// Authentication
 	// Group level middleware
-	registerFanEndpoints(echoRest)
-	registerSensorEndpoints(echoRest)
-	registerCurveEndpoints(echoRest)
-	//registerWebsocketEndpoint(echoRest)
+	registerFanEndpoints(echoRest, daemon)
+	registerFanGroupEndpoints(echoRest)
+	registerSensorEndpoints(echoRest, daemon)
+	registerCurveEndpoints(echoRest, daemon)
+	registerConfigEndpoints(echoRest, daemon)
+	registerProfileEndpoints(echoRest, daemon)
+	registerStreamEndpoint(echoRest)
 
 	return echoRest
 }
 
+// converts the given value into a generic map, which allows adding additional fields to its json representation
+func toJsonMap(value interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
 // returns an empty "ok" answer
 func isAlive(c echo.Context) error {
 	return c.NoContent(http.StatusOK)
@@ -45,8 +99,24 @@ func returnNotFound(c echo.Context, id string) (err error) {
 	}, indentationChar)
 }
 
-// return the error message of an error
+// return the error message of an error caused by the request
+func returnBadRequest(c echo.Context, e error) (err error) {
+	return c.JSONPretty(http.StatusBadRequest, &Result{
+		Name:    "Bad Request",
+		Message: e.Error(),
+	}, indentationChar)
+}
+
+// return the error message of an error, using the status code of an httpError if possible
 func returnError(c echo.Context, e error) (err error) {
+	var httpErr httpError
+	if errors.As(e, &httpErr) {
+		return c.JSONPretty(httpErr.code, &Result{
+			Name:    httpErr.name,
+			Message: httpErr.message,
+		}, indentationChar)
+	}
+
 	return c.JSONPretty(http.StatusInternalServerError, &Result{
 		Name:    "Unknown Error",
 		Message: e.Error(),
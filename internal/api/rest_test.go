@@ -0,0 +1,128 @@
+package api
+
+import (
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/curves"
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeDaemon applies configuration changes by registering and removing sensors, curves and fans,
+// without running any fan controllers
+type fakeDaemon struct {
+	config configuration.Configuration
+	// number of changes that have been applied
+	updates int
+}
+
+// newFakeDaemon creates a daemon running the given configuration, whose objects are removed after the test
+func newFakeDaemon(t *testing.T, config configuration.Configuration) *fakeDaemon {
+	d := &fakeDaemon{}
+	err := d.UpdateConfig(func(c *configuration.Configuration) error {
+		*c = config
+		return nil
+	})
+	assert.NoError(t, err)
+	d.updates = 0
+
+	t.Cleanup(func() {
+		_ = d.UpdateConfig(func(c *configuration.Configuration) error {
+			*c = configuration.Configuration{}
+			return nil
+		})
+	})
+	return d
+}
+
+func (d *fakeDaemon) Reload() error {
+	return nil
+}
+
+func (d *fakeDaemon) UpdateConfig(modify func(config *configuration.Configuration) error) error {
+	config := d.config
+	config.Sensors = append([]configuration.SensorConfig{}, d.config.Sensors...)
+	config.Curves = append([]configuration.CurveConfig{}, d.config.Curves...)
+	config.Fans = append([]configuration.FanConfig{}, d.config.Fans...)
+	if err := modify(&config); err != nil {
+		return err
+	}
+
+	for _, c := range d.config.Sensors {
+		sensors.RemoveSensor(c.ID)
+	}
+	for _, c := range d.config.Curves {
+		curves.RemoveSpeedCurve(c.ID)
+	}
+	for _, c := range d.config.Fans {
+		fans.RemoveFan(c.ID)
+	}
+	for _, c := range config.Sensors {
+		sensor, err := sensors.NewSensor(c)
+		if err != nil {
+			return err
+		}
+		sensors.RegisterSensor(sensor)
+	}
+	for _, c := range config.Curves {
+		curve, err := curves.NewSpeedCurve(c)
+		if err != nil {
+			return err
+		}
+		curves.RegisterSpeedCurve(curve)
+	}
+	for _, c := range config.Fans {
+		fan, err := fans.NewFan(c)
+		if err != nil {
+			return err
+		}
+		fans.RegisterFan(fan)
+	}
+
+	d.config = config
+	d.updates++
+	return nil
+}
+
+func (d *fakeDaemon) ForceProfile(id string) error {
+	return nil
+}
+
+func (d *fakeDaemon) ClearForcedProfile() {
+}
+
+// createTestConfig creates a configuration with a file sensor, a linear curve using it and a file fan using the curve,
+// whose files are located in a temporary directory
+func createTestConfig(t *testing.T) configuration.Configuration {
+	dir := t.TempDir()
+	sensorPath := filepath.Join(dir, "sensor")
+	fanPath := filepath.Join(dir, "fan")
+	assert.NoError(t, os.WriteFile(sensorPath, []byte("40000"), 0644))
+	assert.NoError(t, os.WriteFile(fanPath, []byte("100"), 0644))
+
+	return configuration.Configuration{
+		Sensors: []configuration.SensorConfig{
+			{ID: "api_sensor", File: &configuration.FileSensorConfig{Path: sensorPath}},
+		},
+		Curves: []configuration.CurveConfig{
+			{ID: "api_curve", Linear: &configuration.LinearCurveConfig{Sensor: "api_sensor", Min: 40, Max: 80}},
+		},
+		Fans: []configuration.FanConfig{
+			{ID: "api_fan", Curve: "api_curve", File: &configuration.FileFanConfig{Path: fanPath}},
+		},
+	}
+}
+
+// performRequest sends a request with the given json body to the rest service of the given daemon
+func performRequest(daemon Daemon, method string, path string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	CreateRestService(daemon).ServeHTTP(rec, req)
+	return rec
+}
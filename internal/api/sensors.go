@@ -1,41 +1,105 @@
 package api
 
 import (
-	"errors"
 	"github.com/labstack/echo/v4"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/controller"
 	"github.com/markusressel/fan2go/internal/sensors"
 	"net/http"
+	"strings"
 )
 
-func registerSensorEndpoints(rest *echo.Echo) {
+func registerSensorEndpoints(rest *echo.Echo, daemon Daemon) {
 	group := rest.Group("/sensor")
 
 	group.GET("/", getSensors)
 	group.GET("/:"+urlParamId+"/", getSensor)
-	group.POST("/", createSensor)
-	group.DELETE("/:"+urlParamId+"/", deleteSensor)
+	group.POST("/", func(c echo.Context) error {
+		return createSensor(c, daemon)
+	})
+	group.DELETE("/:"+urlParamId+"/", func(c echo.Context) error {
+		return deleteSensor(c, daemon)
+	})
 }
 
 func getSensors(c echo.Context) error {
-	data := sensors.SensorMap
+	data := map[string]interface{}{}
+	for id, sensor := range sensors.SnapshotSensorMap() {
+		data[id] = getSensorState(sensor)
+	}
 	return c.JSONPretty(http.StatusOK, data, indentationChar)
 }
 
 func getSensor(c echo.Context) error {
 	id := c.Param(urlParamId)
 
-	data, exists := sensors.SensorMap[id]
+	sensor, exists := sensors.GetSensor(id)
 	if !exists {
 		return returnNotFound(c, id)
-	} else {
-		return c.JSONPretty(http.StatusOK, data, indentationChar)
+	}
+
+	data := getSensorState(sensor)
+	return c.JSONPretty(http.StatusOK, data, indentationChar)
+}
+
+// returns the value and health state of the given sensor as of the last iteration of the scheduler
+func getSensorState(sensor sensors.Sensor) controller.SensorState {
+	if state, exists := controller.GetSnapshot().Sensors[sensor.GetId()]; exists {
+		return state
+	}
+	// the sensor has not been read yet
+	return controller.SensorState{
+		Config: sensor.GetConfig(),
+		Health: sensors.GetHealth(sensor),
 	}
 }
 
-func createSensor(c echo.Context) error {
-	return returnError(c, errors.New("not yet supported"))
+// adds a new sensor and starts monitoring it
+func createSensor(c echo.Context, daemon Daemon) error {
+	var sensorConfig configuration.SensorConfig
+	if err := c.Bind(&sensorConfig); err != nil {
+		return returnBadRequest(c, err)
+	}
+
+	err := daemon.UpdateConfig(func(config *configuration.Configuration) error {
+		if len(sensorConfig.ID) <= 0 {
+			return newBadRequestError("Missing sensor id")
+		}
+		for _, existing := range config.Sensors {
+			if existing.ID == sensorConfig.ID {
+				return newConflictError("Sensor with id '%s' already exists", sensorConfig.ID)
+			}
+		}
+		config.Sensors = append(config.Sensors, sensorConfig)
+		return validateUpdatedConfig(config)
+	})
+	if err != nil {
+		return returnError(c, err)
+	}
+
+	sensor, _ := sensors.GetSensor(sensorConfig.ID)
+	data := getSensorState(sensor)
+	return c.JSONPretty(http.StatusCreated, data, indentationChar)
 }
 
-func deleteSensor(c echo.Context) error {
-	return returnError(c, errors.New("not yet supported"))
+// removes the sensor with the given id, if it is not used by any curve
+func deleteSensor(c echo.Context, daemon Daemon) error {
+	id := c.Param(urlParamId)
+
+	err := daemon.UpdateConfig(func(config *configuration.Configuration) error {
+		if usages := configuration.GetSensorUsages(config, id); len(usages) > 0 {
+			return newConflictError("Sensor '%s' is still used by: %s", id, strings.Join(usages, ", "))
+		}
+		for idx, sensorConfig := range config.Sensors {
+			if sensorConfig.ID == id {
+				config.Sensors = append(config.Sensors[:idx:idx], config.Sensors[idx+1:]...)
+				return validateUpdatedConfig(config)
+			}
+		}
+		return newNotFoundError(id)
+	})
+	if err != nil {
+		return returnError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
 }
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestCreateSensor(t *testing.T) {
+	// GIVEN
+	daemon := newFakeDaemon(t, createTestConfig(t))
+
+	// WHEN
+	rec := performRequest(daemon, http.MethodPost, "/sensor/", `{"id": "api_new_sensor", "file": {"path": "/tmp/sensor"}}`)
+
+	// THEN
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), "api_new_sensor")
+	_, exists := sensors.GetSensor("api_new_sensor")
+	assert.True(t, exists)
+	assert.Len(t, daemon.config.Sensors, 2)
+}
+
+func TestCreateSensorRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{name: "malformed body", body: `{"id": `, expected: http.StatusBadRequest},
+		{name: "missing id", body: `{"file": {"path": "/tmp/sensor"}}`, expected: http.StatusBadRequest},
+		{name: "missing type", body: `{"id": "api_new_sensor"}`, expected: http.StatusBadRequest},
+		{name: "duplicate id", body: `{"id": "api_sensor", "file": {"path": "/tmp/sensor"}}`, expected: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			daemon := newFakeDaemon(t, createTestConfig(t))
+
+			// WHEN
+			rec := performRequest(daemon, http.MethodPost, "/sensor/", tt.body)
+
+			// THEN
+			assert.Equal(t, tt.expected, rec.Code)
+			assert.Equal(t, 0, daemon.updates)
+			assert.Len(t, daemon.config.Sensors, 1)
+		})
+	}
+}
+
+func TestDeleteSensor(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		expected int
+		// number of sensors that remain
+		remaining int
+	}{
+		{name: "unused", id: "api_unused_sensor", expected: http.StatusNoContent, remaining: 1},
+		{name: "used by a curve", id: "api_sensor", expected: http.StatusConflict, remaining: 2},
+		{name: "unknown", id: "unknown", expected: http.StatusNotFound, remaining: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			config := createTestConfig(t)
+			unused := config.Sensors[0]
+			unused.ID = "api_unused_sensor"
+			config.Sensors = append(config.Sensors, unused)
+			daemon := newFakeDaemon(t, config)
+
+			// WHEN
+			rec := performRequest(daemon, http.MethodDelete, "/sensor/"+tt.id+"/", "")
+
+			// THEN
+			assert.Equal(t, tt.expected, rec.Code)
+			assert.Len(t, daemon.config.Sensors, tt.remaining)
+			_, exists := sensors.GetSensor("api_sensor")
+			assert.True(t, exists)
+		})
+	}
+}
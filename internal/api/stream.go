@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/markusressel/fan2go/internal/controller"
+	"github.com/markusressel/fan2go/internal/fans"
+	"net/http"
+	"strings"
+)
+
+const (
+	queryParamFan = "fan"
+	// number of events buffered per client before events are dropped
+	streamBufferSize = 64
+)
+
+func registerStreamEndpoint(rest *echo.Echo) {
+	// open streams would otherwise prevent a graceful shutdown of the server
+	shutdown := make(chan struct{})
+	rest.Server.RegisterOnShutdown(func() {
+		close(shutdown)
+	})
+
+	rest.GET("/stream/", func(c echo.Context) error {
+		return streamTickEvents(c, shutdown)
+	})
+}
+
+// streams one Server-Sent Event per fan controller iteration,
+// optionally filtered using one or more "fan" query parameters
+func streamTickEvents(c echo.Context, shutdown <-chan struct{}) error {
+	fanIds := map[string]bool{}
+	for _, param := range c.QueryParams()[queryParamFan] {
+		for _, id := range strings.Split(param, ",") {
+			if len(id) <= 0 {
+				continue
+			}
+			if _, exists := fans.GetFan(id); !exists {
+				return returnNotFound(c, id)
+			}
+			fanIds[id] = true
+		}
+	}
+
+	events, unsubscribe := controller.SubscribeTickEvents(streamBufferSize)
+	defer unsubscribe()
+
+	response := c.Response()
+	response.Header().Set(echo.HeaderContentType, "text/event-stream")
+	response.Header().Set(echo.HeaderCacheControl, "no-cache")
+	response.Header().Set(echo.HeaderConnection, "keep-alive")
+	response.WriteHeader(http.StatusOK)
+	response.Flush()
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-shutdown:
+			return nil
+		case event := <-events:
+			if len(fanIds) > 0 && !fanIds[event.FanId] {
+				continue
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			if _, err = fmt.Fprintf(response, "event: tick\ndata: %s\n\n", data); err != nil {
+				return err
+			}
+			response.Flush()
+		}
+	}
+}
@@ -7,24 +7,21 @@ import (
 	"github.com/markusressel/fan2go/internal/api"
 	"github.com/markusressel/fan2go/internal/configuration"
 	"github.com/markusressel/fan2go/internal/controller"
-	"github.com/markusressel/fan2go/internal/curves"
-	"github.com/markusressel/fan2go/internal/fans"
-	"github.com/markusressel/fan2go/internal/hwmon"
 	"github.com/markusressel/fan2go/internal/persistence"
-	"github.com/markusressel/fan2go/internal/sensors"
 	"github.com/markusressel/fan2go/internal/statistics"
 	"github.com/markusressel/fan2go/internal/ui"
-	"github.com/markusressel/fan2go/internal/util"
 	"github.com/oklog/run"
 	"net/http"
 	"os"
 	"os/signal"
 	"os/user"
-	"regexp"
 	"syscall"
 	"time"
 )
 
+// profileUpdateInterval is the interval at which the triggers and the schedule of profiles are evaluated
+const profileUpdateInterval = 10 * time.Second
+
 func RunDaemon() {
 	owner, err := getProcessOwner()
 	if err != nil {
@@ -35,11 +32,17 @@ func RunDaemon() {
 
 	pers := persistence.NewPersistence(configuration.CurrentConfig.DbPath)
 
-	fanControllers := initializeObjects(pers)
-
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	registerCollectors()
+
+	d := newDaemon(ctx, pers)
+	err = d.ApplyConfig(configuration.CurrentConfig)
+	if err != nil {
+		ui.Fatal("Unable to start daemon: %v", err)
+	}
+
 	var g run.Group
 	{
 		// === Global Webserver
@@ -47,12 +50,12 @@ func RunDaemon() {
 			g.Add(func() error {
 				ui.Info("Starting Webserver...")
 
-				servers := createWebServer()
+				servers := createWebServer(d)
 
 				select {
 				case <-ctx.Done():
 					ui.Debug("Stopping all webservers...")
-					timeoutCtx, timeoutCancel := context.WithTimeout(ctx, 5*time.Second)
+					timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), 5*time.Second)
 					defer timeoutCancel()
 
 					for _, server := range servers {
@@ -73,60 +76,89 @@ func RunDaemon() {
 		}
 	}
 	{
-		// === sensor monitoring
-		for _, sensor := range sensors.SensorMap {
-			s := sensor
-			pollingRate := configuration.CurrentConfig.TempSensorPollingRate
-			mon := NewSensorMonitor(s, pollingRate)
-
-			g.Add(func() error {
-				err := mon.Run(ctx)
-				ui.Info("Sensor Monitor for sensor %s stopped.", s.GetId())
-				if err != nil {
-					panic(err)
-				}
-				return err
-			}, func(err error) {
-				if err != nil {
-					ui.Warning("Error monitoring sensor: %v", err)
-				}
-			})
-		}
+		// === fan controllers, which initialize their fan and then hand it over to the scheduler
+		g.Add(func() error {
+			<-ctx.Done()
+			d.Wait()
+			return nil
+		}, func(err error) {
+			cancel()
+		})
 	}
 	{
-		// === fan controllers
-		for f, c := range fanControllers {
-			fan := f
-			fanController := c
-			g.Add(func() error {
-				err := fanController.Run(ctx)
-				ui.Info("Fan controller for fan %s stopped.", fan.GetId())
-				if err != nil {
-					ui.NotifyError(fmt.Sprintf("Fan Controller: %s", fan.GetId()), err.Error())
-					panic(err)
-				}
-				return err
-			}, func(err error) {
-				if err != nil {
-					ui.WarningAndNotify(fmt.Sprintf("Fan Controller: %s", fan.GetId()), "Something went wrong: %v", err)
+		// === control loop of all sensors, curves, fan groups and fan controllers
+		scheduler := controller.NewScheduler(
+			configuration.CurrentConfig.ControllerAdjustmentTickRate,
+			configuration.CurrentConfig.TempSensorPollingRate,
+			configuration.CurrentConfig.RpmPollingRate,
+		)
+		g.Add(func() error {
+			return scheduler.Run(ctx)
+		}, func(err error) {
+			cancel()
+		})
+	}
+	if configuration.CurrentConfig.Failsafe != nil {
+		// === critical temperature failsafe
+		monitor := newFailsafeMonitor(*configuration.CurrentConfig.Failsafe, configuration.CurrentConfig.TempSensorPollingRate)
+		g.Add(func() error {
+			return monitor.Run(ctx)
+		}, func(err error) {
+			cancel()
+		})
+	}
+	{
+		// === profile triggers and schedule, evaluated even without any, as they may be added by a reload
+		ticker := time.NewTicker(profileUpdateInterval)
+		g.Add(func() error {
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-ticker.C:
+					d.UpdateProfile()
 				}
-			})
-		}
+			}
+		}, func(err error) {
+			ticker.Stop()
+			cancel()
+		})
+	}
+	{
+		// === configuration reload
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGHUP)
 
-		if len(fans.FanMap) == 0 {
-			ui.Fatal("No valid fan configurations, exiting.")
-		}
+		g.Add(func() error {
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-sig:
+					ui.Info("Received SIGHUP signal, reloading configuration...")
+					if err := d.Reload(); err != nil {
+						ui.ErrorAndNotify("Config Reload Error", "Keeping current configuration: %v", err)
+					}
+				}
+			}
+		}, func(err error) {
+			signal.Stop(sig)
+			cancel()
+		})
 	}
 	{
-		sig := make(chan os.Signal)
+		sig := make(chan os.Signal, 1)
 		signal.Notify(sig, os.Interrupt, syscall.SIGTERM, os.Kill)
 
 		g.Add(func() error {
-			<-sig
-			ui.Info("Received SIGTERM signal, exiting...")
+			select {
+			case <-sig:
+				ui.Info("Received SIGTERM signal, exiting...")
+			case <-ctx.Done():
+			}
 			return nil
 		}, func(err error) {
-			defer close(sig)
+			signal.Stop(sig)
 			cancel()
 		})
 	}
@@ -140,11 +172,11 @@ func RunDaemon() {
 	}
 }
 
-func createWebServer() []*echo.Echo {
+func createWebServer(d api.Daemon) []*echo.Echo {
 	result := []*echo.Echo{}
 	// Setup Main Server
 	if configuration.CurrentConfig.Api.Enabled {
-		result = append(result, startRestServer())
+		result = append(result, startRestServer(d))
 	}
 
 	if configuration.CurrentConfig.Statistics.Enabled {
@@ -154,10 +186,10 @@ func createWebServer() []*echo.Echo {
 	return result
 }
 
-func startRestServer() *echo.Echo {
+func startRestServer(d api.Daemon) *echo.Echo {
 	ui.Info("Starting REST api server...")
 
-	restServer := api.CreateRestService()
+	restServer := api.CreateRestService(d)
 
 	go func() {
 		apiConfig := configuration.CurrentConfig.Api
@@ -188,139 +220,15 @@ func startStatisticsServer() *echo.Echo {
 	return echoPrometheus
 }
 
-func initializeObjects(pers persistence.Persistence) map[fans.Fan]controller.FanController {
-	controllers := hwmon.GetChips()
-
-	initializeSensors(controllers)
-	initializeCurves()
-
-	var result = map[fans.Fan]controller.FanController{}
-
-	for config, fan := range initializeFans(controllers) {
-		updateRate := configuration.CurrentConfig.ControllerAdjustmentTickRate
-
-		var pidLoop util.PidLoop
-		if config.ControlLoop != nil {
-			pidLoop = *util.NewPidLoop(
-				config.ControlLoop.P,
-				config.ControlLoop.I,
-				config.ControlLoop.D,
-			)
-		} else {
-			pidLoop = *util.NewPidLoop(
-				0.03,
-				0.002,
-				0.0005,
-			)
-		}
-		fanController := controller.NewFanController(pers, fan, pidLoop, updateRate)
-		result[fan] = fanController
-	}
-
-	var fanControllers = []controller.FanController{}
-	for _, c := range result {
-		fanControllers = append(fanControllers, c)
-	}
-	controllerCollector := statistics.NewControllerCollector(fanControllers)
-	statistics.Register(controllerCollector)
-
-	return result
-}
-
-func initializeSensors(controllers []*hwmon.HwMonController) {
-	var sensorList []sensors.Sensor
-	for _, config := range configuration.CurrentConfig.Sensors {
-		if config.HwMon != nil {
-			found := false
-			for _, c := range controllers {
-				matched, err := regexp.MatchString("(?i)"+config.HwMon.Platform, c.Platform)
-				if err != nil {
-					ui.Fatal("Failed to match platform regex of %s (%s) against controller platform %s", config.ID, config.HwMon.Platform, c.Platform)
-				}
-				if matched {
-					found = true
-					config.HwMon.TempInput = c.Sensors[config.HwMon.Index].Input
-				}
-			}
-			if !found {
-				ui.Fatal("Couldn't find hwmon device with platform '%s' for sensor: %s. Run 'fan2go detect' again and correct any mistake.", config.HwMon.Platform, config.ID)
-			}
-		}
-
-		sensor, err := sensors.NewSensor(config)
-		if err != nil {
-			ui.Fatal("Unable to process sensor configuration: %s", config.ID)
-		}
-		sensorList = append(sensorList, sensor)
-
-		currentValue, err := sensor.GetValue()
-		if err != nil {
-			ui.Warning("Error reading sensor %s: %v", config.ID, err)
-		}
-		sensor.SetMovingAvg(currentValue)
-
-		sensors.SensorMap[config.ID] = sensor
-	}
-
-	sensorCollector := statistics.NewSensorCollector(sensorList)
-	statistics.Register(sensorCollector)
-}
-
-func initializeCurves() {
-	var curveList []curves.SpeedCurve
-	for _, config := range configuration.CurrentConfig.Curves {
-		curve, err := curves.NewSpeedCurve(config)
-		if err != nil {
-			ui.Fatal("Unable to process curve configuration: %s", config.ID)
-		}
-		curveList = append(curveList, curve)
-		curves.SpeedCurveMap[config.ID] = curve
-	}
-
-	curveCollector := statistics.NewCurveCollector(curveList)
-	statistics.Register(curveCollector)
-}
-
-func initializeFans(controllers []*hwmon.HwMonController) map[configuration.FanConfig]fans.Fan {
-	var result = map[configuration.FanConfig]fans.Fan{}
-
-	var fanList []fans.Fan
-
-	for _, config := range configuration.CurrentConfig.Fans {
-		if config.HwMon != nil {
-			found := false
-			for _, c := range controllers {
-				matched, err := regexp.MatchString("(?i)"+config.HwMon.Platform, c.Platform)
-				if err != nil {
-					ui.Fatal("Failed to match platform regex of %s (%s) against controller platform %s", config.ID, config.HwMon.Platform, c.Platform)
-				}
-				if matched {
-					found = true
-					fan := c.Fans[config.HwMon.Index].Config.HwMon
-					config.HwMon.PwmOutput = fan.PwmOutput
-					config.HwMon.RpmInput = fan.RpmInput
-					break
-				}
-			}
-			if !found {
-				ui.Fatal("Couldn't find hwmon device with platform '%s' for fan: %s", config.HwMon.Platform, config.ID)
-			}
-		}
-
-		fan, err := fans.NewFan(config)
-		if err != nil {
-			ui.Fatal("Unable to process fan configuration of '%s': %v", config.ID, err)
-		}
-		fans.FanMap[config.ID] = fan
-		result[config] = fan
-
-		fanList = append(fanList, fan)
-	}
-
-	fanCollector := statistics.NewFanCollector(fanList)
-	statistics.Register(fanCollector)
-
-	return result
+// registerCollectors registers all prometheus collectors, which read
+// the currently running objects on each collection
+func registerCollectors() {
+	statistics.Register(statistics.NewSensorCollector())
+	statistics.Register(statistics.NewCurveCollector())
+	statistics.Register(statistics.NewFanCollector())
+	statistics.Register(statistics.NewControllerCollector())
+	statistics.Register(statistics.NewFanGroupCollector())
+	statistics.Register(statistics.NewProfileCollector())
 }
 
 func getProcessOwner() (string, error) {
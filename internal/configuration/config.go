@@ -1,10 +1,13 @@
 package configuration
 
 import (
+	"bytes"
 	"github.com/markusressel/fan2go/internal/ui"
 	"github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -26,8 +29,16 @@ type Configuration struct {
 	Sensors []SensorConfig `json:"sensors"`
 	Curves  []CurveConfig  `json:"curves"`
 
+	// FanGroups are fans which are controlled together
+	FanGroups []FanGroupConfig `json:"fanGroups"`
+
+	Profiles []ProfileConfig  `json:"profiles"`
+	Schedule []ScheduleConfig `json:"schedule"`
+
 	Api        ApiConfig        `json:"api"`
 	Statistics StatisticsConfig `json:"statistics"`
+
+	Failsafe *FailsafeConfig `json:"failsafe,omitempty"`
 }
 
 var CurrentConfig Configuration
@@ -54,36 +65,36 @@ func InitConfig(cfgFile string) {
 
 	viper.AutomaticEnv() // read in environment variables that match
 
-	setDefaultValues()
+	setDefaultValues(viper.GetViper())
 }
 
-func setDefaultValues() {
-	viper.SetDefault("dbpath", "/etc/fan2go/fan2go.db")
-	viper.SetDefault("RunFanInitializationInParallel", true)
-	viper.SetDefault("MaxRpmDiffForSettledFan", 10.0)
-	viper.SetDefault("TempSensorPollingRate", 200*time.Millisecond)
-	viper.SetDefault("TempRollingWindowSize", 10)
-	viper.SetDefault("RpmPollingRate", 1*time.Second)
-	viper.SetDefault("RpmRollingWindowSize", 10)
+func setDefaultValues(v *viper.Viper) {
+	v.SetDefault("dbpath", "/etc/fan2go/fan2go.db")
+	v.SetDefault("RunFanInitializationInParallel", true)
+	v.SetDefault("MaxRpmDiffForSettledFan", 10.0)
+	v.SetDefault("TempSensorPollingRate", 200*time.Millisecond)
+	v.SetDefault("TempRollingWindowSize", 10)
+	v.SetDefault("RpmPollingRate", 1*time.Second)
+	v.SetDefault("RpmRollingWindowSize", 10)
 
-	viper.SetDefault("Statistics", StatisticsConfig{
+	v.SetDefault("Statistics", StatisticsConfig{
 		Enabled: false,
 		Port:    9000,
 	})
-	viper.SetDefault("Statistics.Port", 9000)
+	v.SetDefault("Statistics.Port", 9000)
 
-	viper.SetDefault("Api", ApiConfig{
+	v.SetDefault("Api", ApiConfig{
 		Enabled: false,
 		Host:    "localhost",
 		Port:    9001,
 	})
-	viper.SetDefault("Api.Host", "localhost")
-	viper.SetDefault("Api.Port", 9001)
+	v.SetDefault("Api.Host", "localhost")
+	v.SetDefault("Api.Port", 9001)
 
-	viper.SetDefault("ControllerAdjustmentTickRate", 200*time.Millisecond)
+	v.SetDefault("ControllerAdjustmentTickRate", 200*time.Millisecond)
 
-	viper.SetDefault("sensors", []SensorConfig{})
-	viper.SetDefault("fans", []FanConfig{})
+	v.SetDefault("sensors", []SensorConfig{})
+	v.SetDefault("fans", []FanConfig{})
 }
 
 // DetectAndReadConfigFile detects the path of the first existing config file
@@ -105,6 +116,43 @@ func GetFilePath() string {
 	return viper.ConfigFileUsed()
 }
 
+// ParsedConfigFile is the content of a config file, which has been parsed without modifying the global configuration
+type ParsedConfigFile struct {
+	Config Configuration
+	// the raw content of the file
+	data []byte
+}
+
+// ReadConfigFile re-reads the config file and parses it into a new Configuration,
+// without modifying CurrentConfig or the values of the global viper instance
+func ReadConfigFile() (*ParsedConfigFile, error) {
+	path := GetFilePath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	v := viper.New()
+	v.SetConfigType(strings.TrimPrefix(filepath.Ext(path), "."))
+	v.AutomaticEnv()
+	setDefaultValues(v)
+	if err = v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	result := &ParsedConfigFile{data: data}
+	if err = v.Unmarshal(&result.Config); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Commit makes the global viper instance use the values of the parsed config file.
+// This should only be done once the configuration has been applied successfully.
+func (f *ParsedConfigFile) Commit() error {
+	return viper.ReadConfig(bytes.NewReader(f.data))
+}
+
 func LoadConfig() {
 	// load default configuration values
 	err := viper.Unmarshal(&CurrentConfig)
@@ -0,0 +1,40 @@
+package configuration
+
+import (
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadConfigFileDoesNotModifyGlobalValues(t *testing.T) {
+	// GIVEN
+	path := filepath.Join(t.TempDir(), "fan2go.yaml")
+	err := os.WriteFile(path, []byte("dbPath: /tmp/old.db\n"), 0644)
+	assert.NoError(t, err)
+	InitConfig(path)
+	defer viper.Reset()
+	ReadInConfig()
+
+	err = os.WriteFile(path, []byte("dbPath: /tmp/new.db\nrpmPollingRate: 2s\n"), 0644)
+	assert.NoError(t, err)
+
+	// WHEN
+	configFile, err := ReadConfigFile()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, "/tmp/new.db", configFile.Config.DbPath)
+	assert.Equal(t, 2*time.Second, configFile.Config.RpmPollingRate)
+	assert.Equal(t, 10, configFile.Config.TempRollingWindowSize)
+	assert.Equal(t, "/tmp/old.db", viper.GetString("dbPath"))
+
+	// WHEN
+	err = configFile.Commit()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, "/tmp/new.db", viper.GetString("dbPath"))
+}
@@ -1,10 +1,17 @@
 package configuration
 
+import (
+	"github.com/markusressel/fan2go/internal/expression"
+	"time"
+)
+
 type CurveConfig struct {
-	ID       string               `json:"id"`
-	Linear   *LinearCurveConfig   `json:"linear,omitempty"`
-	PID      *PidCurveConfig      `json:"pid,omitempty"`
-	Function *FunctionCurveConfig `json:"function,omitempty"`
+	ID         string                 `json:"id"`
+	Linear     *LinearCurveConfig     `json:"linear,omitempty"`
+	PID        *PidCurveConfig        `json:"pid,omitempty"`
+	Function   *FunctionCurveConfig   `json:"function,omitempty"`
+	Derivative *DerivativeCurveConfig `json:"derivative,omitempty"`
+	Expression *ExpressionCurveConfig `json:"expression,omitempty"`
 }
 
 type LinearCurveConfig struct {
@@ -12,6 +19,8 @@ type LinearCurveConfig struct {
 	Min    int             `json:"min"`
 	Max    int             `json:"max"`
 	Steps  map[int]float64 `json:"steps"`
+	// Interpolation is used between steps, one of: linear | cubic | monotone | step, defaults to linear
+	Interpolation string `json:"interpolation,omitempty"`
 }
 
 type PidCurveConfig struct {
@@ -20,16 +29,76 @@ type PidCurveConfig struct {
 	P        float64 `json:"p"`
 	I        float64 `json:"i"`
 	D        float64 `json:"d"`
+	// DerivativeFilter is the time constant of a low-pass filter applied to the derivative, disabled if 0
+	DerivativeFilter time.Duration `json:"derivativeFilter,omitempty"`
+	// OutputMin is the lowest value of the curve, defaults to 0
+	OutputMin *int `json:"outputMin,omitempty"`
+	// OutputMax is the highest value of the curve, defaults to 255
+	OutputMax *int `json:"outputMax,omitempty"`
+	// Reverse calculates the error as measured - setPoint instead of setPoint - measured,
+	// which allows using positive constants to increase the speed above the setPoint
+	Reverse bool `json:"reverse,omitempty"`
+}
+
+// GetOutputRange returns the configured output range of the curve, or the defaults
+func (c PidCurveConfig) GetOutputRange() (min int, max int) {
+	min, max = 0, 255
+	if c.OutputMin != nil {
+		min = *c.OutputMin
+	}
+	if c.OutputMax != nil {
+		max = *c.OutputMax
+	}
+	return min, max
+}
+
+type DerivativeCurveConfig struct {
+	Sensor string `json:"sensor"`
+	// Window is the time span of sensor values used to calculate the rate of change
+	Window time.Duration `json:"window"`
+	// Min is the rate of change (in °C/s) at or below which the curve is at minimum speed
+	Min float64 `json:"min"`
+	// Max is the rate of change (in °C/s) at or above which the curve is at maximum speed
+	Max float64 `json:"max"`
 }
 
+const (
+	// DefaultDerivativeWindow is the window of a derivative curve, if not configured otherwise
+	DefaultDerivativeWindow = 10 * time.Second
+	// MaxDerivativeWindow is the longest window supported by derivative curves, limited by the sensor history
+	MaxDerivativeWindow = 5 * time.Minute
+)
+
 const (
 	FunctionAverage = "average"
 	FunctionDelta   = "delta"
 	FunctionMinimum = "minimum"
 	FunctionMaximum = "maximum"
+	// FunctionWeighted calculates the average of all curves using the given weights
+	FunctionWeighted = "weighted"
+	// FunctionSum adds the values of all curves, limited to 255
+	FunctionSum    = "sum"
+	FunctionMedian = "median"
 )
 
 type FunctionCurveConfig struct {
 	Type   string   `json:"type"`
 	Curves []string `json:"curves"`
+	// Weights contains the weight of each curve in Curves (in the same order), only used by the weighted function
+	Weights []float64 `json:"weights,omitempty"`
+}
+
+type ExpressionCurveConfig struct {
+	// Formula is evaluated using the values of the referenced sensors (in °C) and curves (0..255)
+	Formula string `json:"formula"`
+}
+
+// GetReferencedIds returns the ids of all sensors and curves used in the formula,
+// or nil if it is invalid
+func (c ExpressionCurveConfig) GetReferencedIds() []string {
+	parsed, err := expression.Parse(c.Formula)
+	if err != nil {
+		return nil
+	}
+	return parsed.Variables()
 }
@@ -0,0 +1,12 @@
+package configuration
+
+type FailsafeConfig struct {
+	// CriticalTemp is the temperature (in °C) of any sensor at which all fans are set to their max speed
+	CriticalTemp float64 `json:"criticalTemp"`
+	// RecoveryTemp is the temperature (in °C) all sensors have to fall below to resume normal control
+	RecoveryTemp float64 `json:"recoveryTemp"`
+	// Sensors is a list of sensor ids to monitor, all sensors are monitored if empty
+	Sensors []string `json:"sensors"`
+	// Exec is an optional command that is run when the critical temperature is reached
+	Exec *ExecConfig `json:"exec,omitempty"`
+}
@@ -1,5 +1,7 @@
 package configuration
 
+import "time"
+
 type FanConfig struct {
 	ID        string `json:"id"`
 	NeverStop bool   `json:"neverStop"`
@@ -15,8 +17,94 @@ type FanConfig struct {
 	File        *FileFanConfig     `json:"file,omitempty"`
 	Cmd         *CmdFanConfig      `json:"cmd,omitempty"`
 	ControlLoop *ControlLoopConfig `json:"controlLoop,omitempty"`
+	// RpmTarget interprets the curve value as a percentage of the max RPM of the fan instead of a PWM value
+	RpmTarget bool `json:"rpmTarget"`
+	// Hysteresis is the minimum change (in °C) of the sensors used by the curve required to change the fan speed
+	Hysteresis float64 `json:"hysteresis"`
+	// MinDirectionChangeInterval is the minimum time between the last speed change and a change in the opposite direction
+	MinDirectionChangeInterval time.Duration `json:"minDirectionChangeInterval"`
+	// RampUp is the maximum increase of the PWM value per second, 0 means unlimited
+	RampUp float64 `json:"rampUp"`
+	// RampDown is the maximum decrease of the PWM value per second, 0 means unlimited
+	RampDown float64 `json:"rampDown"`
+	// OnSensorFailure defines what happens if a sensor used by the curve of this fan is stale,
+	// one of: maxSpeed | holdLast | auto
+	OnSensorFailure string `json:"onSensorFailure"`
+	// StallDetection enables detection of a dead or blocked fan, if set
+	StallDetection *StallDetectionConfig `json:"stallDetection,omitempty"`
+	// Recalibration enables gradual updates of the measured fan curve while the fan is controlled, if set
+	Recalibration *RecalibrationConfig `json:"recalibration,omitempty"`
+	// Sweep defines which pwm values are measured during the initialization of the fan, all of them if not set
+	Sweep *SweepConfig `json:"sweep,omitempty"`
+}
+
+type StallDetectionConfig struct {
+	// Threshold is the ratio [0..1] of the expected RPM below which the fan is considered stalled
+	Threshold float64 `json:"threshold"`
+	// Duration is the time the RPM has to stay below the threshold before the fan is considered stalled
+	Duration time.Duration `json:"duration"`
+	// Action is applied to all other fans while this fan is stalled, one of: none | maxSpeed
+	Action string `json:"action"`
+}
+
+type RecalibrationConfig struct {
+	// Smoothing is the weight (0..1] of a new RPM sample compared to the stored RPM of the same PWM value
+	Smoothing float64 `json:"smoothing"`
+	// SettleTime is the time the PWM value has to stay unchanged, before the RPM of the fan is sampled
+	SettleTime time.Duration `json:"settleTime"`
+	// SaveInterval is the time between two saves of the updated fan curve
+	SaveInterval time.Duration `json:"saveInterval"`
+}
+
+type SweepConfig struct {
+	// Strategy defines how the pwm values to measure are selected, one of: full | coarse | adaptive
+	Strategy string `json:"strategy"`
+	// Steps is the number of pwm values measured by the coarse strategy,
+	// and the number of pwm values the adaptive strategy starts with
+	Steps int `json:"steps"`
+	// Tolerance is the difference (in RPM) between a measured and an interpolated value,
+	// above which the adaptive strategy measures additional pwm values in between
+	Tolerance float64 `json:"tolerance"`
 }
 
+const (
+	// SweepStrategyFull measures every distinct pwm value
+	SweepStrategyFull = "full"
+	// SweepStrategyCoarse measures evenly spaced pwm values and interpolates the values in between
+	SweepStrategyCoarse = "coarse"
+	// SweepStrategyAdaptive starts like SweepStrategyCoarse, but measures additional pwm values
+	// where the RPM doesn't change linearly
+	SweepStrategyAdaptive = "adaptive"
+
+	DefaultSweepSteps     = 8
+	DefaultSweepTolerance = 50.0
+)
+
+const (
+	DefaultRecalibrationSmoothing    = 0.05
+	DefaultRecalibrationSettleTime   = 10 * time.Second
+	DefaultRecalibrationSaveInterval = 30 * time.Minute
+)
+
+const (
+	// SensorFailureMaxSpeed sets the fan to its max speed
+	SensorFailureMaxSpeed = "maxSpeed"
+	// SensorFailureHoldLast keeps the last pwm value of the fan
+	SensorFailureHoldLast = "holdLast"
+	// SensorFailureAuto hands control of the fan back to the mainboard
+	SensorFailureAuto = "auto"
+)
+
+const (
+	// StallActionNone only notifies about a stalled fan
+	StallActionNone = "none"
+	// StallActionMaxSpeed sets all other fans to their max speed while a fan is stalled
+	StallActionMaxSpeed = "maxSpeed"
+
+	DefaultStallThreshold = 0.5
+	DefaultStallDuration  = 10 * time.Second
+)
+
 type HwMonFanConfig struct {
 	Platform  string `json:"platform"`
 	Index     int    `json:"index"`
@@ -0,0 +1,38 @@
+package configuration
+
+type FanGroupConfig struct {
+	ID string `json:"id"`
+	// Fans is a list of fans which are controlled together, using the same curve value in every iteration
+	Fans []string `json:"fans"`
+	// Curve is the id of the curve used to control all member fans,
+	// defaults to the curve of the member fans, which has to be the same for all of them in this case
+	Curve string `json:"curve"`
+	// TargetRpm interprets the curve value as a percentage of the max RPM of the slowest member fan,
+	// so all member fans spin at the same RPM
+	TargetRpm bool `json:"targetRpm"`
+}
+
+// GetCurveId returns the id of the curve used to control all member fans
+func (c FanGroupConfig) GetCurveId(fans []FanConfig) string {
+	if len(c.Curve) > 0 || len(c.Fans) <= 0 {
+		return c.Curve
+	}
+	for _, fanConfig := range fans {
+		if fanConfig.ID == c.Fans[0] {
+			return fanConfig.Curve
+		}
+	}
+	return ""
+}
+
+// FindFanGroup returns the group the fan with the given id is a member of, if any
+func FindFanGroup(groups []FanGroupConfig, fanId string) (FanGroupConfig, bool) {
+	for _, group := range groups {
+		for _, member := range group.Fans {
+			if member == fanId {
+				return group, true
+			}
+		}
+	}
+	return FanGroupConfig{}, false
+}
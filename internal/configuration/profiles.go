@@ -0,0 +1,62 @@
+package configuration
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type ProfileConfig struct {
+	ID string `json:"id"`
+	// Fans is a list of fans whose curve is replaced while this profile is active,
+	// fans which are not listed keep using their own curve
+	Fans []ProfileFanConfig `json:"fans"`
+	// Trigger activates this profile automatically while its conditions are met, if set
+	Trigger *ProfileTriggerConfig `json:"trigger,omitempty"`
+}
+
+type ProfileTriggerConfig struct {
+	// Processes is a list of process names, the profile is activated while any of them is running
+	Processes []string `json:"processes"`
+	// Load is the 1 minute system load average above which the profile is activated, 0 disables this condition
+	Load float64 `json:"load"`
+	// CoolDown is the time the profile stays active after its conditions are no longer met
+	CoolDown time.Duration `json:"coolDown"`
+}
+
+type ProfileFanConfig struct {
+	Fan   string `json:"fan"`
+	Curve string `json:"curve"`
+}
+
+type ScheduleConfig struct {
+	// Profile is the id of the profile that is active during this time frame
+	Profile string `json:"profile"`
+	// From is the time of day (HH:MM) at which the time frame starts
+	From string `json:"from"`
+	// To is the time of day (HH:MM) at which the time frame ends, may be earlier than From to span midnight
+	To string `json:"to"`
+	// Days is a list of weekdays (mon, tue, ...) on which the time frame starts, every day if empty
+	Days []string `json:"days"`
+}
+
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseTimeOfDay parses a time of day in the format HH:MM and returns the duration since midnight
+func ParseTimeOfDay(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day '%s', expected format HH:MM", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// ParseWeekday parses the abbreviated name of a weekday (mon, tue, ...)
+func ParseWeekday(value string) (time.Weekday, error) {
+	for i, day := range weekdays {
+		if strings.EqualFold(value, day) {
+			return time.Weekday(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday '%s', use one of: %s", value, strings.Join(weekdays, " | "))
+}
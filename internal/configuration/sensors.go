@@ -1,10 +1,16 @@
 package configuration
 
+import "time"
+
 type SensorConfig struct {
 	ID    string             `json:"id"`
 	HwMon *HwMonSensorConfig `json:"hwMon,omitempty"`
 	File  *FileSensorConfig  `json:"file,omitempty"`
 	Cmd   *CmdSensorConfig   `json:"cmd,omitempty"`
+	// MaxFailedReads is the number of consecutive failed reads after which the sensor is considered stale
+	MaxFailedReads int `json:"maxFailedReads,omitempty"`
+	// StaleTimeout is the time after which the sensor is considered stale if its value didn't change, 0 disables this
+	StaleTimeout time.Duration `json:"staleTimeout,omitempty"`
 }
 
 type HwMonSensorConfig struct {
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/looplab/tarjan"
+	"github.com/markusressel/fan2go/internal/expression"
 	"github.com/markusressel/fan2go/internal/ui"
 	"github.com/markusressel/fan2go/internal/util"
 	"golang.org/x/exp/slices"
@@ -14,6 +15,11 @@ func Validate(configPath string) error {
 	return validateConfig(&CurrentConfig, configPath)
 }
 
+// ValidateConfig validates the given configuration, which does not have to be the CurrentConfig
+func ValidateConfig(config *Configuration, configPath string) error {
+	return validateConfig(config, configPath)
+}
+
 func validateConfig(config *Configuration, path string) error {
 	err := validateSensors(config)
 	if err != nil {
@@ -24,8 +30,20 @@ func validateConfig(config *Configuration, path string) error {
 		return err
 	}
 	err = validateFans(config)
+	if err != nil {
+		return err
+	}
+	err = validateFanGroups(config)
+	if err != nil {
+		return err
+	}
+	err = validateFailsafe(config)
+	if err != nil {
+		return err
+	}
+	err = validateProfiles(config)
 
-	if containsCmdSensors() || containsCmdFan() {
+	if containsCmdSensors(config) || containsCmdFan(config) || containsFailsafeCmd(config) {
 		if _, err := util.CheckFilePermissionsForExecution(path); err != nil {
 			return errors.New(fmt.Sprintf("Config file '%s' has invalid permissions: %s", path, err))
 		}
@@ -34,8 +52,8 @@ func validateConfig(config *Configuration, path string) error {
 	return err
 }
 
-func containsCmdFan() bool {
-	for _, fanConfig := range CurrentConfig.Fans {
+func containsCmdFan(config *Configuration) bool {
+	for _, fanConfig := range config.Fans {
 		if fanConfig.Cmd != nil {
 			return true
 		}
@@ -44,8 +62,12 @@ func containsCmdFan() bool {
 	return false
 }
 
-func containsCmdSensors() bool {
-	for _, sensorConfig := range CurrentConfig.Sensors {
+func containsFailsafeCmd(config *Configuration) bool {
+	return config.Failsafe != nil && config.Failsafe.Exec != nil
+}
+
+func containsCmdSensors(config *Configuration) bool {
+	for _, sensorConfig := range config.Sensors {
 		if sensorConfig.Cmd != nil {
 			return true
 		}
@@ -84,6 +106,13 @@ func validateSensors(config *Configuration) error {
 			ui.Warning("Unused sensor configuration: %s", sensorConfig.ID)
 		}
 
+		if sensorConfig.MaxFailedReads < 0 {
+			return errors.New(fmt.Sprintf("Sensor %s: maxFailedReads must be >= 0", sensorConfig.ID))
+		}
+		if sensorConfig.StaleTimeout < 0 {
+			return errors.New(fmt.Sprintf("Sensor %s: staleTimeout must be >= 0", sensorConfig.ID))
+		}
+
 		if sensorConfig.HwMon != nil {
 			if sensorConfig.HwMon.Index <= 0 {
 				return errors.New(fmt.Sprintf("Sensor %s: invalid index, must be >= 1", sensorConfig.ID))
@@ -95,20 +124,40 @@ func validateSensors(config *Configuration) error {
 }
 
 func isSensorConfigInUse(config SensorConfig, curves []CurveConfig) bool {
+	return len(getSensorUsages(config.ID, curves)) > 0
+}
+
+// GetSensorUsages returns the ids of all curves which reference the sensor with the given id,
+// as well as "failsafe" if the failsafe explicitly monitors it
+func GetSensorUsages(config *Configuration, sensorId string) []string {
+	result := getSensorUsages(sensorId, config.Curves)
+	if config.Failsafe != nil && slices.Contains(config.Failsafe.Sensors, sensorId) {
+		result = append(result, "failsafe")
+	}
+	return result
+}
+
+func getSensorUsages(sensorId string, curves []CurveConfig) []string {
+	var result []string
 	for _, curveConfig := range curves {
 		if curveConfig.Function != nil {
 			// function curves cannot reference sensors
 			continue
 		}
-		if curveConfig.Linear != nil && curveConfig.Linear.Sensor == config.ID {
-			return true
+		if curveConfig.Derivative != nil && curveConfig.Derivative.Sensor == sensorId {
+			result = append(result, curveConfig.ID)
 		}
-		if curveConfig.PID != nil && curveConfig.PID.Sensor == config.ID {
-			return true
+		if curveConfig.Expression != nil && util.ContainsString(curveConfig.Expression.GetReferencedIds(), sensorId) {
+			result = append(result, curveConfig.ID)
+		}
+		if curveConfig.Linear != nil && curveConfig.Linear.Sensor == sensorId {
+			result = append(result, curveConfig.ID)
+		}
+		if curveConfig.PID != nil && curveConfig.PID.Sensor == sensorId {
+			result = append(result, curveConfig.ID)
 		}
 	}
-
-	return false
+	return result
 }
 
 func validateCurves(config *Configuration) error {
@@ -131,23 +180,36 @@ func validateCurves(config *Configuration) error {
 		if curveConfig.Function != nil {
 			subConfigs++
 		}
+		if curveConfig.Derivative != nil {
+			subConfigs++
+		}
+		if curveConfig.Expression != nil {
+			subConfigs++
+		}
 		if subConfigs > 1 {
 			return errors.New(fmt.Sprintf("Curve %s: only one curve type can be used per curve definition block", curveConfig.ID))
 		}
 		if subConfigs <= 0 {
-			return errors.New(fmt.Sprintf("Curve %s: sub-configuration for curve is missing, use one of: linear | pid | function", curveConfig.ID))
+			return errors.New(fmt.Sprintf("Curve %s: sub-configuration for curve is missing, use one of: linear | pid | function | derivative | expression", curveConfig.ID))
 		}
 
-		if !isCurveConfigInUse(curveConfig, config.Curves, config.Fans) {
+		if !isCurveConfigInUse(curveConfig, config.Curves, config.Fans, config.FanGroups, config.Profiles) {
 			ui.Warning("Unused curve configuration: %s", curveConfig.ID)
 		}
 
 		if curveConfig.Function != nil {
-			supportedTypes := []string{FunctionMinimum, FunctionAverage, FunctionMaximum, FunctionDelta}
+			supportedTypes := []string{FunctionMinimum, FunctionAverage, FunctionMaximum, FunctionDelta, FunctionWeighted, FunctionSum, FunctionMedian}
 			if !slices.Contains(supportedTypes, curveConfig.Function.Type) {
 				return errors.New(fmt.Sprintf("Curve %s: unsupported function type '%s', use one of: %s", curveConfig.ID, curveConfig.Function.Type, strings.Join(supportedTypes, " | ")))
 			}
 
+			if curveConfig.Function.Type == FunctionWeighted {
+				err := validateFunctionWeights(curveConfig.ID, curveConfig.Function)
+				if err != nil {
+					return err
+				}
+			}
+
 			var connections []interface{}
 			for _, curve := range curveConfig.Function.Curves {
 				if curve == curveConfig.ID {
@@ -161,6 +223,32 @@ func validateCurves(config *Configuration) error {
 			graph[curveConfig.ID] = connections
 		}
 
+		if curveConfig.Expression != nil {
+			parsed, err := expression.Parse(curveConfig.Expression.Formula)
+			if err != nil {
+				return errors.New(fmt.Sprintf("Curve %s: invalid expression: %v", curveConfig.ID, err))
+			}
+
+			var connections []interface{}
+			for _, id := range parsed.Variables() {
+				if id == curveConfig.ID {
+					return errors.New(fmt.Sprintf("Curve %s: a curve cannot reference itself", curveConfig.ID))
+				}
+				isSensor := sensorIdExists(id, config)
+				isCurve := curveIdExists(id, config)
+				if isSensor && isCurve {
+					return errors.New(fmt.Sprintf("Curve %s: '%s' is ambiguous, there is a sensor and a curve with this id", curveConfig.ID, id))
+				}
+				if !isSensor && !isCurve {
+					return errors.New(fmt.Sprintf("Curve %s: no sensor or curve definition with id '%s' found", curveConfig.ID, id))
+				}
+				if isCurve {
+					connections = append(connections, id)
+				}
+			}
+			graph[curveConfig.ID] = connections
+		}
+
 		if curveConfig.Linear != nil {
 			if len(curveConfig.Linear.Sensor) <= 0 {
 				return errors.New(fmt.Sprintf("Curve %s: Missing sensorId", curveConfig.ID))
@@ -169,6 +257,12 @@ func validateCurves(config *Configuration) error {
 			if !sensorIdExists(curveConfig.Linear.Sensor, config) {
 				return errors.New(fmt.Sprintf("Curve %s: no sensor definition with id '%s' found", curveConfig.ID, curveConfig.Linear.Sensor))
 			}
+
+			supportedInterpolations := []string{util.InterpolationTypeLinear, util.InterpolationTypeCubic, util.InterpolationTypeMonotone, util.InterpolationTypeStep}
+			interpolation := curveConfig.Linear.Interpolation
+			if len(interpolation) > 0 && !slices.Contains(supportedInterpolations, interpolation) {
+				return errors.New(fmt.Sprintf("Curve %s: unsupported interpolation '%s', use one of: %s", curveConfig.ID, interpolation, strings.Join(supportedInterpolations, " | ")))
+			}
 		}
 
 		if curveConfig.PID != nil {
@@ -184,6 +278,34 @@ func validateCurves(config *Configuration) error {
 			if pidConfig.P == 0 && pidConfig.I == 0 && pidConfig.D == 0 {
 				return errors.New(fmt.Sprintf("Curve %s: all PID constants are zero", curveConfig.ID))
 			}
+
+			if pidConfig.DerivativeFilter < 0 {
+				return errors.New(fmt.Sprintf("Curve %s: derivativeFilter must not be negative", curveConfig.ID))
+			}
+
+			outputMin, outputMax := pidConfig.GetOutputRange()
+			if outputMin < 0 || outputMax > 255 || outputMin >= outputMax {
+				return errors.New(fmt.Sprintf("Curve %s: outputMin and outputMax must be in range [0..255] and outputMin must be less than outputMax", curveConfig.ID))
+			}
+		}
+
+		if curveConfig.Derivative != nil {
+			derivativeConfig := curveConfig.Derivative
+			if len(derivativeConfig.Sensor) <= 0 {
+				return errors.New(fmt.Sprintf("Curve %s: Missing sensorId", curveConfig.ID))
+			}
+
+			if !sensorIdExists(derivativeConfig.Sensor, config) {
+				return errors.New(fmt.Sprintf("Curve %s: no sensor definition with id '%s' found", curveConfig.ID, derivativeConfig.Sensor))
+			}
+
+			if derivativeConfig.Window < 0 || derivativeConfig.Window > MaxDerivativeWindow {
+				return errors.New(fmt.Sprintf("Curve %s: window must be between 0 and %s", curveConfig.ID, MaxDerivativeWindow))
+			}
+
+			if derivativeConfig.Max <= derivativeConfig.Min {
+				return errors.New(fmt.Sprintf("Curve %s: max must be greater than min", curveConfig.ID))
+			}
 		}
 
 	}
@@ -192,6 +314,23 @@ func validateCurves(config *Configuration) error {
 	return err
 }
 
+func validateFunctionWeights(curveId string, config *FunctionCurveConfig) error {
+	if len(config.Weights) != len(config.Curves) {
+		return errors.New(fmt.Sprintf("Curve %s: the weighted function requires exactly one weight per curve", curveId))
+	}
+	var totalWeight float64
+	for _, weight := range config.Weights {
+		if weight < 0 {
+			return errors.New(fmt.Sprintf("Curve %s: weights must not be negative", curveId))
+		}
+		totalWeight += weight
+	}
+	if totalWeight <= 0 {
+		return errors.New(fmt.Sprintf("Curve %s: at least one weight must be greater than zero", curveId))
+	}
+	return nil
+}
+
 func sensorIdExists(sensorId string, config *Configuration) bool {
 	for _, sensor := range config.Sensors {
 		if sensor.ID == sensorId {
@@ -212,22 +351,48 @@ func validateNoLoops(graph map[interface{}][]interface{}) error {
 	return nil
 }
 
-func isCurveConfigInUse(config CurveConfig, curves []CurveConfig, fans []FanConfig) bool {
+func isCurveConfigInUse(config CurveConfig, curves []CurveConfig, fans []FanConfig, groups []FanGroupConfig, profiles []ProfileConfig) bool {
+	return len(getCurveUsages(config.ID, curves, fans, groups, profiles)) > 0
+}
+
+// GetCurveUsages returns the ids of all curves, fans, fan groups and profiles which reference the curve with the given id
+func GetCurveUsages(config *Configuration, curveId string) []string {
+	return getCurveUsages(curveId, config.Curves, config.Fans, config.FanGroups, config.Profiles)
+}
+
+func getCurveUsages(curveId string, curves []CurveConfig, fans []FanConfig, groups []FanGroupConfig, profiles []ProfileConfig) []string {
+	var result []string
 	for _, curveConfig := range curves {
 		if curveConfig.Function != nil {
-			if util.ContainsString(curveConfig.Function.Curves, config.ID) {
-				return true
+			if util.ContainsString(curveConfig.Function.Curves, curveId) {
+				result = append(result, curveConfig.ID)
+			}
+		}
+		if curveConfig.Expression != nil {
+			if util.ContainsString(curveConfig.Expression.GetReferencedIds(), curveId) {
+				result = append(result, curveConfig.ID)
 			}
 		}
 	}
-
 	for _, fanConfig := range fans {
-		if fanConfig.Curve == config.ID {
-			return true
+		if fanConfig.Curve == curveId {
+			result = append(result, fanConfig.ID)
 		}
 	}
-
-	return false
+	for _, groupConfig := range groups {
+		if groupConfig.Curve == curveId {
+			result = append(result, groupConfig.ID)
+		}
+	}
+	for _, profileConfig := range profiles {
+		for _, profileFan := range profileConfig.Fans {
+			if profileFan.Curve == curveId {
+				result = append(result, profileConfig.ID)
+				break
+			}
+		}
+	}
+	return result
 }
 
 func validateFans(config *Configuration) error {
@@ -265,6 +430,76 @@ func validateFans(config *Configuration) error {
 			return errors.New(fmt.Sprintf("Fan %s: no curve definition with id '%s' found", fanConfig.ID, fanConfig.Curve))
 		}
 
+		if fanConfig.Hysteresis < 0 {
+			return errors.New(fmt.Sprintf("Fan %s: hysteresis must be >= 0", fanConfig.ID))
+		}
+
+		if fanConfig.MinDirectionChangeInterval < 0 {
+			return errors.New(fmt.Sprintf("Fan %s: minDirectionChangeInterval must be >= 0", fanConfig.ID))
+		}
+
+		if fanConfig.RampUp < 0 {
+			return errors.New(fmt.Sprintf("Fan %s: rampUp must be >= 0", fanConfig.ID))
+		}
+
+		if fanConfig.RampDown < 0 {
+			return errors.New(fmt.Sprintf("Fan %s: rampDown must be >= 0", fanConfig.ID))
+		}
+
+		if fanConfig.StallDetection != nil {
+			stallConfig := fanConfig.StallDetection
+			if stallConfig.Threshold < 0 || stallConfig.Threshold > 1 {
+				return errors.New(fmt.Sprintf("Fan %s: stallDetection threshold must be within [0..1]", fanConfig.ID))
+			}
+			if stallConfig.Duration < 0 {
+				return errors.New(fmt.Sprintf("Fan %s: stallDetection duration must be >= 0", fanConfig.ID))
+			}
+			supportedActions := []string{StallActionNone, StallActionMaxSpeed}
+			if len(stallConfig.Action) > 0 && !slices.Contains(supportedActions, stallConfig.Action) {
+				return errors.New(fmt.Sprintf("Fan %s: unsupported stallDetection action '%s', use one of: %s", fanConfig.ID, stallConfig.Action, strings.Join(supportedActions, " | ")))
+			}
+			if fanConfig.File != nil || (fanConfig.Cmd != nil && fanConfig.Cmd.GetRpm == nil) {
+				return errors.New(fmt.Sprintf("Fan %s: stallDetection requires an RPM sensor", fanConfig.ID))
+			}
+		}
+
+		if fanConfig.Recalibration != nil {
+			recalibrationConfig := fanConfig.Recalibration
+			if recalibrationConfig.Smoothing < 0 || recalibrationConfig.Smoothing > 1 {
+				return errors.New(fmt.Sprintf("Fan %s: recalibration smoothing must be within [0..1]", fanConfig.ID))
+			}
+			if recalibrationConfig.SettleTime < 0 {
+				return errors.New(fmt.Sprintf("Fan %s: recalibration settleTime must be >= 0", fanConfig.ID))
+			}
+			if recalibrationConfig.SaveInterval < 0 {
+				return errors.New(fmt.Sprintf("Fan %s: recalibration saveInterval must be >= 0", fanConfig.ID))
+			}
+			if fanConfig.HwMon == nil {
+				return errors.New(fmt.Sprintf("Fan %s: recalibration is only supported by hwmon fans", fanConfig.ID))
+			}
+		}
+
+		if fanConfig.Sweep != nil {
+			sweepConfig := fanConfig.Sweep
+			supportedStrategies := []string{SweepStrategyFull, SweepStrategyCoarse, SweepStrategyAdaptive}
+			if len(sweepConfig.Strategy) > 0 && !slices.Contains(supportedStrategies, sweepConfig.Strategy) {
+				return errors.New(fmt.Sprintf("Fan %s: unsupported sweep strategy '%s', use one of: %s", fanConfig.ID, sweepConfig.Strategy, strings.Join(supportedStrategies, " | ")))
+			}
+			if sweepConfig.Steps != 0 && sweepConfig.Steps < 2 {
+				return errors.New(fmt.Sprintf("Fan %s: sweep steps must be >= 2", fanConfig.ID))
+			}
+			if sweepConfig.Tolerance < 0 {
+				return errors.New(fmt.Sprintf("Fan %s: sweep tolerance must be >= 0", fanConfig.ID))
+			}
+		}
+
+		if len(fanConfig.OnSensorFailure) > 0 {
+			supportedPolicies := []string{SensorFailureMaxSpeed, SensorFailureHoldLast, SensorFailureAuto}
+			if !slices.Contains(supportedPolicies, fanConfig.OnSensorFailure) {
+				return errors.New(fmt.Sprintf("Fan %s: unsupported onSensorFailure '%s', use one of: %s", fanConfig.ID, fanConfig.OnSensorFailure, strings.Join(supportedPolicies, " | ")))
+			}
+		}
+
 		if fanConfig.HwMon != nil {
 			if fanConfig.HwMon.Index <= 0 {
 				return errors.New(fmt.Sprintf("Fan %s: invalid index, must be >= 1", fanConfig.ID))
@@ -275,6 +510,9 @@ func validateFans(config *Configuration) error {
 			if len(fanConfig.File.Path) <= 0 {
 				return errors.New(fmt.Sprintf("Fan %s: no file path provided", fanConfig.ID))
 			}
+			if fanConfig.RpmTarget {
+				return errors.New(fmt.Sprintf("Fan %s: rpmTarget requires an RPM sensor, which is not supported by file fans", fanConfig.ID))
+			}
 		}
 
 		if fanConfig.Cmd != nil {
@@ -292,12 +530,192 @@ func validateFans(config *Configuration) error {
 			if len(cmdConfig.GetPwm.Exec) <= 0 {
 				return errors.New(fmt.Sprintf("Fan %s: getPwm executable is missing", fanConfig.ID))
 			}
+
+			if fanConfig.RpmTarget && cmdConfig.GetRpm == nil {
+				return errors.New(fmt.Sprintf("Fan %s: rpmTarget requires a getRpm configuration", fanConfig.ID))
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateFanGroups(config *Configuration) error {
+	groupIds := []string{}
+	memberIds := map[string]string{}
+
+	for _, groupConfig := range config.FanGroups {
+		if len(groupConfig.ID) <= 0 {
+			return errors.New("Fan group: missing id")
+		}
+		if slices.Contains(groupIds, groupConfig.ID) {
+			return errors.New(fmt.Sprintf("Duplicate fan group id detected: %s", groupConfig.ID))
+		}
+		if fanIdExists(groupConfig.ID, config) {
+			return errors.New(fmt.Sprintf("Fan group %s: id is already used by a fan", groupConfig.ID))
+		}
+		groupIds = append(groupIds, groupConfig.ID)
+
+		if len(groupConfig.Fans) <= 0 {
+			return errors.New(fmt.Sprintf("Fan group %s: no fans defined", groupConfig.ID))
+		}
+
+		curveIds := []string{}
+		for _, fanId := range groupConfig.Fans {
+			fanConfig, exists := findFanConfig(fanId, config)
+			if !exists {
+				return errors.New(fmt.Sprintf("Fan group %s: no fan definition with id '%s' found", groupConfig.ID, fanId))
+			}
+			if otherGroupId, isMember := memberIds[fanId]; isMember {
+				return errors.New(fmt.Sprintf("Fan group %s: fan '%s' is already a member of fan group '%s'", groupConfig.ID, fanId, otherGroupId))
+			}
+			memberIds[fanId] = groupConfig.ID
+
+			if groupConfig.TargetRpm && !supportsRpmSensor(fanConfig) {
+				return errors.New(fmt.Sprintf("Fan group %s: targetRpm requires an RPM sensor, which fan '%s' doesn't have", groupConfig.ID, fanId))
+			}
+			if !slices.Contains(curveIds, fanConfig.Curve) {
+				curveIds = append(curveIds, fanConfig.Curve)
+			}
+		}
+
+		if len(groupConfig.Curve) <= 0 {
+			if len(curveIds) > 1 {
+				return errors.New(fmt.Sprintf("Fan group %s: member fans use different curves, define the curve of the group explicitly", groupConfig.ID))
+			}
+		} else if !curveIdExists(groupConfig.Curve, config) {
+			return errors.New(fmt.Sprintf("Fan group %s: no curve definition with id '%s' found", groupConfig.ID, groupConfig.Curve))
 		}
 	}
 
 	return nil
 }
 
+// supportsRpmSensor returns whether the given fan is able to report its RPM
+func supportsRpmSensor(fanConfig FanConfig) bool {
+	if fanConfig.File != nil {
+		return false
+	}
+	if fanConfig.Cmd != nil {
+		return fanConfig.Cmd.GetRpm != nil
+	}
+	return true
+}
+
+func validateFailsafe(config *Configuration) error {
+	failsafeConfig := config.Failsafe
+	if failsafeConfig == nil {
+		return nil
+	}
+
+	if failsafeConfig.RecoveryTemp >= failsafeConfig.CriticalTemp {
+		return errors.New("Failsafe: recoveryTemp must be lower than criticalTemp")
+	}
+
+	for _, sensorId := range failsafeConfig.Sensors {
+		if !sensorIdExists(sensorId, config) {
+			return errors.New(fmt.Sprintf("Failsafe: no sensor definition with id '%s' found", sensorId))
+		}
+	}
+
+	if failsafeConfig.Exec != nil && len(failsafeConfig.Exec.Exec) <= 0 {
+		return errors.New("Failsafe: exec executable is missing")
+	}
+
+	return nil
+}
+
+func validateProfiles(config *Configuration) error {
+	profileIds := []string{}
+	for _, profileConfig := range config.Profiles {
+		if len(profileConfig.ID) <= 0 {
+			return errors.New("Profile: missing id")
+		}
+		if slices.Contains(profileIds, profileConfig.ID) {
+			return errors.New(fmt.Sprintf("Duplicate profile id detected: %s", profileConfig.ID))
+		}
+		profileIds = append(profileIds, profileConfig.ID)
+
+		fanIds := []string{}
+		for _, profileFan := range profileConfig.Fans {
+			if !fanIdExists(profileFan.Fan, config) && !fanGroupIdExists(profileFan.Fan, config) {
+				return errors.New(fmt.Sprintf("Profile %s: no fan definition with id '%s' found", profileConfig.ID, profileFan.Fan))
+			}
+			if group, isMember := FindFanGroup(config.FanGroups, profileFan.Fan); isMember {
+				return errors.New(fmt.Sprintf("Profile %s: fan '%s' is a member of fan group '%s', use the id of the group instead", profileConfig.ID, profileFan.Fan, group.ID))
+			}
+			if slices.Contains(fanIds, profileFan.Fan) {
+				return errors.New(fmt.Sprintf("Profile %s: duplicate fan '%s'", profileConfig.ID, profileFan.Fan))
+			}
+			fanIds = append(fanIds, profileFan.Fan)
+
+			if !curveIdExists(profileFan.Curve, config) {
+				return errors.New(fmt.Sprintf("Profile %s: no curve definition with id '%s' found", profileConfig.ID, profileFan.Curve))
+			}
+		}
+
+		if trigger := profileConfig.Trigger; trigger != nil {
+			if trigger.Load < 0 {
+				return errors.New(fmt.Sprintf("Profile %s: trigger load must not be negative", profileConfig.ID))
+			}
+			if len(trigger.Processes) <= 0 && trigger.Load <= 0 {
+				return errors.New(fmt.Sprintf("Profile %s: trigger requires at least one of: processes | load", profileConfig.ID))
+			}
+			if trigger.CoolDown < 0 {
+				return errors.New(fmt.Sprintf("Profile %s: trigger coolDown must not be negative", profileConfig.ID))
+			}
+		}
+	}
+
+	for idx, scheduleConfig := range config.Schedule {
+		if !slices.Contains(profileIds, scheduleConfig.Profile) {
+			return errors.New(fmt.Sprintf("Schedule entry %d: no profile definition with id '%s' found", idx, scheduleConfig.Profile))
+		}
+		if _, err := ParseTimeOfDay(scheduleConfig.From); err != nil {
+			return errors.New(fmt.Sprintf("Schedule entry %d: from: %v", idx, err))
+		}
+		if _, err := ParseTimeOfDay(scheduleConfig.To); err != nil {
+			return errors.New(fmt.Sprintf("Schedule entry %d: to: %v", idx, err))
+		}
+		for _, day := range scheduleConfig.Days {
+			if _, err := ParseWeekday(day); err != nil {
+				return errors.New(fmt.Sprintf("Schedule entry %d: %v", idx, err))
+			}
+		}
+	}
+
+	return nil
+}
+
+func fanIdExists(fanId string, config *Configuration) bool {
+	for _, fan := range config.Fans {
+		if fan.ID == fanId {
+			return true
+		}
+	}
+
+	return false
+}
+
+func findFanConfig(fanId string, config *Configuration) (FanConfig, bool) {
+	for _, fan := range config.Fans {
+		if fan.ID == fanId {
+			return fan, true
+		}
+	}
+	return FanConfig{}, false
+}
+
+func fanGroupIdExists(groupId string, config *Configuration) bool {
+	for _, group := range config.FanGroups {
+		if group.ID == groupId {
+			return true
+		}
+	}
+
+	return false
+}
+
 func curveIdExists(curveId string, config *Configuration) bool {
 	for _, curve := range config.Curves {
 		if curve.ID == curveId {
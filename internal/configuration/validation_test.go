@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestValidateDuplicateFanId(t *testing.T) {
@@ -98,6 +99,287 @@ func TestValidateFanCurveWithIdIsNotDefined(t *testing.T) {
 	assert.EqualError(t, err, "Fan fan: no curve definition with id 'curve' found")
 }
 
+func TestValidateFanRpmTargetWithoutRpmSensor(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Fans: []FanConfig{
+			{
+				ID:        "fan",
+				Curve:     "curve",
+				RpmTarget: true,
+				File: &FileFanConfig{
+					Path: "abc",
+				},
+			},
+		},
+		Curves: []CurveConfig{
+			{
+				ID: "curve",
+				Linear: &LinearCurveConfig{
+					Sensor: "sensor",
+					Min:    0,
+					Max:    100,
+				},
+			},
+		},
+		Sensors: []SensorConfig{
+			{
+				ID: "sensor",
+				File: &FileSensorConfig{
+					Path: "",
+				},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "Fan fan: rpmTarget requires an RPM sensor, which is not supported by file fans")
+}
+
+func TestValidateFanNegativeRampDown(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Fans: []FanConfig{
+			{
+				ID:       "fan",
+				Curve:    "curve",
+				RampDown: -1,
+				HwMon: &HwMonFanConfig{
+					Platform: "platform",
+					Index:    1,
+				},
+			},
+		},
+		Curves: []CurveConfig{
+			{
+				ID: "curve",
+				Linear: &LinearCurveConfig{
+					Sensor: "sensor",
+					Min:    0,
+					Max:    100,
+				},
+			},
+		},
+		Sensors: []SensorConfig{
+			{
+				ID: "sensor",
+				File: &FileSensorConfig{
+					Path: "",
+				},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "Fan fan: rampDown must be >= 0")
+}
+
+func TestValidateFanRecalibrationSmoothing(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Fans: []FanConfig{
+			{
+				ID:    "fan",
+				Curve: "curve",
+				Recalibration: &RecalibrationConfig{
+					Smoothing: 1.5,
+				},
+				HwMon: &HwMonFanConfig{
+					Platform: "platform",
+					Index:    1,
+				},
+			},
+		},
+		Curves: []CurveConfig{
+			{
+				ID: "curve",
+				Linear: &LinearCurveConfig{
+					Sensor: "sensor",
+					Min:    0,
+					Max:    100,
+				},
+			},
+		},
+		Sensors: []SensorConfig{
+			{
+				ID: "sensor",
+				File: &FileSensorConfig{
+					Path: "",
+				},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "Fan fan: recalibration smoothing must be within [0..1]")
+}
+
+func TestValidateFanSweepStrategy(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Fans: []FanConfig{
+			{
+				ID:    "fan",
+				Curve: "curve",
+				Sweep: &SweepConfig{
+					Strategy: "random",
+				},
+				HwMon: &HwMonFanConfig{
+					Platform: "platform",
+					Index:    1,
+				},
+			},
+		},
+		Curves: []CurveConfig{
+			{
+				ID: "curve",
+				Linear: &LinearCurveConfig{
+					Sensor: "sensor",
+					Min:    0,
+					Max:    100,
+				},
+			},
+		},
+		Sensors: []SensorConfig{
+			{
+				ID: "sensor",
+				File: &FileSensorConfig{
+					Path: "",
+				},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "Fan fan: unsupported sweep strategy 'random', use one of: full | coarse | adaptive")
+}
+
+func TestValidateFailsafeRecoveryTempAboveCriticalTemp(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Sensors: []SensorConfig{
+			{
+				ID: "sensor",
+				File: &FileSensorConfig{
+					Path: "",
+				},
+			},
+		},
+		Failsafe: &FailsafeConfig{
+			CriticalTemp: 80,
+			RecoveryTemp: 85,
+			Sensors:      []string{"sensor"},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "Failsafe: recoveryTemp must be lower than criticalTemp")
+}
+
+func TestValidateProfileCurveIsNotDefined(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Fans: []FanConfig{
+			{
+				ID:    "fan",
+				Curve: "curve",
+				File: &FileFanConfig{
+					Path: "abc",
+				},
+			},
+		},
+		Curves: []CurveConfig{
+			{
+				ID: "curve",
+				Linear: &LinearCurveConfig{
+					Sensor: "sensor",
+				},
+			},
+		},
+		Sensors: []SensorConfig{
+			{
+				ID: "sensor",
+				File: &FileSensorConfig{
+					Path: "",
+				},
+			},
+		},
+		Profiles: []ProfileConfig{
+			{
+				ID: "silent",
+				Fans: []ProfileFanConfig{
+					{Fan: "fan", Curve: "silent_curve"},
+				},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "Profile silent: no curve definition with id 'silent_curve' found")
+}
+
+func TestValidateProfileTriggerWithoutCondition(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Profiles: []ProfileConfig{
+			{
+				ID: "performance",
+				Trigger: &ProfileTriggerConfig{
+					CoolDown: time.Minute,
+				},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "Profile performance: trigger requires at least one of: processes | load")
+}
+
+func TestValidateScheduleInvalidTime(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Profiles: []ProfileConfig{
+			{
+				ID: "silent",
+			},
+		},
+		Schedule: []ScheduleConfig{
+			{
+				Profile: "silent",
+				From:    "22:00",
+				To:      "7am",
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "Schedule entry 0: to: invalid time of day '7am', expected format HH:MM")
+}
+
 func TestValidateCurveSubConfigSensorIdIsMissing(t *testing.T) {
 	// GIVEN
 	config := Configuration{
@@ -114,7 +396,7 @@ func TestValidateCurveSubConfigSensorIdIsMissing(t *testing.T) {
 	err := validateConfig(&config, "")
 
 	// THEN
-	assert.EqualError(t, err, "Curve curve: sub-configuration for curve is missing, use one of: linear | pid | function")
+	assert.EqualError(t, err, "Curve curve: sub-configuration for curve is missing, use one of: linear | pid | function | derivative | expression")
 }
 
 func TestValidateCurveSensorIdIsMissing(t *testing.T) {
@@ -351,7 +633,148 @@ func TestValidateCurveFunctionTypeUnsupported(t *testing.T) {
 	err := validateConfig(&config, "")
 
 	// THEN
-	assert.EqualError(t, err, "Curve curve1: unsupported function type 'unsupported', use one of: minimum | average | maximum | delta")
+	assert.EqualError(t, err, "Curve curve1: unsupported function type 'unsupported', use one of: minimum | average | maximum | delta | weighted | sum | median")
+}
+
+func TestValidateCurveFunctionWeightedMissingWeights(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Curves: []CurveConfig{
+			{
+				ID: "curve1",
+				Function: &FunctionCurveConfig{
+					Type:    FunctionWeighted,
+					Curves:  []string{"curve2", "curve3"},
+					Weights: []float64{0.7},
+				},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "Curve curve1: the weighted function requires exactly one weight per curve")
+}
+
+func TestValidateCurveExpressionUnknownId(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Curves: []CurveConfig{
+			{
+				ID: "curve",
+				Expression: &ExpressionCurveConfig{
+					Formula: "max(sensor, gpu - 10) * 1.2",
+				},
+			},
+		},
+		Sensors: []SensorConfig{
+			{
+				ID: "sensor",
+				File: &FileSensorConfig{
+					Path: "",
+				},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "Curve curve: no sensor or curve definition with id 'gpu' found")
+}
+
+func TestValidateCurveExpressionDependencyCycle(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Curves: []CurveConfig{
+			{
+				ID: "curve1",
+				Expression: &ExpressionCurveConfig{
+					Formula: "curve2 * 2",
+				},
+			},
+			{
+				ID: "curve2",
+				Function: &FunctionCurveConfig{
+					Type:   FunctionAverage,
+					Curves: []string{"curve1"},
+				},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.ErrorContains(t, err, "You have created a curve dependency cycle")
+}
+
+func TestValidateCurvePidOutputRange(t *testing.T) {
+	// GIVEN
+	outputMin := 200
+	outputMax := 100
+	config := Configuration{
+		Curves: []CurveConfig{
+			{
+				ID: "curve",
+				PID: &PidCurveConfig{
+					Sensor:    "sensor",
+					SetPoint:  60,
+					P:         0.05,
+					OutputMin: &outputMin,
+					OutputMax: &outputMax,
+				},
+			},
+		},
+		Sensors: []SensorConfig{
+			{
+				ID: "sensor",
+				File: &FileSensorConfig{
+					Path: "",
+				},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "Curve curve: outputMin and outputMax must be in range [0..255] and outputMin must be less than outputMax")
+}
+
+func TestValidateCurveDerivativeMaxBelowMin(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Curves: []CurveConfig{
+			{
+				ID: "curve",
+				Derivative: &DerivativeCurveConfig{
+					Sensor: "sensor",
+					Min:    1,
+					Max:    0.5,
+				},
+			},
+		},
+		Sensors: []SensorConfig{
+			{
+				ID: "sensor",
+				File: &FileSensorConfig{
+					Path: "",
+				},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "Curve curve: max must be greater than min")
 }
 
 func TestValidateSensorSubConfigSensorIdIsMissing(t *testing.T) {
@@ -417,3 +840,196 @@ func TestValidateDuplicateSensorId(t *testing.T) {
 	// THEN
 	assert.EqualError(t, err, fmt.Sprintf("Duplicate sensor id detected: %s", sensorId))
 }
+
+func TestGetCurveUsages(t *testing.T) {
+	// GIVEN
+	config := Configuration{
+		Fans: []FanConfig{
+			{
+				ID:    "fan",
+				Curve: "curve1",
+			},
+		},
+		Curves: []CurveConfig{
+			{
+				ID: "curve1",
+				Linear: &LinearCurveConfig{
+					Sensor: "sensor",
+				},
+			},
+			{
+				ID: "curve2",
+				Function: &FunctionCurveConfig{
+					Type:   FunctionAverage,
+					Curves: []string{"curve1"},
+				},
+			},
+		},
+	}
+
+	// WHEN
+	curve1Usages := GetCurveUsages(&config, "curve1")
+	curve2Usages := GetCurveUsages(&config, "curve2")
+	sensorUsages := GetSensorUsages(&config, "sensor")
+
+	// THEN
+	assert.Equal(t, []string{"curve2", "fan"}, curve1Usages)
+	assert.Empty(t, curve2Usages)
+	assert.Equal(t, []string{"curve1"}, sensorUsages)
+}
+
+func createFanGroupTestConfig(groups ...FanGroupConfig) Configuration {
+	return Configuration{
+		Fans: []FanConfig{
+			{
+				ID:    "fan1",
+				Curve: "curve1",
+				HwMon: &HwMonFanConfig{
+					Platform: "platform",
+					Index:    1,
+				},
+			},
+			{
+				ID:    "fan2",
+				Curve: "curve2",
+				File: &FileFanConfig{
+					Path: "/sys/class/hwmon/hwmon0/pwm1",
+				},
+			},
+		},
+		FanGroups: groups,
+		Curves: []CurveConfig{
+			{
+				ID: "curve1",
+				Linear: &LinearCurveConfig{
+					Sensor: "sensor",
+					Min:    0,
+					Max:    100,
+				},
+			},
+			{
+				ID: "curve2",
+				Linear: &LinearCurveConfig{
+					Sensor: "sensor",
+					Min:    20,
+					Max:    80,
+				},
+			},
+		},
+		Sensors: []SensorConfig{
+			{
+				ID: "sensor",
+				File: &FileSensorConfig{
+					Path: "",
+				},
+			},
+		},
+	}
+}
+
+func TestValidateFanGroup(t *testing.T) {
+	// GIVEN
+	config := createFanGroupTestConfig(FanGroupConfig{
+		ID:    "group",
+		Fans:  []string{"fan1", "fan2"},
+		Curve: "curve1",
+	})
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fan1", "group"}, GetCurveUsages(&config, "curve1"))
+}
+
+func TestValidateFanGroupMissingFan(t *testing.T) {
+	// GIVEN
+	config := createFanGroupTestConfig(FanGroupConfig{
+		ID:    "group",
+		Fans:  []string{"fan1", "fan3"},
+		Curve: "curve1",
+	})
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "Fan group group: no fan definition with id 'fan3' found")
+}
+
+func TestValidateFanGroupFanInMultipleGroups(t *testing.T) {
+	// GIVEN
+	config := createFanGroupTestConfig(
+		FanGroupConfig{
+			ID:   "group1",
+			Fans: []string{"fan1"},
+		},
+		FanGroupConfig{
+			ID:   "group2",
+			Fans: []string{"fan2", "fan1"},
+		},
+	)
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "Fan group group2: fan 'fan1' is already a member of fan group 'group1'")
+}
+
+func TestValidateFanGroupDifferentMemberCurves(t *testing.T) {
+	// GIVEN
+	config := createFanGroupTestConfig(FanGroupConfig{
+		ID:   "group",
+		Fans: []string{"fan1", "fan2"},
+	})
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "Fan group group: member fans use different curves, define the curve of the group explicitly")
+}
+
+func TestValidateFanGroupTargetRpmWithoutRpmSensor(t *testing.T) {
+	// GIVEN
+	config := createFanGroupTestConfig(FanGroupConfig{
+		ID:        "group",
+		Fans:      []string{"fan1", "fan2"},
+		Curve:     "curve1",
+		TargetRpm: true,
+	})
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "Fan group group: targetRpm requires an RPM sensor, which fan 'fan2' doesn't have")
+}
+
+func TestValidateProfileReferencesFanGroupMember(t *testing.T) {
+	// GIVEN
+	config := createFanGroupTestConfig(FanGroupConfig{
+		ID:    "group",
+		Fans:  []string{"fan1", "fan2"},
+		Curve: "curve1",
+	})
+	config.Profiles = []ProfileConfig{
+		{
+			ID: "quiet",
+			Fans: []ProfileFanConfig{
+				{
+					Fan:   "fan1",
+					Curve: "curve2",
+				},
+			},
+		},
+	}
+
+	// WHEN
+	err := validateConfig(&config, "")
+
+	// THEN
+	assert.EqualError(t, err, "Profile quiet: fan 'fan1' is a member of fan group 'group', use the id of the group instead")
+}
@@ -0,0 +1,115 @@
+package configuration
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// UpdatePidCurveConstants replaces the p, i and d constants of the pid curve with the given id
+// in the config file at the given path. Only the affected values are changed, so the formatting
+// and comments of the rest of the file are kept as they are.
+func UpdatePidCurveConstants(path string, curveId string, p float64, i float64, d float64) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var root yaml.Node
+	if err = yaml.Unmarshal(data, &root); err != nil {
+		return err
+	}
+	if len(root.Content) <= 0 {
+		return fmt.Errorf("config file %s is empty", path)
+	}
+
+	pidNode, err := findPidCurveNode(root.Content[0], curveId)
+	if err != nil {
+		return err
+	}
+	if pidNode.Style&yaml.FlowStyle != 0 || len(pidNode.Content) <= 0 {
+		return fmt.Errorf("curve %s: only block style pid sections can be updated", curveId)
+	}
+
+	lines := strings.Split(string(data), "\n")
+	var insertions []string
+	for _, constant := range []struct {
+		key   string
+		value float64
+	}{{"p", p}, {"i", i}, {"d", d}} {
+		formatted := strconv.FormatFloat(constant.value, 'g', 4, 64)
+		valueNode := findMappingValue(pidNode, constant.key)
+		if valueNode == nil {
+			insertions = append(insertions, fmt.Sprintf("%s: %s", constant.key, formatted))
+			continue
+		}
+		if valueNode.Kind != yaml.ScalarNode || valueNode.Style != 0 {
+			return fmt.Errorf("curve %s: unsupported value for %s", curveId, constant.key)
+		}
+		line := lines[valueNode.Line-1]
+		start := valueNode.Column - 1
+		lines[valueNode.Line-1] = line[:start] + formatted + line[start+len(valueNode.Value):]
+	}
+
+	if len(insertions) > 0 {
+		// add missing constants after the last entry of the pid section, using the same indentation
+		indentation := strings.Repeat(" ", pidNode.Content[0].Column-1)
+		lastLine := lastLineOf(pidNode)
+		var added []string
+		for _, insertion := range insertions {
+			added = append(added, indentation+insertion)
+		}
+		lines = append(lines[:lastLine], append(added, lines[lastLine:]...)...)
+	}
+
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")), info.Mode())
+}
+
+func findPidCurveNode(document *yaml.Node, curveId string) (*yaml.Node, error) {
+	curvesNode := findMappingValue(document, "curves")
+	if curvesNode == nil || curvesNode.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("no curves found in config file")
+	}
+	for _, curveNode := range curvesNode.Content {
+		idNode := findMappingValue(curveNode, "id")
+		if idNode == nil || idNode.Value != curveId {
+			continue
+		}
+		pidNode := findMappingValue(curveNode, "pid")
+		if pidNode == nil || pidNode.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("curve %s is not a pid curve", curveId)
+		}
+		return pidNode, nil
+	}
+	return nil, fmt.Errorf("no curve with id '%s' found in config file", curveId)
+}
+
+// findMappingValue returns the value of the given key, or nil if the node is no mapping or the key doesn't exist
+func findMappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for idx := 0; idx+1 < len(node.Content); idx += 2 {
+		if node.Content[idx].Value == key {
+			return node.Content[idx+1]
+		}
+	}
+	return nil
+}
+
+// lastLineOf returns the (1-based) number of the last line containing any part of the given node
+func lastLineOf(node *yaml.Node) int {
+	result := node.Line
+	for _, child := range node.Content {
+		if line := lastLineOf(child); line > result {
+			result = line
+		}
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+package configuration
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdatePidCurveConstants(t *testing.T) {
+	// GIVEN
+	path := filepath.Join(t.TempDir(), "fan2go.yaml")
+	content := `curves:
+  - id: linear_curve
+    linear:
+      sensor: cpu_package
+      min: 40
+      max: 80
+  # tuned by hand
+  - id: pid_curve
+    pid:
+      sensor: cpu_package
+      setPoint: 60
+      p: -0.05 # proportional
+      d: -0.005
+sensors: []
+`
+	err := os.WriteFile(path, []byte(content), 0644)
+	assert.NoError(t, err)
+
+	// WHEN
+	err = UpdatePidCurveConstants(path, "pid_curve", -0.1234567, -0.002, -0.5)
+
+	// THEN
+	assert.NoError(t, err)
+	result, _ := os.ReadFile(path)
+	expected := `curves:
+  - id: linear_curve
+    linear:
+      sensor: cpu_package
+      min: 40
+      max: 80
+  # tuned by hand
+  - id: pid_curve
+    pid:
+      sensor: cpu_package
+      setPoint: 60
+      p: -0.1235 # proportional
+      d: -0.5
+      i: -0.002
+sensors: []
+`
+	assert.Equal(t, expected, string(result))
+}
+
+func TestUpdatePidCurveConstantsNoPidCurve(t *testing.T) {
+	// GIVEN
+	path := filepath.Join(t.TempDir(), "fan2go.yaml")
+	content := `curves:
+  - id: linear_curve
+    linear:
+      sensor: cpu_package
+`
+	err := os.WriteFile(path, []byte(content), 0644)
+	assert.NoError(t, err)
+
+	// WHEN
+	err = UpdatePidCurveConstants(path, "linear_curve", 1, 1, 1)
+
+	// THEN
+	assert.EqualError(t, err, "curve linear_curve is not a pid curve")
+}
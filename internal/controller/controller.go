@@ -7,21 +7,90 @@ import (
 	"github.com/markusressel/fan2go/internal/curves"
 	"github.com/markusressel/fan2go/internal/fans"
 	"github.com/markusressel/fan2go/internal/persistence"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/markusressel/fan2go/internal/tuning"
 	"github.com/markusressel/fan2go/internal/ui"
 	"github.com/markusressel/fan2go/internal/util"
-	"github.com/oklog/run"
 	"math"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
 var InitializationSequenceMutex sync.Mutex
 
+const (
+	// stepResponseDuration is the time the RPM of a fan is recorded after a pwm step during initialization
+	stepResponseDuration = 20 * time.Second
+	// stepResponseSampleRate is the time between two RPM measurements of the step response
+	stepResponseSampleRate = 250 * time.Millisecond
+	// initializationStepDuration is the expected duration of a single RPM measurement during initialization,
+	// used to estimate the progress of the initialization
+	initializationStepDuration = 3 * time.Second
+	// expectedSettleDuration is the usual time waitForFanToSettle takes after a large speed change,
+	// used to estimate the progress of the initialization
+	expectedSettleDuration = 10 * time.Second
+	// initialSettleSamples is the number of consecutive RPM measurements which have to be stable,
+	// before a fan is considered settled after a large speed change
+	initialSettleSamples = 10
+	// stepSettleSamples is the number of consecutive RPM measurements which have to be stable,
+	// before a fan is considered settled after a small speed change, f.ex. between two pwm values of a sweep.
+	// since most sensors are update only each second, at least two are needed to make sure we get the most recent one
+	stepSettleSamples = 2
+	// maxSettleDuration is the time after which a fan is considered settled, even if its RPM is still changing
+	maxSettleDuration = 30 * time.Second
+)
+
+var (
+	FanControllerMap      = map[string]FanController{}
+	fanControllerMapMutex sync.RWMutex
+
+	failsafeActive      bool
+	failsafeActiveMutex sync.RWMutex
+
+	// fan id -> stall action of all currently stalled fans
+	stalledFans      = map[string]string{}
+	stalledFansMutex sync.RWMutex
+)
+
+// SetFailsafeActive makes all fan controllers run their fans at max speed regardless of their curve (true),
+// or resume normal control (false)
+func SetFailsafeActive(active bool) {
+	failsafeActiveMutex.Lock()
+	defer failsafeActiveMutex.Unlock()
+	failsafeActive = active
+}
+
+// IsFailsafeActive indicates whether all fans are currently forced to run at max speed
+func IsFailsafeActive() bool {
+	failsafeActiveMutex.RLock()
+	defer failsafeActiveMutex.RUnlock()
+	return failsafeActive
+}
+
 type FanControllerStatistics struct {
 	UnexpectedPwmValueCount int
 	IncreasedMinPwmCount    int
 	MinPwmOffset            int
+	// Stalled indicates whether the fan is currently considered stalled
+	Stalled bool
+	// StallCount is the number of times the fan was detected as stalled
+	StallCount int
+	// CurveErrorCount is the number of times the curve of the fan couldn't be evaluated
+	CurveErrorCount int
+}
+
+// Override temporarily replaces the curve of a fan controller with either
+// a fixed value or an alternative curve
+type Override struct {
+	// Pwm is a fixed pwm value [0..255] that is set on the fan instead of the curve value, if set.
+	// Unlike curve values, it is not mapped to the pwm range of the fan.
+	Pwm *int `json:"pwm,omitempty"`
+	// CurveId is the id of a curve that is used instead of the fan curve, if set
+	CurveId string `json:"curve,omitempty"`
+	// Expires is the point in time at which the override is cleared automatically
+	Expires time.Time `json:"expires"`
 }
 
 type FanController interface {
@@ -32,8 +101,21 @@ type FanController interface {
 
 	GetStatistics() FanControllerStatistics
 
-	// RunInitializationSequence for the given fan to determine its characteristics
-	RunInitializationSequence() (err error)
+	// SetCurve replaces the curve used to control the fan
+	SetCurve(curve curves.SpeedCurve)
+
+	// SetOverride makes the controller use the given override until it expires
+	SetOverride(override Override)
+	// GetOverride returns the currently active override, if any
+	GetOverride() *Override
+	// ClearOverride removes the currently active override, if any
+	ClearOverride()
+
+	// RunInitializationSequence for the given fan to determine its characteristics.
+	// The sequence is aborted if the given context is canceled, it resumes where it stopped when run again.
+	RunInitializationSequence(ctx context.Context) (err error)
+	// GetInitializationProgress returns the progress of the running initialization sequence, if any
+	GetInitializationProgress() *InitializationProgress
 
 	UpdateFanSpeed() error
 }
@@ -47,6 +129,38 @@ type PidFanController struct {
 	fan fans.Fan
 	// the curve used to control the fan
 	curve curves.SpeedCurve
+	// guards access to curve, which can be replaced while the controller is running
+	curveMutex sync.RWMutex
+	// an override which temporarily replaces the curve, if set
+	override *Override
+	// guards access to override
+	overrideMutex sync.Mutex
+	// the id of the curve that was evaluated last, empty if a fixed override value was used
+	lastCurveId string
+	// the last value of the curve (or override) that was evaluated
+	lastCurveValue int
+	// the target pwm value which passed the hysteresis filter last
+	hysteresisTarget *int
+	// the values (in °C) of the curve sensors at the time hysteresisTarget was accepted
+	hysteresisSensorValues map[string]float64
+	// the direction (-1, 1) of the last change of hysteresisTarget, 0 if there was none yet
+	lastDirection int
+	// the time of the last change of hysteresisTarget
+	lastTargetChange time.Time
+	// the exact (not rounded) pwm value calculated by the ramp limits, to allow slow rates
+	rampPwm *float64
+	// the time the ramp limits were applied last
+	lastRampUpdate time.Time
+	// indicates whether the onSensorFailure policy of the fan is currently applied
+	sensorFailure bool
+	// indicates whether the onSensorFailure policy of the fan is currently applied, because its curve can't be evaluated
+	curveFailure bool
+	// a copy of the fan curve data measured during initialization, used as a reference for stall detection
+	expectedRpm map[int]float64
+	// the time since which the RPM of the fan is below the expected RPM
+	stallSince time.Time
+	// indicates whether the fan is spinning, a fan at rest is driven at its start pwm until it spins
+	spinning bool
 	// rate to update the target fan speed
 	updateRate time.Duration
 	// the original pwm_enabled flag state of the fan before starting the controller
@@ -64,6 +178,30 @@ type PidFanController struct {
 
 	// offset applied to the actual minPwm of the fan to ensure "neverStops" constraint
 	minPwmOffset int
+	// the pwm boundaries learned during initialization and at runtime, nil if unknown
+	pwmBoundaries *persistence.FanPwmBoundaries
+	// progress of the running initialization sequence
+	progress progressTracker
+
+	// the group the fan is a member of, nil if it is controlled individually
+	group *FanGroup
+	// the decision of the group that is applied in the current update
+	groupDecision *GroupDecision
+	// the RPM the fan is driven at in the current update, if its group uses a shared target RPM
+	groupTargetRpm *float64
+
+	// guards the state of the control loop, which is run by the scheduler
+	mutex sync.Mutex
+	// indicates whether the control loop is running, which is the case once the fan has been initialized
+	running bool
+	// closed when the control loop has stopped due to an error
+	failed chan struct{}
+	// the curve values of the current iteration of the scheduler, nil if curves are evaluated individually
+	evaluation *curves.Evaluation
+	// gradually updates the fan curve data while the fan is controlled, nil if disabled
+	recalibration *recalibrator
+	// the RPM of the last measurement
+	lastRpm int
 }
 
 func NewFanController(
@@ -72,10 +210,13 @@ func NewFanController(
 	pidLoop util.PidLoop,
 	updateRate time.Duration,
 ) FanController {
+	curve, _ := curves.GetSpeedCurve(fan.GetCurveId())
+	group, _ := GetFanGroupOfFan(fan.GetId())
 	return &PidFanController{
 		persistence:                 persistence,
 		fan:                         fan,
-		curve:                       curves.SpeedCurveMap[fan.GetCurveId()],
+		curve:                       curve,
+		group:                       group,
 		updateRate:                  updateRate,
 		pwmValuesWithDistinctTarget: []int{},
 		pwmMap:                      map[int]int{},
@@ -89,35 +230,114 @@ func (f *PidFanController) GetFanId() string {
 }
 
 func (f *PidFanController) GetStatistics() FanControllerStatistics {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
 	return f.stats
 }
 
-func (f *PidFanController) Run(ctx context.Context) error {
-	fan := f.fan
+func (f *PidFanController) GetInitializationProgress() *InitializationProgress {
+	return f.progress.get(time.Now())
+}
 
-	if fan.ShouldNeverStop() && !fan.Supports(fans.FeatureRpmSensor) {
-		ui.Warning("WARN: cannot guarantee neverStop option on fan %s, since it has no RPM input.", fan.GetId())
+func (f *PidFanController) SetCurve(curve curves.SpeedCurve) {
+	f.curveMutex.Lock()
+	defer f.curveMutex.Unlock()
+	f.curve = curve
+}
+
+func (f *PidFanController) getCurve() curves.SpeedCurve {
+	if f.group != nil {
+		return f.group.getCurve()
 	}
+	f.curveMutex.RLock()
+	defer f.curveMutex.RUnlock()
+	return f.curve
+}
 
-	// store original pwm value
-	pwm, err := fan.GetPwm()
-	if err != nil {
-		ui.Warning("Cannot read pwm value of %s", fan.GetId())
+func (f *PidFanController) SetOverride(override Override) {
+	f.overrideMutex.Lock()
+	defer f.overrideMutex.Unlock()
+	ui.Info("Overriding curve of fan %s until %s", f.fan.GetId(), override.Expires.Format(time.RFC3339))
+	f.override = &override
+}
+
+func (f *PidFanController) GetOverride() *Override {
+	f.overrideMutex.Lock()
+	defer f.overrideMutex.Unlock()
+	if f.override != nil && !time.Now().Before(f.override.Expires) {
+		ui.Info("Curve override of fan %s has expired", f.fan.GetId())
+		f.override = nil
 	}
-	f.originalPwmValue = pwm
+	if f.override == nil {
+		return nil
+	}
+	override := *f.override
+	return &override
+}
 
-	// store original pwm_enable value
-	if f.fan.Supports(fans.FeatureControlMode) {
-		pwmEnabled, err := fan.GetPwmEnabled()
-		if err != nil {
-			ui.Warning("Cannot read pwm_enable value of %s", fan.GetId())
+func (f *PidFanController) ClearOverride() {
+	f.overrideMutex.Lock()
+	defer f.overrideMutex.Unlock()
+	if f.override != nil {
+		ui.Info("Clearing curve override of fan %s", f.fan.GetId())
+	}
+	f.override = nil
+}
+
+// evaluateCurve returns the current curve value for this fan, taking an active override
+// and the decision of its group into account
+func (f *PidFanController) evaluateCurve() (int, error) {
+	f.groupTargetRpm = nil
+	curve := f.getCurve()
+	override := f.GetOverride()
+	if override == nil && f.groupDecision != nil {
+		decision := f.groupDecision
+		f.lastCurveId = decision.CurveId
+		f.lastCurveValue = decision.CurveValue
+		f.groupTargetRpm = decision.TargetRpm
+		return decision.CurveValue, decision.Err
+	}
+	if override != nil {
+		if override.Pwm != nil {
+			f.lastCurveId = ""
+			f.lastCurveValue = *override.Pwm
+			return *override.Pwm, nil
 		}
-		f.originalPwmEnabled = fans.ControlMode(pwmEnabled)
+		if overrideCurve, exists := curves.GetSpeedCurve(override.CurveId); exists {
+			curve = overrideCurve
+		} else {
+			ui.Warning("Override curve %s of fan %s doesn't exist, using default curve", override.CurveId, f.fan.GetId())
+		}
+	}
+
+	var value int
+	var err error
+	if f.evaluation != nil {
+		value, err = f.evaluation.Evaluate(curve)
+	} else {
+		value, err = curve.Evaluate()
 	}
+	f.lastCurveId = curve.GetId()
+	f.lastCurveValue = value
+	return value, err
+}
+
+func (f *PidFanController) Run(ctx context.Context) error {
+	fan := f.fan
+
+	if fan.ShouldNeverStop() && !fan.Supports(fans.FeatureRpmSensor) {
+		ui.Warning("WARN: cannot guarantee neverStop option on fan %s, since it has no RPM input.", fan.GetId())
+	}
+
+	f.storeOriginalState()
 
 	ui.Info("Gathering sensor data for %s...", fan.GetId())
 	// wait a bit to gather monitoring data
-	time.Sleep(2*time.Second + configuration.CurrentConfig.TempSensorPollingRate*2)
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-time.After(2*time.Second + configuration.CurrentConfig.TempSensorPollingRate*2):
+	}
 
 	// check if we have data for this fan in persistence,
 	// if not we need to run the initialization sequence
@@ -127,7 +347,11 @@ func (f *PidFanController) Run(ctx context.Context) error {
 		_, ok := fan.(*fans.HwMonFan)
 		if ok {
 			ui.Warning("Fan '%s' has not yet been analyzed, starting initialization sequence...", fan.GetId())
-			err = f.RunInitializationSequence()
+			err = f.RunInitializationSequence(ctx)
+			if ctx.Err() != nil {
+				// the fan has already been restored, the initialization resumes on the next start
+				return nil
+			}
 			if err != nil {
 				return err
 			}
@@ -149,10 +373,39 @@ func (f *PidFanController) Run(ctx context.Context) error {
 		return err
 	}
 
-	f.computePwmMap()
+	// the fan curve data is updated continuously, so keep a copy of the original measurement
+	f.expectedRpm = make(map[int]float64, len(fanPwmData))
+	for pwm, rpm := range fanPwmData {
+		f.expectedRpm[pwm] = rpm
+	}
+
+	err = f.computePwmMap(ctx)
+	if err != nil {
+		f.restorePwmEnabled()
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
 
 	f.updateDistinctPwmValues()
 
+	if boundaries, err := f.persistence.LoadFanPwmBoundaries(fan.GetId()); err == nil {
+		fan.SetStartPwm(boundaries.StartPwm, false)
+		fan.SetMinPwm(boundaries.MinPwm, false)
+		f.minPwmOffset = boundaries.MinPwmOffset
+		f.stats.MinPwmOffset = boundaries.MinPwmOffset
+		f.pwmBoundaries = &boundaries
+	}
+
+	// use the control loop constants measured during initialization, unless they are configured explicitly
+	if fan.GetConfig().ControlLoop == nil {
+		if controlLoop, err := f.persistence.LoadFanControlLoop(fan.GetId()); err == nil {
+			ui.Info("Using measured control loop of fan '%s': P %g, I %g, D %g", fan.GetId(), controlLoop.P, controlLoop.I, controlLoop.D)
+			f.pidLoop = util.NewPidLoop(controlLoop.P, controlLoop.I, controlLoop.D)
+		}
+	}
+
 	ui.Debug("PWM map of fan '%s': %v", fan.GetId(), f.pwmMap)
 	ui.Info("PWM settings of fan '%s': Min %d, Start %d, Max %d", fan.GetId(), fan.GetMinPwm(), fan.GetStartPwm(), fan.GetMaxPwm())
 	ui.Info("Starting controller loop for fan '%s'", fan.GetId())
@@ -161,63 +414,150 @@ func (f *PidFanController) Run(ctx context.Context) error {
 		ui.Warning("Suspicious pwm config of fan '%s': MinPwm (%d) > StartPwm (%d)", fan.GetId(), fan.GetMinPwm(), fan.GetStartPwm())
 	}
 
-	var g run.Group
+	if config := fan.GetConfig().Recalibration; config != nil && fan.Supports(fans.FeatureRpmSensor) {
+		f.recalibration = newRecalibrator(f.persistence, fan, *config, time.Now())
+	}
+	if f.group != nil {
+		ui.Info("Fan %s is controlled by fan group %s", fan.GetId(), f.group.GetId())
+	}
 
-	if fan.Supports(fans.FeatureRpmSensor) {
-		// === rpm monitoring
-		pollingRate := configuration.CurrentConfig.RpmPollingRate
-
-		g.Add(func() error {
-			tick := time.Tick(pollingRate)
-			for {
-				select {
-				case <-ctx.Done():
-					ui.Info("Stopping RPM monitor of fan controller for fan %s...", fan.GetId())
-					return nil
-				case <-tick:
-					measureRpm(fan)
-				}
-			}
-		}, func(err error) {
-			if err != nil {
-				ui.Warning("Error monitoring fan rpm: %v", err)
-			}
-		})
-	}
-
-	{
-		g.Add(func() error {
-			time.Sleep(1 * time.Second)
-			tick := time.Tick(f.updateRate)
-			for {
-				select {
-				case <-ctx.Done():
-					ui.Info("Stopping fan controller for fan %s...", fan.GetId())
-					f.restorePwmEnabled()
-					return nil
-				case <-tick:
-					err = f.UpdateFanSpeed()
-					if err != nil {
-						ui.ErrorAndNotify("Fan Control Error", "Fan %s: %v", fan.GetId(), err)
-						f.restorePwmEnabled()
-						return nil
-					}
-				}
-			}
-		}, func(err error) {
-			if err != nil {
-				ui.Fatal("Error monitoring fan rpm: %v", err)
-			}
-		})
+	// the control loop itself is run by the scheduler, until the controller is stopped
+	failed := f.start()
+	select {
+	case <-ctx.Done():
+		ui.Info("Stopping fan controller for fan %s...", fan.GetId())
+		f.stop()
+	case <-failed:
 	}
+	return nil
+}
 
-	err = g.Run()
-	return err
+// start hands the control loop over to the scheduler, the returned channel is closed if it fails
+func (f *PidFanController) start() <-chan struct{} {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.failed = make(chan struct{})
+	f.running = true
+	return f.failed
+}
+
+// stop ends the control loop and restores the original state of the fan
+func (f *PidFanController) stop() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if !f.running {
+		return
+	}
+	f.running = false
+	if f.recalibration != nil {
+		f.recalibration.save(time.Now())
+	}
+	f.setStalled(false)
+	f.restorePwmEnabled()
+}
+
+// update runs a single iteration of the control loop, using the curve values of the given evaluation
+// and the last decision of the group of the fan, if any
+func (f *PidFanController) update(evaluation *curves.Evaluation) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if !f.running {
+		return
+	}
+
+	f.evaluation = evaluation
+	if f.group != nil {
+		f.groupDecision = f.group.GetLastDecision()
+	}
+	err := f.UpdateFanSpeed()
+	f.evaluation = nil
+	if err != nil {
+		ui.ErrorAndNotify("Fan Control Error", "Fan %s: %v", f.fan.GetId(), err)
+		f.running = false
+		f.restorePwmEnabled()
+		close(f.failed)
+	}
+}
+
+// updateRpm measures the current RPM of the fan and uses it to recalibrate the fan curve, if enabled
+func (f *PidFanController) updateRpm(now time.Time) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if !f.running || !f.fan.Supports(fans.FeatureRpmSensor) {
+		return
+	}
+
+	pwm, rpm, err := measureRpm(f.fan)
+	if err != nil {
+		return
+	}
+	f.lastRpm = rpm
+	if f.recalibration != nil {
+		f.recalibration.update(pwm, rpm, now)
+	}
+}
+
+// getState returns the current state of the fan, ok is false if the control loop isn't running
+func (f *PidFanController) getState() (state FanState, ok bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if !f.running {
+		return state, false
+	}
+
+	fan := f.fan
+	state = FanState{
+		Config:       fan.GetConfig(),
+		RpmMovingAvg: fan.GetRpmAvg(),
+		MinPwm:       fan.GetMinPwm(),
+		StartPwm:     fan.GetStartPwm(),
+		MaxPwm:       fan.GetMaxPwm(),
+		FanCurveData: map[int]float64{},
+		Statistics:   f.stats,
+	}
+	if f.lastSetPwm != nil {
+		state.Pwm = f.mapToClosestDistinct(*f.lastSetPwm)
+	}
+	if fan.Supports(fans.FeatureRpmSensor) {
+		rpm := f.lastRpm
+		state.Rpm = &rpm
+	}
+	if curveData := fan.GetFanCurveData(); curveData != nil {
+		for pwm, rpm := range *curveData {
+			state.FanCurveData[pwm] = rpm
+		}
+	}
+	return state, true
 }
 
 func (f *PidFanController) UpdateFanSpeed() error {
 	fan := f.fan
 
+	f.detectStall(time.Now())
+
+	if IsFailsafeActive() {
+		f.setMaxSpeed()
+		f.publishPolicyTickEvent(TickStateFailsafe, "critical temperature reached")
+		return nil
+	}
+
+	if stalledFanId, stalled := getOtherStalledFan(fan.GetId(), configuration.StallActionMaxSpeed); stalled {
+		ui.Debug("Fan %s is stalled, setting fan %s to max speed", stalledFanId, fan.GetId())
+		f.setMaxSpeed()
+		f.publishPolicyTickEvent(TickStateStalledFan, fmt.Sprintf("fan %s is stalled", stalledFanId))
+		return nil
+	}
+
+	staleSensors := f.getStaleSensors()
+	if len(staleSensors) > 0 {
+		f.applySensorFailurePolicy(staleSensors)
+		f.publishPolicyTickEvent(TickStateSensorFailure, fmt.Sprintf("sensor(s) %s are stale", strings.Join(staleSensors, ", ")))
+		return nil
+	} else if f.sensorFailure {
+		f.sensorFailure = false
+		ui.Info("Sensors of fan %s have recovered, resuming normal control", fan.GetId())
+	}
+
 	lastSetPwm := 0
 	if f.lastSetPwm != nil {
 		lastSetPwm = *(f.lastSetPwm)
@@ -230,18 +570,26 @@ func (f *PidFanController) UpdateFanSpeed() error {
 	}
 
 	// calculate the direct optimal target speed
-	target := f.calculateTargetPwm()
+	target, err := f.calculateTargetPwm()
+	if err != nil {
+		f.applyCurveFailurePolicy(err)
+		f.publishPolicyTickEvent(TickStateCurveFailure, err.Error())
+		return nil
+	} else if f.curveFailure {
+		f.curveFailure = false
+		ui.Info("Curve of fan %s can be evaluated again, resuming normal control", fan.GetId())
+	}
+	if target >= 0 {
+		target = f.applyHysteresis(target, time.Now())
+	}
 
-	// ask the PID controller how to proceed
+	// ask the PID controller how to proceed, the loop is stateful so it must only be advanced once per iteration
 	pidChange := math.Ceil(f.pidLoop.Loop(float64(target), float64(lastSetPwm)))
 
-	// the last value set on the pid controller target
-	pidControllerTarget := math.Ceil(f.pidLoop.Loop(float64(target), float64(lastSetPwm)))
-	pidControllerTarget = pidControllerTarget + pidChange
-
 	// ensure we are within sane bounds
-	coerced := util.Coerce(float64(lastSetPwm)+pidControllerTarget, 0, 255)
+	coerced := util.Coerce(float64(lastSetPwm)+pidChange, 0, 255)
 	roundedTarget := int(math.Round(coerced))
+	roundedTarget = f.applyRampLimits(lastSetPwm, roundedTarget, time.Now())
 
 	if target >= 0 {
 		_ = trySetManualPwm(f.fan)
@@ -249,15 +597,30 @@ func (f *PidFanController) UpdateFanSpeed() error {
 		if err != nil {
 			ui.Error("Error setting %s: %v", fan.GetId(), err)
 		}
+		lastSetPwm = roundedTarget
 	}
 
+	f.publishTickEvent(TickStateNormal, "", target, f.mapToClosestDistinct(lastSetPwm))
+
 	return nil
 }
 
-func (f *PidFanController) RunInitializationSequence() (err error) {
+func (f *PidFanController) RunInitializationSequence(ctx context.Context) (err error) {
 	fan := f.fan
 
-	f.computePwmMap()
+	f.storeOriginalState()
+	defer func() {
+		if err != nil {
+			// don't leave the fan at whatever pwm value was measured last
+			f.restorePwmEnabled()
+		}
+	}()
+
+	err = f.computePwmMap(ctx)
+	if err != nil {
+		ui.Error("Unable to compute pwm map of fan %s: %v", fan.GetId(), err)
+		return err
+	}
 
 	err = f.persistence.SaveFanPwmMap(fan.GetId(), f.pwmMap)
 	if err != nil {
@@ -269,89 +632,335 @@ func (f *PidFanController) RunInitializationSequence() (err error) {
 		ui.Info("Fan '%s' doesn't support RPM sensor, skipping fan curve measurement", fan.GetId())
 		return nil
 	}
+
+	checkpoint, loadErr := f.persistence.LoadFanInitializationCheckpoint(fan.GetId())
+	if loadErr != nil || checkpoint.CurveData == nil {
+		checkpoint = persistence.FanInitializationCheckpoint{CurveData: map[int]float64{}}
+	} else {
+		ui.Info("Resuming initialization of fan %s, %d PWM values have already been measured", fan.GetId(), len(checkpoint.CurveData))
+	}
+
+	sweepConfig := configuration.SweepConfig{Strategy: configuration.SweepStrategyFull}
+	if fan.GetConfig().Sweep != nil {
+		sweepConfig = *fan.GetConfig().Sweep
+	}
+	pendingMeasurements := estimateSweepMeasurements(sweepConfig, len(f.pwmValuesWithDistinctTarget), len(checkpoint.CurveData))
+
+	f.progress.start(time.Now(), time.Duration(len(checkpoint.CurveData))*initializationStepDuration)
+	defer f.progress.stop()
+
 	ui.Info("Measuring RPM curve...")
+	f.beginProgressPhase(InitializationPhaseFanCurve, f.estimateRemainingInitialization(pendingMeasurements, checkpoint.MinPwm == nil, len(f.pwmValuesWithDistinctTarget)/2))
 
 	err = trySetManualPwm(fan)
 	if err != nil {
 		ui.Warning("Could not enable manual fan mode on %s, trying to continue anyway...", fan.GetId())
 	}
 
-	curveData := map[int]float64{}
-
 	initialMeasurement := true
-	for pwm := range f.pwmValuesWithDistinctTarget {
+	measure := func(pwm int) (float64, bool, error) {
 		// set a pwm
-		err = f.setPwm(pwm)
+		err := f.setPwm(pwm)
 		if err != nil {
 			ui.Error("Unable to run initialization sequence on %s: %v", fan.GetId(), err)
-			return err
+			return 0, false, err
 		}
 
 		actualPwm, err := fan.GetPwm()
 		if err != nil {
 			ui.Error("Fan %s: Unable to measure current PWM", fan.GetId())
-			return err
+			return 0, false, err
 		}
 		if actualPwm != pwm {
 			ui.Debug("Fan %s: Actual PWM value differs from requested one, skipping. Requested: %d Actual: %d", fan.GetId(), pwm, actualPwm)
-			continue
+			f.advanceProgress(initializationStepDuration)
+			return 0, false, nil
 		}
 
+		// wait for the fan speed to settle, the first change is usually the largest one
+		settleSamples := stepSettleSamples
 		if initialMeasurement {
 			initialMeasurement = false
-			f.waitForFanToSettle(fan)
-		} else {
-			// wait a bit to allow the fan speed to settle.
-			// since most sensors are update only each second,
-			// we wait double that to make sure we get
-			// the most recent measurement
-			time.Sleep(2 * time.Second)
+			settleSamples = initialSettleSamples
+		}
+		if err = f.waitForFanToSettle(ctx, fan, settleSamples); err != nil {
+			return 0, false, err
 		}
 
 		rpm, err := fan.GetRpm()
 		if err != nil {
 			ui.Error("Unable to measure RPM of fan %s", fan.GetId())
-			return err
+			return 0, false, err
 		}
-		ui.Debug("Measuring RPM of %s at PWM %d: %d", fan.GetId(), pwm, rpm)
+		ui.Debug("Measured RPM of %d at PWM %d for fan %s", rpm, pwm, fan.GetId())
 
-		// update rpm curve
 		fan.SetRpmAvg(float64(rpm))
-		curveData[pwm] = float64(rpm)
-
-		ui.Debug("Measured RPM of %d at PWM %d for fan %s", int(fan.GetRpmAvg()), pwm, fan.GetId())
+		return float64(rpm), true, nil
 	}
+	err = sweepFanCurve(sweepConfig, f.pwmValuesWithDistinctTarget, checkpoint.CurveData, measure, func() {
+		f.saveInitializationCheckpoint(checkpoint)
+		f.advanceProgress(initializationStepDuration)
+	})
+	if err != nil {
+		return err
+	}
+	ui.Debug("Measured %d of %d PWM values of fan %s", len(checkpoint.CurveData), len(f.pwmValuesWithDistinctTarget), fan.GetId())
 
+	curveData := interpolateFanCurve(f.pwmValuesWithDistinctTarget, checkpoint.CurveData)
 	err = fan.AttachFanCurveData(&curveData)
 	if err != nil {
 		ui.Error("Failed to attach fan curve data to fan %s: %v", fan.GetId(), err)
 		return err
 	}
 
+	startPwm := fan.GetStartPwm()
+	minPwm := startPwm
+	if checkpoint.MinPwm != nil {
+		minPwm = *checkpoint.MinPwm
+	} else {
+		ui.Info("Measuring min PWM...")
+		f.beginProgressPhase(InitializationPhaseMinPwm, f.estimateRemainingInitialization(0, true, f.countPwmValuesBelow(startPwm)))
+		minPwm, err = f.measureMinPwm(ctx, startPwm)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			ui.Warning("Unable to measure min PWM of fan %s, using start PWM instead: %v", fan.GetId(), err)
+			minPwm = startPwm
+		}
+		checkpoint.MinPwm = &minPwm
+		f.saveInitializationCheckpoint(checkpoint)
+	}
+	ui.Info("Fan %s starts at PWM %d and keeps spinning down to PWM %d", fan.GetId(), startPwm, minPwm)
+
+	var controlLoop *configuration.ControlLoopConfig
+	if fan.GetConfig().ControlLoop == nil {
+		ui.Info("Measuring step response...")
+		f.beginProgressPhase(InitializationPhaseStepResponse, f.estimateRemainingInitialization(0, false, 0))
+		measured, err := f.measureControlLoop(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			// the default control loop is used instead
+			ui.Warning("Unable to measure step response of fan %s: %v", fan.GetId(), err)
+		} else {
+			controlLoop = &measured
+		}
+		f.advanceProgress(expectedSettleDuration + stepResponseDuration)
+	}
+
 	// save to database to restore it on restarts
 	err = f.persistence.SaveFanPwmData(fan)
 	if err != nil {
 		ui.Error("Failed to save fan PWM data for %s: %v", fan.GetId(), err)
+		return err
 	}
-	return err
+
+	fan.SetMinPwm(minPwm, false)
+	f.minPwmOffset = 0
+	f.stats.MinPwmOffset = 0
+	f.pwmBoundaries = &persistence.FanPwmBoundaries{
+		StartPwm: startPwm,
+		MinPwm:   minPwm,
+	}
+	err = f.persistence.SaveFanPwmBoundaries(fan.GetId(), *f.pwmBoundaries)
+	if err != nil {
+		ui.Error("Failed to save PWM boundaries of %s: %v", fan.GetId(), err)
+		return err
+	}
+
+	if controlLoop != nil {
+		err = f.persistence.SaveFanControlLoop(fan.GetId(), *controlLoop)
+		if err != nil {
+			ui.Error("Failed to save control loop of %s: %v", fan.GetId(), err)
+			return err
+		}
+	}
+
+	if err = f.persistence.DeleteFanInitializationCheckpoint(fan.GetId()); err != nil {
+		ui.Warning("Failed to delete initialization checkpoint of fan %s: %v", fan.GetId(), err)
+	}
+	return nil
 }
 
-// read the current value of a fan RPM sensor and append it to the moving window
-func measureRpm(fan fans.Fan) {
-	pwm, err := fan.GetPwm()
+// saveInitializationCheckpoint saves the measurements done so far, so the initialization sequence
+// can be resumed if it is aborted
+func (f *PidFanController) saveInitializationCheckpoint(checkpoint persistence.FanInitializationCheckpoint) {
+	if err := f.persistence.SaveFanInitializationCheckpoint(f.fan.GetId(), checkpoint); err != nil {
+		ui.Warning("Failed to save initialization checkpoint of fan %s: %v", f.fan.GetId(), err)
+	}
+}
+
+// estimateRemainingInitialization returns the expected duration of the remaining measurements of the initialization sequence
+func (f *PidFanController) estimateRemainingInitialization(pendingPwmValues int, measureMinPwm bool, minPwmSteps int) time.Duration {
+	remaining := time.Duration(pendingPwmValues) * initializationStepDuration
+	if measureMinPwm {
+		remaining += expectedSettleDuration + time.Duration(minPwmSteps)*initializationStepDuration
+	}
+	if f.fan.GetConfig().ControlLoop == nil {
+		remaining += expectedSettleDuration + stepResponseDuration
+	}
+	return remaining
+}
+
+// countPwmValuesBelow returns the number of distinct pwm values lower than the given one
+func (f *PidFanController) countPwmValuesBelow(pwm int) int {
+	count := 0
+	for _, value := range f.pwmValuesWithDistinctTarget {
+		if value < pwm {
+			count++
+		}
+	}
+	return count
+}
+
+// beginProgressPhase starts the given phase of the initialization sequence and logs the progress
+func (f *PidFanController) beginProgressPhase(phase string, remaining time.Duration) {
+	f.progress.begin(phase, remaining)
+	f.logProgress()
+}
+
+// advanceProgress marks a measurement of the initialization sequence as done and logs the progress every 10%
+func (f *PidFanController) advanceProgress(expected time.Duration) {
+	before := f.progress.get(time.Now())
+	f.progress.advance(expected)
+	after := f.progress.get(time.Now())
+	if before != nil && after != nil && int(before.Percent/10) != int(after.Percent/10) {
+		f.logProgress()
+	}
+}
+
+func (f *PidFanController) logProgress() {
+	progress := f.progress.get(time.Now())
+	if progress == nil {
+		return
+	}
+	ui.Info("Initialization of fan %s: %.0f%% done (%s), about %s remaining",
+		f.fan.GetId(), progress.Percent, progress.Phase, time.Until(progress.Eta).Round(time.Second))
+}
+
+// measureMinPwm lowers the pwm of the spinning fan step by step, starting at the given start pwm,
+// to find the lowest pwm at which it keeps spinning. This is usually lower than the start pwm,
+// since a fan needs more power to start rotating than to keep rotating.
+func (f *PidFanController) measureMinPwm(ctx context.Context, startPwm int) (int, error) {
+	fan := f.fan
+
+	if err := f.setPwm(startPwm); err != nil {
+		return startPwm, err
+	}
+	if err := f.waitForFanToSettle(ctx, fan, initialSettleSamples); err != nil {
+		return startPwm, err
+	}
+	f.advanceProgress(expectedSettleDuration)
+
+	return findMinPwm(f.pwmValuesWithDistinctTarget, startPwm, func(pwm int) (int, error) {
+		if err := f.setPwm(pwm); err != nil {
+			return 0, err
+		}
+		// give the fan time to slow down
+		if err := f.waitForFanToSettle(ctx, fan, stepSettleSamples); err != nil {
+			return 0, err
+		}
+		f.advanceProgress(initializationStepDuration)
+		return fan.GetRpm()
+	})
+}
+
+// findMinPwm returns the lowest of the given (sorted) pwm values below startPwm, at which measureRpm
+// reports a spinning fan, while descending from startPwm. startPwm is returned if the fan stops right away.
+func findMinPwm(pwmValues []int, startPwm int, measureRpm func(pwm int) (int, error)) (int, error) {
+	minPwm := startPwm
+	for idx := len(pwmValues) - 1; idx >= 0; idx-- {
+		pwm := pwmValues[idx]
+		if pwm >= startPwm {
+			continue
+		}
+		rpm, err := measureRpm(pwm)
+		if err != nil {
+			return minPwm, err
+		}
+		if rpm <= 0 {
+			break
+		}
+		minPwm = pwm
+	}
+	return minPwm, nil
+}
+
+// measureControlLoop records the RPM of the fan after a pwm step and derives the constants
+// of the control loop from it
+func (f *PidFanController) measureControlLoop(ctx context.Context) (configuration.ControlLoopConfig, error) {
+	fan := f.fan
+
+	// stay within the range where the fan is spinning
+	startPwm := fan.GetStartPwm()
+	maxPwm := fan.GetMaxPwm()
+	lowPwm := startPwm + (maxPwm-startPwm)/4
+	highPwm := startPwm + (maxPwm-startPwm)*3/4
+	if highPwm <= lowPwm {
+		return configuration.ControlLoopConfig{}, fmt.Errorf("pwm range %d..%d is too small", startPwm, maxPwm)
+	}
+
+	if err := f.setPwm(lowPwm); err != nil {
+		return configuration.ControlLoopConfig{}, err
+	}
+	if err := f.waitForFanToSettle(ctx, fan, initialSettleSamples); err != nil {
+		return configuration.ControlLoopConfig{}, err
+	}
+	initialRpm, err := fan.GetRpm()
 	if err != nil {
-		ui.Warning("Error reading PWM value of fan %s: %v", fan.GetId(), err)
+		return configuration.ControlLoopConfig{}, err
+	}
+
+	if err = f.setPwm(highPwm); err != nil {
+		return configuration.ControlLoopConfig{}, err
 	}
-	rpm, err := fan.GetRpm()
+	start := time.Now()
+	var samples []tuning.Sample
+	for time.Since(start) < stepResponseDuration {
+		if err = sleep(ctx, stepResponseSampleRate); err != nil {
+			return configuration.ControlLoopConfig{}, err
+		}
+		rpm, err := fan.GetRpm()
+		if err != nil {
+			return configuration.ControlLoopConfig{}, err
+		}
+		samples = append(samples, tuning.Sample{Time: time.Since(start), Value: float64(rpm)})
+	}
+
+	response, err := tuning.AnalyzeStepResponse(float64(initialRpm), float64(highPwm-lowPwm), samples)
+	if err != nil {
+		return configuration.ControlLoopConfig{}, err
+	}
+	gains := response.ControlLoopGains(f.updateRate)
+	ui.Info("Step response of fan %s: dead time %s, time constant %s, control loop: P %g, I %g, D %g",
+		fan.GetId(), response.DeadTime, response.TimeConstant, gains.P, gains.I, gains.D)
+
+	return configuration.ControlLoopConfig{
+		P: gains.P,
+		I: gains.I,
+		D: gains.D,
+	}, nil
+}
+
+// read the current value of a fan RPM sensor and append it to the moving window,
+// the returned pwm and rpm values can be used as a sample of the fan curve if err is nil
+func measureRpm(fan fans.Fan) (pwm int, rpm int, err error) {
+	pwm, pwmErr := fan.GetPwm()
+	if pwmErr != nil {
+		ui.Warning("Error reading PWM value of fan %s: %v", fan.GetId(), pwmErr)
+	}
+	rpm, err = fan.GetRpm()
 	if err != nil {
 		ui.Warning("Error reading RPM value of fan %s: %v", fan.GetId(), err)
+		return pwm, rpm, err
 	}
 
 	updatedRpmAvg := util.UpdateSimpleMovingAvg(fan.GetRpmAvg(), configuration.CurrentConfig.RpmRollingWindowSize, float64(rpm))
 	fan.SetRpmAvg(updatedRpmAvg)
 
-	pwmRpmMap := fan.GetFanCurveData()
-	(*pwmRpmMap)[pwm] = float64(rpm)
+	return pwm, rpm, pwmErr
 }
 
 func trySetManualPwm(fan fans.Fan) error {
@@ -370,6 +979,28 @@ func trySetManualPwm(fan fans.Fan) error {
 	return err
 }
 
+// storeOriginalState remembers the current pwm and pwm_enable values of the fan,
+// so they can be restored when the controller stops
+func (f *PidFanController) storeOriginalState() {
+	fan := f.fan
+
+	// store original pwm value
+	pwm, err := fan.GetPwm()
+	if err != nil {
+		ui.Warning("Cannot read pwm value of %s", fan.GetId())
+	}
+	f.originalPwmValue = pwm
+
+	// store original pwm_enable value
+	if fan.Supports(fans.FeatureControlMode) {
+		pwmEnabled, err := fan.GetPwmEnabled()
+		if err != nil {
+			ui.Warning("Cannot read pwm_enable value of %s", fan.GetId())
+		}
+		f.originalPwmEnabled = fans.ControlMode(pwmEnabled)
+	}
+}
+
 func (f *PidFanController) restorePwmEnabled() {
 	ui.Info("Trying to restore fan settings for %s...", f.fan.GetId())
 
@@ -393,12 +1024,12 @@ func (f *PidFanController) restorePwmEnabled() {
 }
 
 // calculates the optimal pwm for a fan with the given target level.
-// returns -1 if no rpm is detected even at fan.maxPwm
-func (f *PidFanController) calculateTargetPwm() int {
+// returns -1 if no rpm is detected even at fan.maxPwm, or an error if the curve can't be evaluated
+func (f *PidFanController) calculateTargetPwm() (int, error) {
 	fan := f.fan
-	target, err := f.curve.Evaluate()
+	target, err := f.evaluateCurve()
 	if err != nil {
-		ui.Fatal("Unable to calculate optimal PWM value for %s: %v", fan.GetId(), err)
+		return 0, err
 	}
 
 	// ensure target value is within bounds of possible values
@@ -414,8 +1045,22 @@ func (f *PidFanController) calculateTargetPwm() int {
 	maxPwm := fan.GetMaxPwm()
 	minPwm := fan.GetMinPwm() + f.minPwmOffset
 
-	// TODO: this assumes a linear curve, but it might be something else
-	target = minPwm + int((float64(target)/fans.MaxPwmValue)*(float64(maxPwm)-float64(minPwm)))
+	fixedPwm := len(f.lastCurveId) <= 0
+
+	if fixedPwm {
+		// a fixed override is a pwm value, which is not mapped to the range of the fan
+	} else if f.groupTargetRpm != nil {
+		// all fans of the group are driven at the same RPM
+		target = fans.ComputePwmForRpm(fan, *f.groupTargetRpm, minPwm, maxPwm)
+	} else if maxRpm := fans.GetMaxRpm(fan); fan.GetConfig().RpmTarget && maxRpm > 0 {
+		// interpret the target value as a percentage of the max RPM of the fan
+		// and use the measured fan curve data to find the matching pwm value
+		targetRpm := (float64(target) / fans.MaxPwmValue) * maxRpm
+		target = fans.ComputePwmForRpm(fan, targetRpm, minPwm, maxPwm)
+	} else {
+		// TODO: this assumes a linear curve, but it might be something else
+		target = minPwm + int((float64(target)/fans.MaxPwmValue)*(float64(maxPwm)-float64(minPwm)))
+	}
 
 	if f.lastSetPwm != nil && f.pwmMap != nil {
 		lastSetPwm := *(f.lastSetPwm)
@@ -429,32 +1074,367 @@ func (f *PidFanController) calculateTargetPwm() int {
 		}
 	}
 
+	startPwm := fan.GetStartPwm()
+	stallPwm := f.getStallPwm(minPwm, startPwm)
+	wasSpinning := f.spinning
+	f.updateSpinning(startPwm, stallPwm)
+	if fixedPwm {
+		return target, nil
+	}
+
 	if fan.Supports(fans.FeatureRpmSensor) {
 		// make sure fans never stop by validating the current RPM
 		// and adjusting the target PWM value upwards if necessary
 		shouldNeverStop := fan.ShouldNeverStop()
-		if shouldNeverStop && (f.lastSetPwm != nil || f.lastSetPwm == &target) {
+		if shouldNeverStop && f.lastSetPwm != nil && !f.spinning {
 			avgRpm := fan.GetRpmAvg()
-			if avgRpm <= 0 {
-				if target >= maxPwm {
-					ui.Error("CRITICAL: Fan %s avg. RPM is %d, even at PWM value %d", fan.GetId(), int(avgRpm), target)
-					return -1
-				}
+			if target >= maxPwm {
+				ui.Error("CRITICAL: Fan %s avg. RPM is %d, even at PWM value %d", fan.GetId(), int(avgRpm), target)
+				return -1, nil
+			}
+			if wasSpinning {
+				// the fan stopped while it was driven within its control range,
+				// a fan at rest that didn't start yet is handled by the spin up below
 				oldOffset := f.minPwmOffset
 				ui.Warning("WARNING: Increasing minPWM of %s from %d to %d, which is supposed to never stop, but RPM is %d",
 					fan.GetId(), oldOffset, oldOffset+1, int(avgRpm))
 				f.increaseMinPwmOffset()
-				fan.SetMinPwm(f.minPwmOffset, true)
 				target++
-
-				// set the moving avg to a value > 0 to prevent
-				// this increase from happening too fast
-				fan.SetRpmAvg(1)
+				stallPwm++
 			}
 		}
 	}
 
-	return target
+	if !f.spinning && target >= stallPwm && target > fans.MinPwmValue && target < startPwm {
+		// a fan at rest only starts at its start pwm, it keeps spinning down to its min pwm once it runs
+		ui.Debug("Fan %s is at rest, driving it at start PWM %d until it spins", fan.GetId(), startPwm)
+		target = startPwm
+	}
+
+	return target, nil
+}
+
+// getStallPwm returns the lowest pwm value at which the fan is supposed to be spinning,
+// given the lower bound of its control range and its start pwm
+func (f *PidFanController) getStallPwm(minPwm int, startPwm int) int {
+	if f.fan.ShouldNeverStop() {
+		return minPwm
+	}
+	if f.pwmBoundaries != nil {
+		// values below the measured min pwm stop the fan anyway
+		return f.pwmBoundaries.MinPwm + f.minPwmOffset
+	}
+	return startPwm
+}
+
+// updateSpinning updates whether the fan is spinning, based on its RPM if it has an RPM sensor,
+// or the last pwm value that was set otherwise
+func (f *PidFanController) updateSpinning(startPwm int, stallPwm int) {
+	if f.fan.Supports(fans.FeatureRpmSensor) {
+		f.spinning = f.fan.GetRpmAvg() > 0
+		return
+	}
+	if f.lastSetPwm == nil {
+		f.spinning = false
+	} else if *f.lastSetPwm >= startPwm {
+		f.spinning = true
+	} else if *f.lastSetPwm < stallPwm {
+		f.spinning = false
+	}
+}
+
+// detectStall compares the RPM of the fan with the RPM measured during initialization for the current pwm,
+// and marks the fan as stalled if it stays below the configured threshold for too long
+func (f *PidFanController) detectStall(now time.Time) {
+	fan := f.fan
+	config := fan.GetConfig().StallDetection
+	if config == nil || !fan.Supports(fans.FeatureRpmSensor) || len(f.expectedRpm) <= 0 || f.lastSetPwm == nil {
+		return
+	}
+
+	threshold := config.Threshold
+	if threshold <= 0 {
+		threshold = configuration.DefaultStallThreshold
+	}
+	duration := config.Duration
+	if duration <= 0 {
+		duration = configuration.DefaultStallDuration
+	}
+
+	pwm := f.mapToClosestDistinct(*f.lastSetPwm)
+	expected := f.expectedRpm[util.FindClosest(pwm, util.SortedKeys(f.expectedRpm))]
+	actual := fan.GetRpmAvg()
+
+	if expected <= 0 || actual >= expected*threshold {
+		f.stallSince = time.Time{}
+		if f.stats.Stalled {
+			ui.Info("Fan %s is spinning again (%d RPM at PWM %d)", fan.GetId(), int(actual), pwm)
+			f.setStalled(false)
+		}
+		return
+	}
+
+	if f.stallSince.IsZero() {
+		f.stallSince = now
+	}
+	if !f.stats.Stalled && now.Sub(f.stallSince) >= duration {
+		ui.ErrorAndNotify("Fan Stall", "Fan %s seems to be stalled: %d RPM at PWM %d, expected ~%d RPM",
+			fan.GetId(), int(actual), pwm, int(expected))
+		f.stats.StallCount++
+		f.setStalled(true)
+	}
+}
+
+// setStalled updates the stall state of the fan, which is used to apply the stall action to all other fans
+func (f *PidFanController) setStalled(stalled bool) {
+	f.stats.Stalled = stalled
+
+	stalledFansMutex.Lock()
+	defer stalledFansMutex.Unlock()
+	if !stalled {
+		delete(stalledFans, f.fan.GetId())
+		return
+	}
+
+	action := configuration.StallActionNone
+	if config := f.fan.GetConfig().StallDetection; config != nil && len(config.Action) > 0 {
+		action = config.Action
+	}
+	stalledFans[f.fan.GetId()] = action
+}
+
+// getOtherStalledFan returns the id of a stalled fan, other than the given one, which uses the given stall action
+func getOtherStalledFan(fanId string, action string) (string, bool) {
+	stalledFansMutex.RLock()
+	defer stalledFansMutex.RUnlock()
+	for id, stallAction := range stalledFans {
+		if id != fanId && stallAction == action {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// setMaxSpeed sets the fan to its max speed, bypassing the curve
+func (f *PidFanController) setMaxSpeed() {
+	_ = trySetManualPwm(f.fan)
+	err := f.setPwm(fans.MaxPwmValue)
+	if err != nil {
+		ui.Error("Error setting %s: %v", f.fan.GetId(), err)
+	}
+	// start ramping from the max value once normal control resumes
+	f.rampPwm = nil
+	f.lastRampUpdate = time.Time{}
+}
+
+// getActiveCurve returns the curve currently used to control the fan, taking an active override into account.
+// Returns nil if the override defines a fixed pwm value.
+func (f *PidFanController) getActiveCurve() curves.SpeedCurve {
+	override := f.GetOverride()
+	if override != nil {
+		if override.Pwm != nil {
+			return nil
+		}
+		if curve, exists := curves.GetSpeedCurve(override.CurveId); exists {
+			return curve
+		}
+	}
+	return f.getCurve()
+}
+
+// getStaleSensors returns the ids of all stale sensors used by the active curve of the fan
+func (f *PidFanController) getStaleSensors() (result []string) {
+	curve := f.getActiveCurve()
+	if curve == nil {
+		return result
+	}
+	for _, sensorId := range curves.GetSensorIds(curve) {
+		if sensor, exists := sensors.GetSensor(sensorId); exists && sensors.IsStale(sensor) {
+			result = append(result, sensorId)
+		}
+	}
+	return result
+}
+
+// applySensorFailurePolicy applies the onSensorFailure policy of the fan
+func (f *PidFanController) applySensorFailurePolicy(staleSensors []string) {
+	fan := f.fan
+	policy := f.getSensorFailurePolicy()
+
+	if !f.sensorFailure {
+		f.sensorFailure = true
+		ui.WarningAndNotify("Sensor Failure", "Fan %s: sensor(s) %s are stale, applying onSensorFailure policy '%s'",
+			fan.GetId(), strings.Join(staleSensors, ", "), policy)
+		f.enterFailurePolicy(policy)
+	}
+
+	f.applyFailurePolicy(policy)
+}
+
+// applyCurveFailurePolicy applies the onSensorFailure policy of the fan, if its curve can't be evaluated,
+// e.g. because an expression divides by zero for the current sensor values
+func (f *PidFanController) applyCurveFailurePolicy(err error) {
+	fan := f.fan
+	policy := f.getSensorFailurePolicy()
+
+	if !f.curveFailure {
+		f.curveFailure = true
+		f.stats.CurveErrorCount++
+		ui.WarningAndNotify("Curve Failure", "Fan %s: unable to evaluate curve: %v, applying onSensorFailure policy '%s'",
+			fan.GetId(), err, policy)
+		f.enterFailurePolicy(policy)
+	} else {
+		ui.Debug("Fan %s: unable to evaluate curve: %v", fan.GetId(), err)
+	}
+
+	f.applyFailurePolicy(policy)
+}
+
+// getSensorFailurePolicy returns the onSensorFailure policy of the fan, falling back to maxSpeed
+// if none is configured or the policy isn't supported by the fan
+func (f *PidFanController) getSensorFailurePolicy() string {
+	fan := f.fan
+	policy := fan.GetConfig().OnSensorFailure
+	if len(policy) <= 0 {
+		policy = configuration.SensorFailureMaxSpeed
+	}
+	if policy == configuration.SensorFailureAuto && !fan.Supports(fans.FeatureControlMode) {
+		policy = configuration.SensorFailureMaxSpeed
+	}
+	return policy
+}
+
+// enterFailurePolicy is called once when the given failure policy starts to be applied
+func (f *PidFanController) enterFailurePolicy(policy string) {
+	if policy == configuration.SensorFailureAuto {
+		err := f.fan.SetPwmEnabled(fans.ControlModeAutomatic)
+		if err != nil {
+			ui.Error("Unable to hand control of fan %s back to the mainboard: %v", f.fan.GetId(), err)
+		}
+	}
+}
+
+// applyFailurePolicy applies the given failure policy in every iteration of the control loop while it is active
+func (f *PidFanController) applyFailurePolicy(policy string) {
+	switch policy {
+	case configuration.SensorFailureMaxSpeed:
+		f.setMaxSpeed()
+	case configuration.SensorFailureHoldLast, configuration.SensorFailureAuto:
+		// leave the fan alone
+	}
+}
+
+// applyHysteresis suppresses changes of the target pwm value while the sensors used by the curve
+// haven't changed by at least the configured hysteresis, as well as changes of direction which
+// follow the previous change too quickly
+func (f *PidFanController) applyHysteresis(target int, now time.Time) int {
+	config := f.fan.GetConfig()
+	if config.Hysteresis <= 0 && config.MinDirectionChangeInterval <= 0 {
+		return target
+	}
+
+	sensorValues := f.getCurveSensorValues()
+	if f.hysteresisTarget == nil || f.GetOverride() != nil {
+		f.acceptHysteresisTarget(target, 0, sensorValues, now)
+		return target
+	}
+
+	current := *f.hysteresisTarget
+	if target == current {
+		return current
+	}
+
+	direction := 1
+	if target < current {
+		direction = -1
+	}
+
+	changed := false
+	for id, value := range sensorValues {
+		reference, exists := f.hysteresisSensorValues[id]
+		if !exists || math.Abs(value-reference) >= config.Hysteresis {
+			changed = true
+			break
+		}
+	}
+	if len(sensorValues) <= 0 {
+		// nothing to compare against, f.ex. because of a fixed override
+		changed = true
+	}
+
+	tooEarly := f.lastDirection != 0 && direction != f.lastDirection &&
+		now.Sub(f.lastTargetChange) < config.MinDirectionChangeInterval
+
+	if changed && !tooEarly {
+		f.acceptHysteresisTarget(target, direction, sensorValues, now)
+		return target
+	}
+
+	// never undercut the (possibly increased) minimum pwm of the fan
+	minPwm := f.fan.GetMinPwm() + f.minPwmOffset
+	if current < minPwm && target >= minPwm {
+		f.acceptHysteresisTarget(minPwm, 1, sensorValues, now)
+		return minPwm
+	}
+
+	return current
+}
+
+func (f *PidFanController) acceptHysteresisTarget(target int, direction int, sensorValues map[string]float64, now time.Time) {
+	f.hysteresisTarget = &target
+	f.hysteresisSensorValues = sensorValues
+	if direction != 0 {
+		f.lastDirection = direction
+		f.lastTargetChange = now
+	}
+}
+
+// getCurveSensorValues returns the current values (in °C) of all sensors used by the last evaluated curve
+func (f *PidFanController) getCurveSensorValues() map[string]float64 {
+	result := map[string]float64{}
+	curve, exists := curves.GetSpeedCurve(f.lastCurveId)
+	if !exists {
+		return result
+	}
+	for _, sensorId := range curves.GetSensorIds(curve) {
+		if sensor, exists := sensors.GetSensor(sensorId); exists {
+			result[sensorId] = sensor.GetMovingAvg() / 1000
+		}
+	}
+	return result
+}
+
+// applyRampLimits limits the change from the last set pwm value to the given target
+// according to the rampUp and rampDown rates (pwm per second) of the fan
+func (f *PidFanController) applyRampLimits(lastSetPwm int, target int, now time.Time) int {
+	config := f.fan.GetConfig()
+	if config.RampUp <= 0 && config.RampDown <= 0 {
+		return target
+	}
+
+	current := float64(lastSetPwm)
+	if f.rampPwm != nil && int(math.Round(*f.rampPwm)) == lastSetPwm {
+		// continue with the exact value of the last step, otherwise
+		// rates smaller than one step per tick would never change anything
+		current = *f.rampPwm
+	}
+
+	elapsed := 0.0
+	if !f.lastRampUpdate.IsZero() {
+		elapsed = now.Sub(f.lastRampUpdate).Seconds()
+	}
+
+	next := float64(target)
+	if config.RampUp > 0 && next > current {
+		next = math.Min(next, current+config.RampUp*elapsed)
+	}
+	if config.RampDown > 0 && next < current {
+		next = math.Max(next, current-config.RampDown*elapsed)
+	}
+
+	f.rampPwm = &next
+	f.lastRampUpdate = now
+	return int(math.Round(next))
 }
 
 // set the pwm speed of a fan to the specified value (0..255)
@@ -473,17 +1453,38 @@ func (f *PidFanController) setPwm(target int) (err error) {
 	return f.fan.SetPwm(closestAvailable)
 }
 
-func (f *PidFanController) waitForFanToSettle(fan fans.Fan) {
-	// TODO: this "waiting" logic could also be applied to the other measurements
+// sleep waits for the given duration, or returns the error of the given context if it is canceled before
+func sleep(ctx context.Context, duration time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(duration):
+		return nil
+	}
+}
+
+// waitForFanToSettle waits until the RPM of the given fan has changed less than maxRpmDiffForSettledFan
+// between the given number of consecutive measurements, which are taken once per second
+func (f *PidFanController) waitForFanToSettle(ctx context.Context, fan fans.Fan, samples int) error {
 	diffThreshold := configuration.CurrentConfig.MaxRpmDiffForSettledFan
 
-	measuredRpmDiffWindow := util.CreateRollingWindow(10)
-	util.FillWindow(measuredRpmDiffWindow, 10, 2*diffThreshold)
+	measuredRpmDiffWindow := util.CreateRollingWindow(samples)
+	util.FillWindow(measuredRpmDiffWindow, samples, 2*diffThreshold)
 	measuredRpmDiffMax := 2 * diffThreshold
-	oldRpm := 0
+	oldRpm, err := fan.GetRpm()
+	if err != nil {
+		oldRpm = 0
+	}
+	start := time.Now()
 	for !(measuredRpmDiffMax < diffThreshold) {
+		if time.Since(start) >= maxSettleDuration {
+			ui.Debug("Fan %s did not settle within %s (current RPM max diff: %f), continuing anyway", fan.GetId(), maxSettleDuration, measuredRpmDiffMax)
+			return nil
+		}
 		ui.Debug("Waiting for fan %s to settle (current RPM max diff: %f)...", fan.GetId(), measuredRpmDiffMax)
-		time.Sleep(1 * time.Second)
+		if err := sleep(ctx, 1*time.Second); err != nil {
+			return err
+		}
 
 		currentRpm, err := fan.GetRpm()
 		if err != nil {
@@ -495,6 +1496,7 @@ func (f *PidFanController) waitForFanToSettle(fan fans.Fan) {
 		measuredRpmDiffMax = math.Ceil(util.GetWindowMax(measuredRpmDiffWindow))
 	}
 	ui.Debug("Fan %s has settled (current RPM max diff: %f)", fan.GetId(), measuredRpmDiffMax)
+	return nil
 }
 
 func (f *PidFanController) mapToClosestDistinct(target int) int {
@@ -503,7 +1505,7 @@ func (f *PidFanController) mapToClosestDistinct(target int) int {
 }
 
 // computePwmMap computes a mapping between "requested pwm value" -> "actual set pwm value"
-func (f *PidFanController) computePwmMap() (err error) {
+func (f *PidFanController) computePwmMap(ctx context.Context) (err error) {
 	if configuration.CurrentConfig.RunFanInitializationInParallel == false {
 		InitializationSequenceMutex.Lock()
 		defer InitializationSequenceMutex.Unlock()
@@ -545,21 +1547,29 @@ func (f *PidFanController) computePwmMap() (err error) {
 	}
 
 	ui.Info("Computing pwm map...")
-	f.computePwmMapAutomatically()
+	err = f.computePwmMapAutomatically(ctx)
+	if err != nil {
+		return err
+	}
 
 	ui.Debug("Saving pwm map to fan...")
 	return f.persistence.SaveFanPwmMap(f.fan.GetId(), f.pwmMap)
 }
 
-func (f *PidFanController) computePwmMapAutomatically() {
+func (f *PidFanController) computePwmMapAutomatically(ctx context.Context) error {
 	fan := f.fan
 	trySetManualPwm(fan)
 
 	// check every pwm value
 	pwmMap := map[int]int{}
 	for i := fans.MaxPwmValue; i >= fans.MinPwmValue; i-- {
-		fan.SetPwm(i)
-		time.Sleep(10 * time.Millisecond)
+		err := fan.SetPwm(i)
+		if err != nil {
+			return fmt.Errorf("unable to set PWM value %d of fan %s: %w", i, fan.GetId(), err)
+		}
+		if err = sleep(ctx, 10*time.Millisecond); err != nil {
+			return err
+		}
 		pwm, err := fan.GetPwm()
 		if err != nil {
 			ui.Warning("Error reading PWM value of fan %s: %v", fan.GetId(), err)
@@ -568,7 +1578,7 @@ func (f *PidFanController) computePwmMapAutomatically() {
 	}
 	f.pwmMap = pwmMap
 
-	fan.SetPwm(f.pwmMap[fan.GetStartPwm()])
+	return fan.SetPwm(f.pwmMap[fan.GetStartPwm()])
 }
 
 func (f *PidFanController) updateDistinctPwmValues() {
@@ -583,4 +1593,51 @@ func (f *PidFanController) increaseMinPwmOffset() {
 	f.minPwmOffset += 1
 	f.stats.MinPwmOffset = f.minPwmOffset
 	f.stats.IncreasedMinPwmCount += 1
+
+	// remember the increase, so it survives restarts
+	if f.pwmBoundaries == nil {
+		startPwm := f.fan.GetStartPwm()
+		f.pwmBoundaries = &persistence.FanPwmBoundaries{
+			StartPwm: startPwm,
+			MinPwm:   startPwm,
+		}
+	}
+	f.pwmBoundaries.MinPwmOffset = f.minPwmOffset
+	if err := f.persistence.SaveFanPwmBoundaries(f.fan.GetId(), *f.pwmBoundaries); err != nil {
+		ui.Warning("Failed to save minPwm offset of fan %s: %v", f.fan.GetId(), err)
+	}
+}
+
+// GetFanController returns the running controller of the fan with the given id
+func GetFanController(fanId string) (FanController, bool) {
+	fanControllerMapMutex.RLock()
+	defer fanControllerMapMutex.RUnlock()
+	c, exists := FanControllerMap[fanId]
+	return c, exists
+}
+
+// RegisterFanController adds the given controller to the FanControllerMap,
+// replacing any existing controller for the same fan
+func RegisterFanController(c FanController) {
+	fanControllerMapMutex.Lock()
+	defer fanControllerMapMutex.Unlock()
+	FanControllerMap[c.GetFanId()] = c
+}
+
+// RemoveFanController removes the controller of the fan with the given id from the FanControllerMap
+func RemoveFanController(fanId string) {
+	fanControllerMapMutex.Lock()
+	defer fanControllerMapMutex.Unlock()
+	delete(FanControllerMap, fanId)
+}
+
+// SnapshotFanControllerMap returns a copy of the FanControllerMap
+func SnapshotFanControllerMap() map[string]FanController {
+	fanControllerMapMutex.RLock()
+	defer fanControllerMapMutex.RUnlock()
+	result := make(map[string]FanController, len(FanControllerMap))
+	for id, c := range FanControllerMap {
+		result[id] = c
+	}
+	return result
 }
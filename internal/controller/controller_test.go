@@ -1,12 +1,16 @@
 package controller
 
 import (
+	"context"
+	"errors"
 	"github.com/markusressel/fan2go/internal/configuration"
 	"github.com/markusressel/fan2go/internal/curves"
 	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/persistence"
 	"github.com/markusressel/fan2go/internal/sensors"
 	"github.com/markusressel/fan2go/internal/util"
 	"github.com/stretchr/testify/assert"
+	"os"
 	"sort"
 	"testing"
 	"time"
@@ -27,7 +31,7 @@ func (sensor MockSensor) GetLabel() string {
 }
 
 func (sensor MockSensor) GetConfig() configuration.SensorConfig {
-	panic("not implemented")
+	return configuration.SensorConfig{ID: sensor.ID}
 }
 
 func (sensor MockSensor) GetValue() (result float64, err error) {
@@ -45,28 +49,37 @@ func (sensor *MockSensor) SetMovingAvg(avg float64) {
 type MockCurve struct {
 	ID    string
 	Value int
+	Err   error
 }
 
 func (c MockCurve) GetId() string {
 	return c.ID
 }
 
+func (c MockCurve) GetConfig() configuration.CurveConfig {
+	return configuration.CurveConfig{ID: c.ID}
+}
+
 func (c MockCurve) Evaluate() (value int, err error) {
-	return c.Value, nil
+	return c.Value, c.Err
 }
 
 type MockFan struct {
 	ID              string
 	PWM             int
 	MinPWM          int
+	StartPWM        int
 	RPM             int
 	curveId         string
 	shouldNeverStop bool
 	speedCurve      *map[int]float64
+	config          configuration.FanConfig
+	// if set, GetPwm blocks until the channel is closed
+	getPwmBlocker chan struct{}
 }
 
 func (fan MockFan) GetStartPwm() int {
-	return 0
+	return fan.StartPWM
 }
 
 func (fan *MockFan) SetStartPwm(pwm int, force bool) {
@@ -98,10 +111,13 @@ func (fan MockFan) GetRpmAvg() float64 {
 }
 
 func (fan *MockFan) SetRpmAvg(rpm float64) {
-	panic("not supported")
+	fan.RPM = int(rpm)
 }
 
 func (fan MockFan) GetPwm() (result int, err error) {
+	if fan.getPwmBlocker != nil {
+		<-fan.getPwmBlocker
+	}
 	return fan.PWM, nil
 }
 
@@ -120,11 +136,11 @@ func (fan *MockFan) AttachFanCurveData(curveData *map[int]float64) (err error) {
 }
 
 func (fan MockFan) GetPwmEnabled() (int, error) {
-	panic("implement me")
+	return int(fans.ControlModeAutomatic), nil
 }
 
 func (fan *MockFan) SetPwmEnabled(value fans.ControlMode) (err error) {
-	panic("implement me")
+	return nil
 }
 
 func (fan MockFan) IsPwmAuto() (bool, error) {
@@ -143,6 +159,10 @@ func (fan MockFan) GetCurveId() string {
 	return fan.curveId
 }
 
+func (fan MockFan) GetConfig() configuration.FanConfig {
+	return fan.config
+}
+
 func (fan MockFan) ShouldNeverStop() bool {
 	return fan.shouldNeverStop
 }
@@ -216,6 +236,30 @@ func (p mockPersistence) LoadFanPwmMap(fanId string) (map[int]int, error) {
 func (p mockPersistence) SaveFanPwmMap(fanId string, pwmMap map[int]int) (err error) { return nil }
 func (p mockPersistence) DeleteFanPwmMap(fanId string) (err error)                   { return nil }
 
+func (p mockPersistence) LoadFanControlLoop(fanId string) (configuration.ControlLoopConfig, error) {
+	return configuration.ControlLoopConfig{}, os.ErrNotExist
+}
+func (p mockPersistence) SaveFanControlLoop(fanId string, config configuration.ControlLoopConfig) (err error) {
+	return nil
+}
+func (p mockPersistence) DeleteFanControlLoop(fanId string) (err error) { return nil }
+
+func (p mockPersistence) LoadFanPwmBoundaries(fanId string) (persistence.FanPwmBoundaries, error) {
+	return persistence.FanPwmBoundaries{}, os.ErrNotExist
+}
+func (p mockPersistence) SaveFanPwmBoundaries(fanId string, boundaries persistence.FanPwmBoundaries) (err error) {
+	return nil
+}
+func (p mockPersistence) DeleteFanPwmBoundaries(fanId string) (err error) { return nil }
+
+func (p mockPersistence) LoadFanInitializationCheckpoint(fanId string) (persistence.FanInitializationCheckpoint, error) {
+	return persistence.FanInitializationCheckpoint{}, os.ErrNotExist
+}
+func (p mockPersistence) SaveFanInitializationCheckpoint(fanId string, checkpoint persistence.FanInitializationCheckpoint) (err error) {
+	return nil
+}
+func (p mockPersistence) DeleteFanInitializationCheckpoint(fanId string) (err error) { return nil }
+
 func createOneToOnePwmMap() map[int]int {
 	var pwmMap = map[int]int{}
 	for i := fans.MinPwmValue; i <= fans.MaxPwmValue; i++ {
@@ -333,9 +377,10 @@ func TestCalculateTargetSpeedLinear(t *testing.T) {
 	controller.updateDistinctPwmValues()
 
 	// WHEN
-	optimal := controller.calculateTargetPwm()
+	optimal, err := controller.calculateTargetPwm()
 
 	// THEN
+	assert.NoError(t, err)
 	assert.Equal(t, 127, optimal)
 }
 
@@ -377,13 +422,72 @@ func TestCalculateTargetSpeedNeverStop(t *testing.T) {
 	controller.updateDistinctPwmValues()
 
 	// WHEN
-	target := controller.calculateTargetPwm()
+	target, err := controller.calculateTargetPwm()
 
 	// THEN
+	assert.NoError(t, err)
 	assert.Greater(t, fan.GetMinPwm(), 0)
 	assert.Equal(t, fan.GetMinPwm(), target)
 }
 
+func TestCalculateTargetSpeedSpinUpFromRest(t *testing.T) {
+	// GIVEN
+	curve := &MockCurve{
+		ID:    "curve",
+		Value: 0,
+	}
+	curves.SpeedCurveMap[curve.GetId()] = curve
+
+	fan := &MockFan{
+		ID:              "spin_up_fan",
+		PWM:             0,
+		RPM:             0,
+		MinPWM:          30,
+		StartPWM:        60,
+		curveId:         curve.GetId(),
+		shouldNeverStop: true,
+		speedCurve:      &LinearFan,
+	}
+	fans.FanMap[fan.GetId()] = fan
+
+	lastSetPwm := 0
+	controller := PidFanController{
+		persistence: mockPersistence{},
+		fan:         fan,
+		curve:       curve,
+		updateRate:  time.Duration(100),
+		pwmMap:      createOneToOnePwmMap(),
+		lastSetPwm:  &lastSetPwm,
+	}
+	controller.updateDistinctPwmValues()
+
+	// WHEN
+	restTarget, err := controller.calculateTargetPwm()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 60, restTarget)
+	assert.Equal(t, 0, controller.minPwmOffset)
+
+	// WHEN
+	fan.RPM = 500
+	spinningTarget, err := controller.calculateTargetPwm()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 30, spinningTarget)
+	assert.Equal(t, 0, controller.minPwmOffset)
+
+	// WHEN
+	fan.RPM = 0
+	stalledTarget, err := controller.calculateTargetPwm()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 60, stalledTarget)
+	assert.Equal(t, 1, controller.minPwmOffset)
+}
+
 func TestFanWithStartPwmConfig(t *testing.T) {
 	// GIVEN
 	startPwm := 50
@@ -463,11 +567,1508 @@ func TestFanController_UpdateFanSpeed_FanCurveGaps(t *testing.T) {
 	controller.updateDistinctPwmValues()
 
 	// WHEN
-	targetPwm := controller.calculateTargetPwm()
+	targetPwm, err := controller.calculateTargetPwm()
 
 	// THEN
+	assert.NoError(t, err)
 	assert.Equal(t, 54, targetPwm)
 
 	closestTarget := controller.mapToClosestDistinct(targetPwm)
 	assert.Equal(t, 50, closestTarget)
 }
+
+func TestCalculateTargetSpeedRpmTarget(t *testing.T) {
+	// GIVEN
+	curve := &MockCurve{
+		ID:    "curve",
+		Value: 127,
+	}
+	curves.SpeedCurveMap[curve.GetId()] = curve
+
+	// a fan which reaches half of its max RPM at ~20% PWM
+	fanCurveData := util.InterpolateLinearly(
+		&map[int]float64{
+			0:   0.0,
+			50:  1000.0,
+			255: 2000.0,
+		},
+		0, 255,
+	)
+	fan := &MockFan{
+		ID:              "fan",
+		PWM:             0,
+		shouldNeverStop: false,
+		curveId:         curve.GetId(),
+		speedCurve:      &fanCurveData,
+		config: configuration.FanConfig{
+			ID:        "fan",
+			Curve:     curve.GetId(),
+			RpmTarget: true,
+		},
+	}
+	fans.FanMap[fan.GetId()] = fan
+
+	controller := PidFanController{
+		persistence: mockPersistence{},
+		fan:         fan,
+		curve:       curve,
+		updateRate:  time.Duration(100),
+		pwmMap:      createOneToOnePwmMap(),
+	}
+	controller.updateDistinctPwmValues()
+
+	// WHEN
+	optimal, err := controller.calculateTargetPwm()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 50, optimal)
+}
+
+func TestCalculateTargetSpeedOverride(t *testing.T) {
+	// GIVEN
+	curve := &MockCurve{
+		ID:    "curve",
+		Value: 127,
+	}
+	curves.SpeedCurveMap[curve.GetId()] = curve
+
+	overrideCurve := &MockCurve{
+		ID:    "override_curve",
+		Value: 50,
+	}
+	curves.SpeedCurveMap[overrideCurve.GetId()] = overrideCurve
+
+	fan := &MockFan{
+		ID:              "fan",
+		PWM:             0,
+		shouldNeverStop: false,
+		curveId:         curve.GetId(),
+		speedCurve:      &LinearFan,
+	}
+	fans.FanMap[fan.GetId()] = fan
+
+	controller := PidFanController{
+		persistence: mockPersistence{},
+		fan:         fan,
+		curve:       curve,
+		updateRate:  time.Duration(100),
+		pwmMap:      createOneToOnePwmMap(),
+	}
+	controller.updateDistinctPwmValues()
+
+	// WHEN
+	fixedPwm := 255
+	controller.SetOverride(Override{
+		Pwm:     &fixedPwm,
+		Expires: time.Now().Add(time.Hour),
+	})
+	fixedTarget, _ := controller.calculateTargetPwm()
+
+	controller.SetOverride(Override{
+		CurveId: overrideCurve.GetId(),
+		Expires: time.Now().Add(time.Hour),
+	})
+	curveTarget, _ := controller.calculateTargetPwm()
+
+	controller.SetOverride(Override{
+		Pwm:     &fixedPwm,
+		Expires: time.Now().Add(-time.Second),
+	})
+	expiredTarget, _ := controller.calculateTargetPwm()
+
+	// THEN
+	assert.Equal(t, 255, fixedTarget)
+	assert.Equal(t, 50, curveTarget)
+	assert.Equal(t, 127, expiredTarget)
+	assert.Nil(t, controller.GetOverride())
+}
+
+func TestCalculateTargetSpeedFixedOverrideIsNotMapped(t *testing.T) {
+	// GIVEN
+	curve := &MockCurve{
+		ID:    "curve",
+		Value: 127,
+	}
+	curves.SpeedCurveMap[curve.GetId()] = curve
+
+	fan := &MockFan{
+		ID:              "fixed_override_fan",
+		PWM:             100,
+		RPM:             500,
+		MinPWM:          100,
+		shouldNeverStop: true,
+		curveId:         curve.GetId(),
+		speedCurve:      &LinearFan,
+	}
+	fans.FanMap[fan.GetId()] = fan
+
+	controller := PidFanController{
+		persistence: mockPersistence{},
+		fan:         fan,
+		curve:       curve,
+		updateRate:  time.Duration(100),
+		pwmMap:      createOneToOnePwmMap(),
+	}
+	controller.updateDistinctPwmValues()
+
+	tests := []int{0, 50, 128, 255}
+	for _, pwm := range tests {
+		// WHEN
+		fixedPwm := pwm
+		controller.SetOverride(Override{
+			Pwm:     &fixedPwm,
+			Expires: time.Now().Add(time.Hour),
+		})
+		target, err := controller.calculateTargetPwm()
+
+		// THEN
+		assert.NoError(t, err)
+		assert.Equal(t, pwm, target)
+	}
+}
+
+func TestUpdateFanSpeedPublishesTickEvent(t *testing.T) {
+	// GIVEN
+	sensor := &MockSensor{
+		ID:        "tick_sensor",
+		Name:      "tick_sensor",
+		MovingAvg: 50000,
+	}
+	sensors.SensorMap[sensor.GetId()] = sensor
+
+	curveConfig := configuration.CurveConfig{
+		ID: "tick_curve",
+		Linear: &configuration.LinearCurveConfig{
+			Sensor: sensor.GetId(),
+			Min:    40,
+			Max:    60,
+		},
+	}
+	curve, _ := curves.NewSpeedCurve(curveConfig)
+	curves.SpeedCurveMap[curve.GetId()] = curve
+
+	fan := &MockFan{
+		ID:              "tick_fan",
+		PWM:             0,
+		shouldNeverStop: false,
+		curveId:         curve.GetId(),
+		speedCurve:      &LinearFan,
+	}
+	fans.FanMap[fan.GetId()] = fan
+
+	controller := PidFanController{
+		persistence: mockPersistence{},
+		fan:         fan,
+		curve:       curve,
+		updateRate:  time.Duration(100),
+		pwmMap:      createOneToOnePwmMap(),
+		pidLoop:     util.NewPidLoop(0.03, 0.002, 0.0005),
+	}
+	controller.updateDistinctPwmValues()
+
+	events, unsubscribe := SubscribeTickEvents(1)
+	defer unsubscribe()
+
+	// WHEN
+	err := controller.UpdateFanSpeed()
+
+	// THEN
+	assert.NoError(t, err)
+	event := <-events
+	assert.Equal(t, fan.GetId(), event.FanId)
+	assert.Equal(t, curve.GetId(), event.CurveId)
+	assert.Equal(t, 127, event.CurveValue)
+	assert.Equal(t, 127, event.TargetPwm)
+	assert.Equal(t, fan.PWM, event.SetPwm)
+	assert.Equal(t, TickStateNormal, event.State)
+	assert.Empty(t, event.Reason)
+	assert.Equal(t, map[string]float64{sensor.GetId(): 50}, event.Sensors)
+	assert.False(t, event.Override)
+}
+
+func TestUpdateFanSpeedAdvancesPidLoopOncePerIteration(t *testing.T) {
+	// GIVEN
+	sensor := &MockSensor{
+		ID:        "pid_once_sensor",
+		Name:      "pid_once_sensor",
+		MovingAvg: 50000,
+	}
+	sensors.SensorMap[sensor.GetId()] = sensor
+
+	curve, _ := curves.NewSpeedCurve(configuration.CurveConfig{
+		ID: "pid_once_curve",
+		Linear: &configuration.LinearCurveConfig{
+			Sensor: sensor.GetId(),
+			Min:    40,
+			Max:    60,
+		},
+	})
+	curves.SpeedCurveMap[curve.GetId()] = curve
+
+	fan := &MockFan{
+		ID:              "pid_once_fan",
+		PWM:             0,
+		shouldNeverStop: false,
+		curveId:         curve.GetId(),
+		speedCurve:      &LinearFan,
+	}
+	fans.FanMap[fan.GetId()] = fan
+
+	// a pure proportional loop, whose output only depends on the current error
+	controller := PidFanController{
+		persistence: mockPersistence{},
+		fan:         fan,
+		curve:       curve,
+		updateRate:  time.Duration(100),
+		pwmMap:      createOneToOnePwmMap(),
+		pidLoop:     util.NewPidLoop(0.5, 0, 0),
+	}
+	controller.updateDistinctPwmValues()
+
+	// the first iteration only initializes the loop
+	err := controller.UpdateFanSpeed()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, fan.PWM)
+
+	// WHEN
+	err = controller.UpdateFanSpeed()
+
+	// THEN
+	assert.NoError(t, err)
+	// half of the error between target (127) and last set pwm (0)
+	assert.Equal(t, 64, fan.PWM)
+}
+
+func TestApplyHysteresis(t *testing.T) {
+	type step struct {
+		// the temperature of the curve sensor in °C
+		temp float64
+		// the time since the start of the test
+		elapsed time.Duration
+		// the target pwm value calculated from the curve
+		target int
+		// the expected target pwm value after applying the hysteresis
+		expected int
+	}
+
+	tests := []struct {
+		name                       string
+		hysteresis                 float64
+		minDirectionChangeInterval time.Duration
+		steps                      []step
+	}{
+		{
+			name: "disabled",
+			steps: []step{
+				{temp: 50, elapsed: 0, target: 100, expected: 100},
+				{temp: 50.2, elapsed: 200 * time.Millisecond, target: 102, expected: 102},
+				{temp: 50, elapsed: 400 * time.Millisecond, target: 100, expected: 100},
+			},
+		},
+		{
+			name:       "small sensor fluctuations are ignored",
+			hysteresis: 2,
+			steps: []step{
+				{temp: 50, elapsed: 0, target: 100, expected: 100},
+				{temp: 51, elapsed: time.Second, target: 110, expected: 100},
+				{temp: 49.5, elapsed: 2 * time.Second, target: 95, expected: 100},
+				{temp: 52, elapsed: 3 * time.Second, target: 120, expected: 120},
+				{temp: 51, elapsed: 4 * time.Second, target: 110, expected: 120},
+				{temp: 49.9, elapsed: 5 * time.Second, target: 99, expected: 99},
+			},
+		},
+		{
+			name:                       "direction changes are delayed",
+			minDirectionChangeInterval: 10 * time.Second,
+			steps: []step{
+				{temp: 50, elapsed: 0, target: 100, expected: 100},
+				{temp: 52, elapsed: time.Second, target: 120, expected: 120},
+				{temp: 53, elapsed: 2 * time.Second, target: 130, expected: 130},
+				{temp: 51, elapsed: 3 * time.Second, target: 110, expected: 130},
+				{temp: 51, elapsed: 11 * time.Second, target: 110, expected: 130},
+				{temp: 51, elapsed: 12 * time.Second, target: 110, expected: 110},
+				{temp: 50, elapsed: 13 * time.Second, target: 100, expected: 100},
+			},
+		},
+		{
+			name:                       "hysteresis and direction change delay combined",
+			hysteresis:                 1,
+			minDirectionChangeInterval: 5 * time.Second,
+			steps: []step{
+				{temp: 50, elapsed: 0, target: 100, expected: 100},
+				{temp: 51, elapsed: time.Second, target: 110, expected: 110},
+				{temp: 50.5, elapsed: 2 * time.Second, target: 105, expected: 110},
+				{temp: 49.5, elapsed: 3 * time.Second, target: 95, expected: 110},
+				{temp: 49.5, elapsed: 6 * time.Second, target: 95, expected: 95},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			sensor := &MockSensor{
+				ID:   "hysteresis_sensor",
+				Name: "hysteresis_sensor",
+			}
+			sensors.SensorMap[sensor.GetId()] = sensor
+
+			curve, _ := curves.NewSpeedCurve(configuration.CurveConfig{
+				ID: "hysteresis_curve",
+				Linear: &configuration.LinearCurveConfig{
+					Sensor: sensor.GetId(),
+					Min:    40,
+					Max:    80,
+				},
+			})
+			curves.SpeedCurveMap[curve.GetId()] = curve
+
+			fan := &MockFan{
+				ID:         "hysteresis_fan",
+				curveId:    curve.GetId(),
+				speedCurve: &LinearFan,
+				config: configuration.FanConfig{
+					ID:                         "hysteresis_fan",
+					Curve:                      curve.GetId(),
+					Hysteresis:                 tt.hysteresis,
+					MinDirectionChangeInterval: tt.minDirectionChangeInterval,
+				},
+			}
+			fans.FanMap[fan.GetId()] = fan
+
+			controller := PidFanController{
+				persistence: mockPersistence{},
+				fan:         fan,
+				curve:       curve,
+				lastCurveId: curve.GetId(),
+				updateRate:  time.Duration(100),
+				pwmMap:      createOneToOnePwmMap(),
+			}
+			controller.updateDistinctPwmValues()
+
+			start := time.Now()
+			for i, s := range tt.steps {
+				sensor.MovingAvg = s.temp * 1000
+
+				// WHEN
+				result := controller.applyHysteresis(s.target, start.Add(s.elapsed))
+
+				// THEN
+				assert.Equal(t, s.expected, result, "step: %d", i)
+			}
+		})
+	}
+}
+
+func TestApplyRampLimits(t *testing.T) {
+	type step struct {
+		// the time since the start of the test
+		elapsed time.Duration
+		// the pwm value calculated by the control loop
+		target int
+		// the expected pwm value after applying the ramp limits
+		expected int
+	}
+
+	tests := []struct {
+		name     string
+		rampUp   float64
+		rampDown float64
+		steps    []step
+	}{
+		{
+			name: "unlimited",
+			steps: []step{
+				{elapsed: 0, target: 100, expected: 100},
+				{elapsed: time.Second, target: 255, expected: 255},
+				{elapsed: 2 * time.Second, target: 0, expected: 0},
+			},
+		},
+		{
+			name:     "fast ramp up, slow ramp down",
+			rampUp:   100,
+			rampDown: 10,
+			steps: []step{
+				{elapsed: 0, target: 100, expected: 100},
+				{elapsed: time.Second, target: 255, expected: 200},
+				{elapsed: 2 * time.Second, target: 255, expected: 255},
+				{elapsed: 3 * time.Second, target: 100, expected: 245},
+				{elapsed: 5 * time.Second, target: 100, expected: 225},
+			},
+		},
+		{
+			name:     "rates slower than one step per tick",
+			rampDown: 2,
+			steps: []step{
+				{elapsed: 0, target: 100, expected: 100},
+				{elapsed: 200 * time.Millisecond, target: 50, expected: 100},
+				{elapsed: 400 * time.Millisecond, target: 50, expected: 99},
+				{elapsed: 600 * time.Millisecond, target: 50, expected: 99},
+				{elapsed: 800 * time.Millisecond, target: 50, expected: 98},
+				{elapsed: 1000 * time.Millisecond, target: 50, expected: 98},
+			},
+		},
+		{
+			name:   "ramp down is unlimited when only ramp up is set",
+			rampUp: 10,
+			steps: []step{
+				{elapsed: 0, target: 100, expected: 100},
+				{elapsed: time.Second, target: 0, expected: 0},
+				{elapsed: 2 * time.Second, target: 100, expected: 10},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			fan := &MockFan{
+				ID:         "ramp_fan",
+				speedCurve: &LinearFan,
+				config: configuration.FanConfig{
+					ID:       "ramp_fan",
+					RampUp:   tt.rampUp,
+					RampDown: tt.rampDown,
+				},
+			}
+
+			controller := PidFanController{
+				persistence: mockPersistence{},
+				fan:         fan,
+				updateRate:  time.Duration(100),
+				pwmMap:      createOneToOnePwmMap(),
+			}
+
+			start := time.Now()
+			lastSetPwm := 100
+			for i, s := range tt.steps {
+				// WHEN
+				result := controller.applyRampLimits(lastSetPwm, s.target, start.Add(s.elapsed))
+				lastSetPwm = result
+
+				// THEN
+				assert.Equal(t, s.expected, result, "step: %d", i)
+			}
+		})
+	}
+}
+
+func TestUpdateFanSpeedFailsafe(t *testing.T) {
+	// GIVEN
+	curve := &MockCurve{
+		ID:    "failsafe_curve",
+		Value: 0,
+	}
+	curves.SpeedCurveMap[curve.GetId()] = curve
+
+	fan := &MockFan{
+		ID:         "failsafe_fan",
+		PWM:        50,
+		curveId:    curve.GetId(),
+		speedCurve: &LinearFan,
+	}
+	fans.FanMap[fan.GetId()] = fan
+
+	controller := PidFanController{
+		persistence: mockPersistence{},
+		fan:         fan,
+		curve:       curve,
+		updateRate:  time.Duration(100),
+		pwmMap:      createOneToOnePwmMap(),
+		pidLoop:     util.NewPidLoop(0.03, 0.002, 0.0005),
+	}
+	controller.updateDistinctPwmValues()
+
+	SetFailsafeActive(true)
+	defer SetFailsafeActive(false)
+
+	events, unsubscribe := SubscribeTickEvents(1)
+	defer unsubscribe()
+
+	// WHEN
+	err := controller.UpdateFanSpeed()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, fans.MaxPwmValue, fan.PWM)
+	event := <-events
+	assert.Equal(t, TickStateFailsafe, event.State)
+	assert.NotEmpty(t, event.Reason)
+	assert.Equal(t, fans.MaxPwmValue, event.SetPwm)
+}
+
+func TestUpdateFanSpeedSensorFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		expected int
+	}{
+		{name: "default", policy: "", expected: fans.MaxPwmValue},
+		{name: "max speed", policy: configuration.SensorFailureMaxSpeed, expected: fans.MaxPwmValue},
+		{name: "hold last value", policy: configuration.SensorFailureHoldLast, expected: 50},
+		{name: "auto", policy: configuration.SensorFailureAuto, expected: 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			sensor := &MockSensor{
+				ID:        "failing_sensor",
+				Name:      "failing_sensor",
+				MovingAvg: 40000,
+			}
+			sensors.RegisterSensor(sensor)
+			for i := 0; i < sensors.DefaultMaxFailedReads; i++ {
+				sensors.RecordSensorError(sensor.GetId(), errors.New("read error"))
+			}
+
+			curve, _ := curves.NewSpeedCurve(configuration.CurveConfig{
+				ID: "failing_sensor_curve",
+				Linear: &configuration.LinearCurveConfig{
+					Sensor: sensor.GetId(),
+					Min:    40,
+					Max:    80,
+				},
+			})
+			curves.SpeedCurveMap[curve.GetId()] = curve
+
+			fan := &MockFan{
+				ID:         "sensor_failure_fan",
+				PWM:        50,
+				curveId:    curve.GetId(),
+				speedCurve: &LinearFan,
+				config: configuration.FanConfig{
+					ID:              "sensor_failure_fan",
+					Curve:           curve.GetId(),
+					OnSensorFailure: tt.policy,
+				},
+			}
+			fans.FanMap[fan.GetId()] = fan
+
+			controller := PidFanController{
+				persistence: mockPersistence{},
+				fan:         fan,
+				curve:       curve,
+				updateRate:  time.Duration(100),
+				pwmMap:      createOneToOnePwmMap(),
+				pidLoop:     util.NewPidLoop(0.03, 0.002, 0.0005),
+			}
+			controller.updateDistinctPwmValues()
+
+			events, unsubscribe := SubscribeTickEvents(1)
+			defer unsubscribe()
+
+			// WHEN
+			err := controller.UpdateFanSpeed()
+
+			// THEN
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, fan.PWM)
+			assert.True(t, controller.sensorFailure)
+			event := <-events
+			assert.Equal(t, TickStateSensorFailure, event.State)
+			assert.Contains(t, event.Reason, sensor.GetId())
+			assert.Equal(t, tt.expected, event.SetPwm)
+		})
+	}
+}
+
+func TestUpdateFanSpeedCurveFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		expected int
+	}{
+		{name: "default", policy: "", expected: fans.MaxPwmValue},
+		{name: "max speed", policy: configuration.SensorFailureMaxSpeed, expected: fans.MaxPwmValue},
+		{name: "hold last value", policy: configuration.SensorFailureHoldLast, expected: 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			curve := &MockCurve{
+				ID:  "failing_curve",
+				Err: errors.New("division by zero"),
+			}
+			curves.SpeedCurveMap[curve.GetId()] = curve
+
+			fan := &MockFan{
+				ID:         "curve_failure_fan",
+				PWM:        50,
+				curveId:    curve.GetId(),
+				speedCurve: &LinearFan,
+				config: configuration.FanConfig{
+					ID:              "curve_failure_fan",
+					Curve:           curve.GetId(),
+					OnSensorFailure: tt.policy,
+				},
+			}
+			fans.FanMap[fan.GetId()] = fan
+
+			controller := PidFanController{
+				persistence: mockPersistence{},
+				fan:         fan,
+				curve:       curve,
+				updateRate:  time.Duration(100),
+				pwmMap:      createOneToOnePwmMap(),
+				pidLoop:     util.NewPidLoop(0.03, 0.002, 0.0005),
+			}
+			controller.updateDistinctPwmValues()
+
+			// WHEN
+			err := controller.UpdateFanSpeed()
+			err2 := controller.UpdateFanSpeed()
+
+			// THEN
+			assert.NoError(t, err)
+			assert.NoError(t, err2)
+			assert.Equal(t, tt.expected, fan.PWM)
+			assert.True(t, controller.curveFailure)
+			assert.Equal(t, 1, controller.stats.CurveErrorCount)
+
+			// WHEN
+			curve.Err = nil
+			curve.Value = 0
+			err = controller.UpdateFanSpeed()
+
+			// THEN
+			assert.NoError(t, err)
+			assert.False(t, controller.curveFailure)
+		})
+	}
+}
+
+func TestDetectStall(t *testing.T) {
+	// GIVEN
+	fan := &MockFan{
+		ID:         "stall_fan",
+		PWM:        200,
+		RPM:        200,
+		speedCurve: &LinearFan,
+		config: configuration.FanConfig{
+			ID: "stall_fan",
+			StallDetection: &configuration.StallDetectionConfig{
+				Threshold: 0.5,
+				Duration:  10 * time.Second,
+				Action:    configuration.StallActionMaxSpeed,
+			},
+		},
+	}
+	lastSetPwm := 200
+	controller := PidFanController{
+		persistence: mockPersistence{},
+		fan:         fan,
+		updateRate:  time.Duration(100),
+		pwmMap:      createOneToOnePwmMap(),
+		expectedRpm: LinearFan,
+		lastSetPwm:  &lastSetPwm,
+	}
+	controller.updateDistinctPwmValues()
+	defer controller.setStalled(false)
+
+	start := time.Now()
+
+	// WHEN
+	controller.detectStall(start)
+	spinning := controller.GetStatistics().Stalled
+
+	fan.RPM = 50
+	controller.detectStall(start.Add(time.Second))
+	tooShort := controller.GetStatistics().Stalled
+
+	controller.detectStall(start.Add(11 * time.Second))
+	stalled := controller.GetStatistics().Stalled
+	_, otherFanAffected := getOtherStalledFan("other_fan", configuration.StallActionMaxSpeed)
+
+	fan.RPM = 190
+	controller.detectStall(start.Add(12 * time.Second))
+	recovered := controller.GetStatistics().Stalled
+	_, otherFanAffectedAfterRecovery := getOtherStalledFan("other_fan", configuration.StallActionMaxSpeed)
+
+	// THEN
+	assert.False(t, spinning)
+	assert.False(t, tooShort)
+	assert.True(t, stalled)
+	assert.True(t, otherFanAffected)
+	assert.False(t, recovered)
+	assert.False(t, otherFanAffectedAfterRecovery)
+	assert.Equal(t, 1, controller.GetStatistics().StallCount)
+}
+
+func TestFindMinPwm(t *testing.T) {
+	// GIVEN
+	pwmValues := []int{0, 10, 20, 30, 40, 50, 60, 255}
+	// the fan needs pwm 50 to start, but keeps spinning down to pwm 30
+	var measured []int
+	measureRpm := func(pwm int) (int, error) {
+		measured = append(measured, pwm)
+		if pwm < 30 {
+			return 0, nil
+		}
+		return pwm * 10, nil
+	}
+
+	// WHEN
+	result, err := findMinPwm(pwmValues, 50, measureRpm)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 30, result)
+	assert.Equal(t, []int{40, 30, 20}, measured)
+}
+
+func TestFindMinPwmStopsImmediately(t *testing.T) {
+	// GIVEN
+	pwmValues := []int{0, 10, 20, 30, 40, 50}
+
+	// WHEN
+	result, err := findMinPwm(pwmValues, 50, func(pwm int) (int, error) {
+		return 0, nil
+	})
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 50, result)
+}
+
+type countingPersistence struct {
+	mockPersistence
+	saved int
+}
+
+func (p *countingPersistence) SaveFanPwmData(fan fans.Fan) (err error) {
+	p.saved++
+	return nil
+}
+
+func TestRecalibratorSmoothsSettledSamples(t *testing.T) {
+	// GIVEN
+	curveData := map[int]float64{
+		100: 1000,
+	}
+	fan := &MockFan{
+		ID:         "fan",
+		speedCurve: &curveData,
+	}
+	p := &countingPersistence{}
+	start := time.Now()
+	config := configuration.RecalibrationConfig{
+		Smoothing:    0.5,
+		SettleTime:   10 * time.Second,
+		SaveInterval: 1 * time.Minute,
+	}
+	r := newRecalibrator(p, fan, config, start)
+
+	// WHEN
+	r.update(100, 2000, start)
+	r.update(100, 2000, start.Add(5*time.Second))
+
+	// THEN
+	// the fan has not settled yet
+	assert.Equal(t, 1000.0, curveData[100])
+
+	// WHEN
+	r.update(100, 2000, start.Add(10*time.Second))
+	r.update(100, 2000, start.Add(11*time.Second))
+
+	// THEN
+	assert.Equal(t, 1750.0, curveData[100])
+	assert.Equal(t, 0, p.saved)
+
+	// WHEN
+	r.update(150, 1500, start.Add(12*time.Second))
+	r.update(150, 1500, start.Add(30*time.Second))
+
+	// THEN
+	// samples of pwm values without stored data are used as they are
+	assert.Equal(t, 1500.0, curveData[150])
+	assert.Equal(t, 0, p.saved)
+}
+
+func TestRecalibratorSavesOnInterval(t *testing.T) {
+	// GIVEN
+	curveData := map[int]float64{
+		100: 1000,
+	}
+	fan := &MockFan{
+		ID:         "fan",
+		speedCurve: &curveData,
+	}
+	p := &countingPersistence{}
+	start := time.Now()
+	r := newRecalibrator(p, fan, configuration.RecalibrationConfig{}, start)
+
+	// WHEN
+	r.save(start)
+
+	// THEN
+	// nothing has changed yet
+	assert.Equal(t, 0, p.saved)
+
+	// WHEN
+	r.update(100, 1100, start)
+	r.update(100, 1100, start.Add(configuration.DefaultRecalibrationSettleTime))
+
+	// THEN
+	assert.InDelta(t, 1000+configuration.DefaultRecalibrationSmoothing*100, curveData[100], 0.000001)
+	assert.Equal(t, 0, p.saved)
+
+	// WHEN
+	r.update(100, 1100, start.Add(configuration.DefaultRecalibrationSaveInterval))
+
+	// THEN
+	assert.Equal(t, 1, p.saved)
+
+	// WHEN
+	r.save(start.Add(configuration.DefaultRecalibrationSaveInterval))
+
+	// THEN
+	// the data has already been saved
+	assert.Equal(t, 1, p.saved)
+}
+
+// initializationPersistence keeps the data stored during an initialization sequence in memory
+type initializationPersistence struct {
+	mockPersistence
+	pwmMap     map[int]int
+	checkpoint *persistence.FanInitializationCheckpoint
+	boundaries *persistence.FanPwmBoundaries
+	savedData  bool
+}
+
+func (p *initializationPersistence) LoadFanPwmMap(fanId string) (map[int]int, error) {
+	return p.pwmMap, nil
+}
+
+func (p *initializationPersistence) SaveFanPwmData(fan fans.Fan) (err error) {
+	p.savedData = true
+	return nil
+}
+
+func (p *initializationPersistence) SaveFanPwmBoundaries(fanId string, boundaries persistence.FanPwmBoundaries) (err error) {
+	p.boundaries = &boundaries
+	return nil
+}
+
+func (p *initializationPersistence) LoadFanInitializationCheckpoint(fanId string) (persistence.FanInitializationCheckpoint, error) {
+	if p.checkpoint == nil {
+		return persistence.FanInitializationCheckpoint{}, os.ErrNotExist
+	}
+	return *p.checkpoint, nil
+}
+
+func (p *initializationPersistence) SaveFanInitializationCheckpoint(fanId string, checkpoint persistence.FanInitializationCheckpoint) (err error) {
+	p.checkpoint = &checkpoint
+	return nil
+}
+
+func (p *initializationPersistence) DeleteFanInitializationCheckpoint(fanId string) (err error) {
+	p.checkpoint = nil
+	return nil
+}
+
+func TestRunInitializationSequenceResumesFromCheckpoint(t *testing.T) {
+	// GIVEN
+	curveData := map[int]float64{}
+	for pwm := fans.MinPwmValue; pwm <= fans.MaxPwmValue; pwm++ {
+		curveData[pwm] = float64(pwm * 10)
+	}
+	minPwm := 30
+	p := &initializationPersistence{
+		pwmMap: createOneToOnePwmMap(),
+		checkpoint: &persistence.FanInitializationCheckpoint{
+			CurveData: curveData,
+			MinPwm:    &minPwm,
+		},
+	}
+	fan := &MockFan{
+		ID:  "fan",
+		PWM: 100,
+		config: configuration.FanConfig{
+			ControlLoop: &configuration.ControlLoopConfig{P: 0.03, I: 0.002, D: 0.0005},
+		},
+	}
+	controller := PidFanController{
+		persistence: p,
+		fan:         fan,
+		pwmMap:      map[int]int{},
+	}
+
+	// WHEN
+	err := controller.RunInitializationSequence(context.Background())
+
+	// THEN
+	// all measurements are restored, so no pwm value has been set
+	assert.NoError(t, err)
+	assert.Equal(t, 100, fan.PWM)
+	assert.Equal(t, curveData, *fan.GetFanCurveData())
+	assert.True(t, p.savedData)
+	assert.Equal(t, persistence.FanPwmBoundaries{StartPwm: 0, MinPwm: 30}, *p.boundaries)
+	assert.Nil(t, p.checkpoint)
+	assert.Nil(t, controller.GetInitializationProgress())
+}
+
+func TestRunInitializationSequenceCanceled(t *testing.T) {
+	// GIVEN
+	p := &initializationPersistence{
+		pwmMap: createOneToOnePwmMap(),
+	}
+	fan := &MockFan{
+		ID:  "fan",
+		PWM: 100,
+	}
+	controller := PidFanController{
+		persistence: p,
+		fan:         fan,
+		pwmMap:      map[int]int{},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// WHEN
+	err := controller.RunInitializationSequence(ctx)
+
+	// THEN
+	assert.ErrorIs(t, err, context.Canceled)
+	// the original pwm value has been restored
+	assert.Equal(t, 100, fan.PWM)
+	assert.False(t, p.savedData)
+	assert.Nil(t, controller.GetInitializationProgress())
+}
+
+func TestProgressTracker(t *testing.T) {
+	// GIVEN
+	tracker := progressTracker{}
+	start := time.Now()
+
+	// THEN
+	assert.Nil(t, tracker.get(start))
+
+	// WHEN
+	tracker.start(start, 10*time.Second)
+	tracker.begin(InitializationPhaseFanCurve, 30*time.Second)
+
+	// THEN
+	progress := tracker.get(start)
+	assert.Equal(t, InitializationPhaseFanCurve, progress.Phase)
+	assert.InDelta(t, 25.0, progress.Percent, 0.000001)
+	assert.Equal(t, start.Add(30*time.Second), progress.Eta)
+
+	// WHEN
+	// the measurements take twice as long as expected
+	tracker.advance(10 * time.Second)
+
+	// THEN
+	progress = tracker.get(start.Add(20 * time.Second))
+	assert.InDelta(t, 50.0, progress.Percent, 0.000001)
+	assert.Equal(t, start.Add(60*time.Second), progress.Eta)
+
+	// WHEN
+	tracker.stop()
+
+	// THEN
+	assert.Nil(t, tracker.get(start))
+}
+
+// kneeFan doesn't spin below pwm 60 and spins linearly faster above it
+func measureKneeFan(measured *[]int) measureFunc {
+	return func(pwm int) (float64, bool, error) {
+		*measured = append(*measured, pwm)
+		if pwm < 60 {
+			return 0, true, nil
+		}
+		return float64(500 + (pwm-60)*10), true, nil
+	}
+}
+
+func createPwmValues(step int) []int {
+	var pwmValues []int
+	for pwm := 0; pwm <= 250; pwm += step {
+		pwmValues = append(pwmValues, pwm)
+	}
+	return pwmValues
+}
+
+func TestSweepFanCurveFull(t *testing.T) {
+	// GIVEN
+	pwmValues := createPwmValues(10)
+	var measured []int
+	curveData := map[int]float64{0: 0, 10: 0}
+	callbacks := 0
+
+	// WHEN
+	err := sweepFanCurve(configuration.SweepConfig{}, pwmValues, curveData, measureKneeFan(&measured), func() {
+		callbacks++
+	})
+
+	// THEN
+	// pwm values are measured, not their indices, and existing measurements are kept
+	assert.NoError(t, err)
+	assert.Equal(t, pwmValues[2:], measured)
+	assert.Equal(t, len(pwmValues)-2, callbacks)
+	assert.Len(t, curveData, len(pwmValues))
+	assert.Equal(t, 1400.0, curveData[150])
+}
+
+func TestSweepFanCurveCoarse(t *testing.T) {
+	// GIVEN
+	pwmValues := createPwmValues(10)
+	var measured []int
+	curveData := map[int]float64{}
+	config := configuration.SweepConfig{Strategy: configuration.SweepStrategyCoarse, Steps: 6}
+
+	// WHEN
+	err := sweepFanCurve(config, pwmValues, curveData, measureKneeFan(&measured), func() {})
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 50, 100, 150, 200, 250}, measured)
+
+	// WHEN
+	result := interpolateFanCurve(pwmValues, curveData)
+
+	// THEN
+	assert.Len(t, result, len(pwmValues))
+	// the fan is assumed to stand still until it is known to spin
+	assert.Equal(t, 0.0, result[90])
+	assert.Equal(t, 1400.0, result[150])
+	assert.Equal(t, 1800.0, result[190])
+}
+
+func TestSweepFanCurveAdaptive(t *testing.T) {
+	// GIVEN
+	pwmValues := createPwmValues(10)
+	var measured []int
+	curveData := map[int]float64{}
+	config := configuration.SweepConfig{Strategy: configuration.SweepStrategyAdaptive, Steps: 6, Tolerance: 10}
+
+	// WHEN
+	err := sweepFanCurve(config, pwmValues, curveData, measureKneeFan(&measured), func() {})
+
+	// THEN
+	// the middle of every segment is checked, but only the segment containing the knee is refined further
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 50, 100, 150, 200, 250, 20, 70, 120, 170, 220, 60, 80}, measured)
+
+	// WHEN
+	result := interpolateFanCurve(pwmValues, curveData)
+
+	// THEN
+	for _, pwm := range pwmValues {
+		expected, _, _ := measureKneeFan(&[]int{})(pwm)
+		assert.Equal(t, expected, result[pwm], "pwm %d", pwm)
+	}
+}
+
+func TestSweepFanCurveSkipsUnavailablePwmValues(t *testing.T) {
+	// GIVEN
+	pwmValues := createPwmValues(50)
+	curveData := map[int]float64{}
+
+	// WHEN
+	err := sweepFanCurve(configuration.SweepConfig{}, pwmValues, curveData, func(pwm int) (float64, bool, error) {
+		return float64(pwm), pwm != 100, nil
+	}, func() {})
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]float64{0: 0, 50: 50, 150: 150, 200: 200, 250: 250}, curveData)
+}
+
+func TestSelectEvenly(t *testing.T) {
+	// WHEN
+	result := selectEvenly(11, 5)
+
+	// THEN
+	assert.Equal(t, []int{0, 3, 5, 8, 10}, result)
+
+	// WHEN
+	result = selectEvenly(3, 8)
+
+	// THEN
+	assert.Equal(t, []int{0, 1, 2}, result)
+
+	// WHEN
+	result = selectEvenly(0, 5)
+
+	// THEN
+	assert.Equal(t, []int{}, result)
+}
+
+func TestFanGroupDecision(t *testing.T) {
+	// GIVEN
+	curve := &MockCurve{
+		ID:    "group_curve",
+		Value: 127,
+	}
+	curves.SpeedCurveMap[curve.GetId()] = curve
+
+	group := NewFanGroup(configuration.FanGroupConfig{
+		ID:   "group",
+		Fans: []string{"fan1", "fan2"},
+	}, curve)
+	evaluation := curves.NewEvaluation()
+
+	// WHEN
+	decision := group.decide(evaluation, time.Now())
+
+	// THEN
+	assert.Equal(t, curve.GetId(), decision.CurveId)
+	assert.Equal(t, 127, decision.CurveValue)
+	assert.Nil(t, decision.TargetRpm)
+	assert.Equal(t, &decision, group.GetLastDecision())
+	assert.Equal(t, map[string]int{curve.GetId(): 127}, evaluation.Values())
+}
+
+func TestFanGroupMembersMapDecisionIndividually(t *testing.T) {
+	// GIVEN
+	curve := &MockCurve{
+		ID:    "group_curve",
+		Value: 128,
+	}
+	curves.SpeedCurveMap[curve.GetId()] = curve
+	group := NewFanGroup(configuration.FanGroupConfig{
+		ID:   "group",
+		Fans: []string{"fan1", "fan2"},
+	}, curve)
+	decision := group.decide(curves.NewEvaluation(), time.Now())
+
+	createController := func(id string, minPwm int) *PidFanController {
+		fan := &MockFan{
+			ID:     id,
+			MinPWM: minPwm,
+			config: configuration.FanConfig{ID: id},
+		}
+		controller := &PidFanController{
+			persistence:   mockPersistence{},
+			fan:           fan,
+			updateRate:    time.Duration(100),
+			pwmMap:        createOneToOnePwmMap(),
+			group:         group,
+			groupDecision: &decision,
+		}
+		controller.updateDistinctPwmValues()
+		return controller
+	}
+	controller1 := createController("fan1", 0)
+	controller2 := createController("fan2", 100)
+
+	// WHEN
+	target1, err1 := controller1.calculateTargetPwm()
+	target2, err2 := controller2.calculateTargetPwm()
+
+	// THEN
+	assert.NoError(t, err1)
+	assert.NoError(t, err2)
+	assert.Equal(t, 128, target1)
+	assert.Equal(t, 177, target2)
+	assert.Equal(t, curve.GetId(), controller1.lastCurveId)
+	assert.Equal(t, curve.GetId(), controller2.lastCurveId)
+}
+
+func TestFanGroupSharedTargetRpm(t *testing.T) {
+	// GIVEN
+	curve := &MockCurve{
+		ID:    "group_curve",
+		Value: 255,
+	}
+	curves.SpeedCurveMap[curve.GetId()] = curve
+
+	createFan := func(id string, maxRpm float64) *MockFan {
+		curveData := util.InterpolateLinearly(
+			&map[int]float64{
+				0:   0.0,
+				255: maxRpm,
+			},
+			0, 255,
+		)
+		fan := &MockFan{
+			ID:         id,
+			speedCurve: &curveData,
+			config:     configuration.FanConfig{ID: id},
+		}
+		fans.FanMap[fan.GetId()] = fan
+		return fan
+	}
+	fastFan := createFan("fast_fan", 2000)
+	slowFan := createFan("slow_fan", 1000)
+	defer delete(fans.FanMap, fastFan.GetId())
+	defer delete(fans.FanMap, slowFan.GetId())
+
+	group := NewFanGroup(configuration.FanGroupConfig{
+		ID:        "group",
+		Fans:      []string{fastFan.GetId(), slowFan.GetId()},
+		TargetRpm: true,
+	}, curve)
+
+	// WHEN
+	decision := group.decide(curves.NewEvaluation(), time.Now())
+
+	// THEN
+	// the slowest fan determines the max RPM of the group
+	assert.NotNil(t, decision.TargetRpm)
+	assert.Equal(t, 1000.0, *decision.TargetRpm)
+
+	fastController := PidFanController{
+		persistence:   mockPersistence{},
+		fan:           fastFan,
+		updateRate:    time.Duration(100),
+		pwmMap:        createOneToOnePwmMap(),
+		group:         group,
+		groupDecision: &decision,
+	}
+	fastController.updateDistinctPwmValues()
+	slowController := PidFanController{
+		persistence:   mockPersistence{},
+		fan:           slowFan,
+		updateRate:    time.Duration(100),
+		pwmMap:        createOneToOnePwmMap(),
+		group:         group,
+		groupDecision: &decision,
+	}
+	slowController.updateDistinctPwmValues()
+
+	fastTarget, _ := fastController.calculateTargetPwm()
+	slowTarget, _ := slowController.calculateTargetPwm()
+	assert.Equal(t, 128, fastTarget)
+	assert.Equal(t, 255, slowTarget)
+}
+
+func TestFanGroupMemberOverride(t *testing.T) {
+	// GIVEN
+	curve := &MockCurve{
+		ID:    "group_curve",
+		Value: 200,
+	}
+	curves.SpeedCurveMap[curve.GetId()] = curve
+	group := NewFanGroup(configuration.FanGroupConfig{
+		ID:   "group",
+		Fans: []string{"fan"},
+	}, curve)
+	decision := group.decide(curves.NewEvaluation(), time.Now())
+
+	fan := &MockFan{
+		ID:     "fan",
+		config: configuration.FanConfig{ID: "fan"},
+	}
+	controller := PidFanController{
+		persistence:   mockPersistence{},
+		fan:           fan,
+		updateRate:    time.Duration(100),
+		pwmMap:        createOneToOnePwmMap(),
+		group:         group,
+		groupDecision: &decision,
+	}
+	controller.updateDistinctPwmValues()
+
+	pwm := 50
+	controller.SetOverride(Override{
+		Pwm:     &pwm,
+		Expires: time.Now().Add(time.Hour),
+	})
+
+	// WHEN
+	value, err := controller.evaluateCurve()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 50, value)
+	assert.Equal(t, curve.GetId(), controller.getCurve().GetId())
+}
+
+func TestSchedulerTick(t *testing.T) {
+	// GIVEN
+	configuration.CurrentConfig.TempRollingWindowSize = 10
+	configuration.CurrentConfig.RpmRollingWindowSize = 10
+
+	sensor := &MockSensor{
+		ID:        "scheduler_sensor",
+		Name:      "scheduler_sensor",
+		MovingAvg: 50000,
+	}
+	sensors.RegisterSensor(sensor)
+	defer sensors.RemoveSensor(sensor.GetId())
+
+	curveConfig := configuration.CurveConfig{
+		ID: "scheduler_curve",
+		Linear: &configuration.LinearCurveConfig{
+			Sensor: sensor.GetId(),
+			Min:    40,
+			Max:    60,
+		},
+	}
+	curve, _ := curves.NewSpeedCurve(curveConfig)
+	curves.RegisterSpeedCurve(curve)
+	defer curves.RemoveSpeedCurve(curve.GetId())
+
+	group := NewFanGroup(configuration.FanGroupConfig{
+		ID:   "scheduler_group",
+		Fans: []string{"scheduler_fan"},
+	}, curve)
+	RegisterFanGroup(group)
+	defer RemoveFanGroup(group.GetId())
+
+	fan := &MockFan{
+		ID:         "scheduler_fan",
+		RPM:        1000,
+		curveId:    curve.GetId(),
+		speedCurve: &LinearFan,
+		config:     configuration.FanConfig{ID: "scheduler_fan"},
+	}
+	controller := &PidFanController{
+		persistence: mockPersistence{},
+		fan:         fan,
+		curve:       curve,
+		updateRate:  time.Duration(100),
+		pwmMap:      createOneToOnePwmMap(),
+		pidLoop:     util.NewPidLoop(0.03, 0.002, 0.0005),
+		group:       group,
+	}
+	controller.updateDistinctPwmValues()
+	controller.start()
+	RegisterFanController(controller)
+	defer RemoveFanController(fan.GetId())
+
+	scheduler := NewScheduler(time.Second, time.Second, time.Second)
+
+	// WHEN
+	now := time.Now()
+	scheduler.tick(now)
+
+	// THEN
+	snapshot := GetSnapshot()
+	assert.Equal(t, now, snapshot.Time)
+
+	assert.Equal(t, 50000.0, snapshot.Sensors[sensor.GetId()].Value)
+	assert.Equal(t, sensors.HealthStateOk, snapshot.Sensors[sensor.GetId()].Health.State)
+	assert.Equal(t, 127, snapshot.Curves[curve.GetId()])
+
+	decision := snapshot.Groups[group.GetId()]
+	assert.Equal(t, curve.GetId(), decision.CurveId)
+	assert.Equal(t, 127, decision.CurveValue)
+
+	fanState, exists := snapshot.Fans[fan.GetId()]
+	assert.True(t, exists)
+	assert.Equal(t, fan.PWM, fanState.Pwm)
+	assert.Equal(t, 1000, *fanState.Rpm)
+	assert.Equal(t, curve.GetId(), controller.lastCurveId)
+	assert.Equal(t, 127, controller.lastCurveValue)
+}
+
+func TestSchedulerSkipsStoppedControllers(t *testing.T) {
+	// GIVEN
+	fan := &MockFan{
+		ID:         "scheduler_stopped_fan",
+		PWM:        42,
+		speedCurve: &LinearFan,
+		config:     configuration.FanConfig{ID: "scheduler_stopped_fan"},
+	}
+	controller := &PidFanController{
+		persistence: mockPersistence{},
+		fan:         fan,
+		updateRate:  time.Duration(100),
+		pwmMap:      createOneToOnePwmMap(),
+		pidLoop:     util.NewPidLoop(0.03, 0.002, 0.0005),
+	}
+	controller.updateDistinctPwmValues()
+	RegisterFanController(controller)
+	defer RemoveFanController(fan.GetId())
+
+	scheduler := NewScheduler(time.Second, time.Second, time.Second)
+
+	// WHEN
+	scheduler.tick(time.Now())
+
+	// THEN
+	_, exists := GetSnapshot().Fans[fan.GetId()]
+	assert.False(t, exists)
+	assert.Equal(t, 42, fan.PWM)
+}
+
+func TestSchedulerIsolatesSlowFans(t *testing.T) {
+	// GIVEN
+	configuration.CurrentConfig.TempRollingWindowSize = 10
+
+	sensor := &MockSensor{
+		ID:        "scheduler_slow_sensor",
+		Name:      "scheduler_slow_sensor",
+		MovingAvg: 50000,
+	}
+	sensors.RegisterSensor(sensor)
+	defer sensors.RemoveSensor(sensor.GetId())
+
+	curve, _ := curves.NewSpeedCurve(configuration.CurveConfig{
+		ID: "scheduler_slow_curve",
+		Linear: &configuration.LinearCurveConfig{
+			Sensor: sensor.GetId(),
+			Min:    40,
+			Max:    60,
+		},
+	})
+	curves.RegisterSpeedCurve(curve)
+	defer curves.RemoveSpeedCurve(curve.GetId())
+
+	blocker := make(chan struct{})
+	slowFan := &MockFan{
+		ID:            "scheduler_slow_fan",
+		curveId:       curve.GetId(),
+		speedCurve:    &LinearFan,
+		config:        configuration.FanConfig{ID: "scheduler_slow_fan"},
+		getPwmBlocker: blocker,
+	}
+	fastFan := &MockFan{
+		ID:         "scheduler_fast_fan",
+		curveId:    curve.GetId(),
+		speedCurve: &LinearFan,
+		config:     configuration.FanConfig{ID: "scheduler_fast_fan"},
+	}
+	for _, fan := range []*MockFan{slowFan, fastFan} {
+		controller := &PidFanController{
+			persistence: mockPersistence{},
+			fan:         fan,
+			curve:       curve,
+			updateRate:  time.Duration(100),
+			pwmMap:      createOneToOnePwmMap(),
+			pidLoop:     util.NewPidLoop(0.03, 0.002, 0.0005),
+		}
+		controller.updateDistinctPwmValues()
+		controller.start()
+		RegisterFanController(controller)
+		defer RemoveFanController(fan.GetId())
+	}
+
+	// the ids are read from the fields directly, since the slow fan is still in use by its update
+	scheduler := NewScheduler(50*time.Millisecond, time.Second, time.Second)
+
+	// WHEN
+	scheduler.tick(time.Now())
+
+	// THEN
+	snapshot := GetSnapshot()
+	_, exists := snapshot.Fans[fastFan.ID]
+	assert.True(t, exists)
+	_, exists = snapshot.Fans[slowFan.ID]
+	assert.False(t, exists)
+
+	// WHEN
+	scheduler.tick(time.Now())
+
+	// THEN
+	snapshot = GetSnapshot()
+	_, exists = snapshot.Fans[fastFan.ID]
+	assert.True(t, exists)
+
+	// WHEN
+	close(blocker)
+	<-scheduler.fanUpdates[slowFan.ID].done
+	scheduler.tick(time.Now())
+
+	// THEN
+	snapshot = GetSnapshot()
+	_, exists = snapshot.Fans[fastFan.ID]
+	assert.True(t, exists)
+	_, exists = snapshot.Fans[slowFan.ID]
+	assert.True(t, exists)
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"github.com/markusressel/fan2go/internal/curves"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/markusressel/fan2go/internal/util"
+	"time"
+)
+
+const (
+	// TickStateNormal means the fan was controlled using its curve (or override)
+	TickStateNormal = "normal"
+	// TickStateFailsafe means the fan was set to max speed, because the critical temperature was reached
+	TickStateFailsafe = "failsafe"
+	// TickStateStalledFan means the fan was set to max speed, because another fan is stalled
+	TickStateStalledFan = "stalledFan"
+	// TickStateSensorFailure means the onSensorFailure policy was applied, because a sensor of the curve is stale
+	TickStateSensorFailure = "sensorFailure"
+	// TickStateCurveFailure means the onSensorFailure policy was applied, because the curve couldn't be evaluated
+	TickStateCurveFailure = "curveFailure"
+)
+
+// TickEvent describes the outcome of a single iteration of the control loop of a fan
+type TickEvent struct {
+	FanId string    `json:"fan"`
+	Time  time.Time `json:"time"`
+	// State describes how the fan was controlled in this iteration, one of the TickState constants
+	State string `json:"state"`
+	// Reason explains why the curve was bypassed, empty in the normal state
+	Reason string `json:"reason,omitempty"`
+	// CurveId is the id of the curve used last, empty if a fixed override value was used
+	CurveId string `json:"curve"`
+	// CurveValue is the value [0..255] of the curve (or override) used last
+	CurveValue int `json:"curveValue"`
+	// Sensors contains the moving average (in °C) of all sensors the curve depends on
+	Sensors map[string]float64 `json:"sensors"`
+	// TargetPwm is the optimal pwm value calculated from the curve value,
+	// or the pwm value applied by the policy which bypassed the curve
+	TargetPwm int `json:"targetPwm"`
+	// SetPwm is the pwm value that was actually applied to the fan
+	SetPwm int `json:"setPwm"`
+	// Rpm is the moving average of the fan speed
+	Rpm float64 `json:"rpm"`
+	// Override indicates whether an override was active in this iteration
+	Override bool `json:"override"`
+}
+
+var tickEvents = util.NewBroker[TickEvent]()
+
+// SubscribeTickEvents returns a channel which receives a TickEvent for every iteration of every fan controller,
+// as well as a function to end the subscription. Events are dropped if the channel buffer is full.
+func SubscribeTickEvents(bufferSize int) (<-chan TickEvent, func()) {
+	return tickEvents.Subscribe(bufferSize)
+}
+
+// publishPolicyTickEvent publishes a tick event for an iteration in which the curve was bypassed,
+// using the pwm value the policy has left the fan at
+func (f *PidFanController) publishPolicyTickEvent(state string, reason string) {
+	if !tickEvents.HasSubscribers() {
+		return
+	}
+
+	setPwm := 0
+	if f.lastSetPwm != nil {
+		setPwm = f.mapToClosestDistinct(*f.lastSetPwm)
+	} else if pwm, err := f.fan.GetPwm(); err == nil {
+		setPwm = pwm
+	}
+	f.publishTickEvent(state, reason, setPwm, setPwm)
+}
+
+func (f *PidFanController) publishTickEvent(state string, reason string, target int, setPwm int) {
+	if !tickEvents.HasSubscribers() {
+		return
+	}
+
+	sensorValues := map[string]float64{}
+	if curve, exists := curves.GetSpeedCurve(f.lastCurveId); exists {
+		for _, sensorId := range curves.GetSensorIds(curve) {
+			if sensor, exists := sensors.GetSensor(sensorId); exists {
+				sensorValues[sensorId] = sensor.GetMovingAvg() / 1000
+			}
+		}
+	}
+
+	tickEvents.Publish(TickEvent{
+		FanId:      f.fan.GetId(),
+		Time:       time.Now(),
+		State:      state,
+		Reason:     reason,
+		CurveId:    f.lastCurveId,
+		CurveValue: f.lastCurveValue,
+		Sensors:    sensorValues,
+		TargetPwm:  target,
+		SetPwm:     setPwm,
+		Rpm:        f.fan.GetRpmAvg(),
+		Override:   f.GetOverride() != nil,
+	})
+}
@@ -0,0 +1,178 @@
+package controller
+
+import (
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/curves"
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/util"
+	"math"
+	"sync"
+	"time"
+)
+
+var (
+	FanGroupMap      = map[string]*FanGroup{}
+	fanGroupMapMutex sync.RWMutex
+)
+
+// GroupDecision is the control decision of a fan group, which is shared by all of its member fans
+type GroupDecision struct {
+	// Time is the point in time at which the decision was made
+	Time time.Time `json:"time"`
+	// CurveId is the id of the curve that was evaluated
+	CurveId string `json:"curve"`
+	// CurveValue is the value [0..255] of the curve
+	CurveValue int `json:"curveValue"`
+	// TargetRpm is the RPM all member fans are driven at, if the group uses a shared target RPM
+	TargetRpm *float64 `json:"targetRpm,omitempty"`
+	// Err is the error that occurred while evaluating the curve, if any
+	Err error `json:"-"`
+}
+
+// FanGroup makes a decision for a group of fans in every iteration of the scheduler, which is applied by
+// the controllers of its member fans, which map it to the pwm values of their fan individually
+type FanGroup struct {
+	config configuration.FanGroupConfig
+	// the curve used to control all member fans
+	curve curves.SpeedCurve
+	// guards access to curve, which can be replaced while the group is running
+	curveMutex sync.RWMutex
+
+	// the decision that was made last, nil if there was none yet
+	lastDecision *GroupDecision
+	// guards access to lastDecision
+	decisionMutex sync.RWMutex
+}
+
+func NewFanGroup(config configuration.FanGroupConfig, curve curves.SpeedCurve) *FanGroup {
+	return &FanGroup{
+		config: config,
+		curve:  curve,
+	}
+}
+
+func (g *FanGroup) GetId() string {
+	return g.config.ID
+}
+
+func (g *FanGroup) GetConfig() configuration.FanGroupConfig {
+	return g.config
+}
+
+// GetFanIds returns the ids of all member fans
+func (g *FanGroup) GetFanIds() []string {
+	return g.config.Fans
+}
+
+// SetCurve replaces the curve used to control all member fans
+func (g *FanGroup) SetCurve(curve curves.SpeedCurve) {
+	g.curveMutex.Lock()
+	defer g.curveMutex.Unlock()
+	g.curve = curve
+}
+
+func (g *FanGroup) getCurve() curves.SpeedCurve {
+	g.curveMutex.RLock()
+	defer g.curveMutex.RUnlock()
+	return g.curve
+}
+
+// GetLastDecision returns the decision that was made last, or nil if there was none yet
+func (g *FanGroup) GetLastDecision() *GroupDecision {
+	g.decisionMutex.RLock()
+	defer g.decisionMutex.RUnlock()
+	return g.lastDecision
+}
+
+// decide evaluates the curve of the group using the given evaluation, the result is applied by
+// the controllers of all member fans in the same iteration of the scheduler
+func (g *FanGroup) decide(evaluation *curves.Evaluation, now time.Time) GroupDecision {
+	curve := g.getCurve()
+	value, err := evaluation.Evaluate(curve)
+	decision := GroupDecision{
+		Time:       now,
+		CurveId:    curve.GetId(),
+		CurveValue: value,
+		Err:        err,
+	}
+
+	if g.config.TargetRpm && err == nil {
+		if maxRpm := g.getMaxRpm(); maxRpm > 0 {
+			// interpret the curve value as a percentage of the max RPM of the slowest fan,
+			// which is the highest RPM all member fans are able to reach
+			targetRpm := (util.Coerce(float64(value), fans.MinPwmValue, fans.MaxPwmValue) / fans.MaxPwmValue) * maxRpm
+			decision.TargetRpm = &targetRpm
+		}
+	}
+
+	g.decisionMutex.Lock()
+	g.lastDecision = &decision
+	g.decisionMutex.Unlock()
+
+	return decision
+}
+
+// getMaxRpm returns the lowest max RPM of all member fans whose fan curve data is known
+func (g *FanGroup) getMaxRpm() float64 {
+	result := math.Inf(1)
+	for _, fanId := range g.GetFanIds() {
+		fan, exists := fans.GetFan(fanId)
+		if !exists {
+			continue
+		}
+		if maxRpm := fans.GetMaxRpm(fan); maxRpm > 0 && maxRpm < result {
+			result = maxRpm
+		}
+	}
+	if math.IsInf(result, 1) {
+		return 0
+	}
+	return result
+}
+
+// GetFanGroup returns the running fan group with the given id
+func GetFanGroup(groupId string) (*FanGroup, bool) {
+	fanGroupMapMutex.RLock()
+	defer fanGroupMapMutex.RUnlock()
+	g, exists := FanGroupMap[groupId]
+	return g, exists
+}
+
+// GetFanGroupOfFan returns the running fan group the fan with the given id is a member of, if any
+func GetFanGroupOfFan(fanId string) (*FanGroup, bool) {
+	fanGroupMapMutex.RLock()
+	defer fanGroupMapMutex.RUnlock()
+	for _, g := range FanGroupMap {
+		for _, member := range g.GetFanIds() {
+			if member == fanId {
+				return g, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// RegisterFanGroup adds the given group to the FanGroupMap, replacing any existing group with the same id
+func RegisterFanGroup(g *FanGroup) {
+	fanGroupMapMutex.Lock()
+	defer fanGroupMapMutex.Unlock()
+	FanGroupMap[g.GetId()] = g
+}
+
+// RemoveFanGroup removes the group with the given id from the FanGroupMap
+func RemoveFanGroup(groupId string) {
+	fanGroupMapMutex.Lock()
+	defer fanGroupMapMutex.Unlock()
+	delete(FanGroupMap, groupId)
+}
+
+// SnapshotFanGroupMap returns a copy of the FanGroupMap
+func SnapshotFanGroupMap() map[string]*FanGroup {
+	fanGroupMapMutex.RLock()
+	defer fanGroupMapMutex.RUnlock()
+	result := make(map[string]*FanGroup, len(FanGroupMap))
+	for id, g := range FanGroupMap {
+		result[id] = g
+	}
+	return result
+}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// InitializationPhaseFanCurve measures the RPM of the fan for each distinct pwm value
+	InitializationPhaseFanCurve = "fanCurve"
+	// InitializationPhaseMinPwm measures the lowest pwm at which a spinning fan keeps rotating
+	InitializationPhaseMinPwm = "minPwm"
+	// InitializationPhaseStepResponse measures how fast the fan reacts to a sudden speed change
+	InitializationPhaseStepResponse = "stepResponse"
+)
+
+// InitializationProgress describes the state of a running initialization sequence
+type InitializationProgress struct {
+	// Phase is the measurement that is currently running
+	Phase string `json:"phase"`
+	// Percent is the share [0..100] of the initialization sequence that is done
+	Percent float64 `json:"percent"`
+	// Started is the point in time at which the initialization sequence was started
+	Started time.Time `json:"started"`
+	// Eta is the estimated point in time at which the initialization sequence is done
+	Eta time.Time `json:"eta"`
+}
+
+// progressTracker estimates the progress of an initialization sequence, based on the expected
+// duration of the measurements that are done and remaining
+type progressTracker struct {
+	mutex  sync.Mutex
+	active bool
+
+	started time.Time
+	phase   string
+	// expected duration of the measurements restored from a checkpoint
+	resumed time.Duration
+	// expected duration of the measurements done since started
+	done time.Duration
+	// expected duration of the remaining measurements
+	remaining time.Duration
+}
+
+// start resets the tracker, resumed is the expected duration of the measurements restored from a checkpoint
+func (t *progressTracker) start(now time.Time, resumed time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.active = true
+	t.started = now
+	t.phase = ""
+	t.resumed = resumed
+	t.done = 0
+	t.remaining = 0
+}
+
+// stop marks the initialization sequence as finished
+func (t *progressTracker) stop() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.active = false
+}
+
+// begin starts the given phase, remaining is the expected duration of all measurements left,
+// including those of the following phases
+func (t *progressTracker) begin(phase string, remaining time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.phase = phase
+	t.remaining = remaining
+}
+
+// advance marks a measurement with the given expected duration as done
+func (t *progressTracker) advance(expected time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.done += expected
+	t.remaining -= expected
+	if t.remaining < 0 {
+		t.remaining = 0
+	}
+}
+
+// get returns the current progress, or nil if no initialization sequence is running
+func (t *progressTracker) get(now time.Time) *InitializationProgress {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if !t.active {
+		return nil
+	}
+
+	percent := 0.0
+	total := t.resumed + t.done + t.remaining
+	if total > 0 {
+		percent = float64(t.resumed+t.done) / float64(total) * 100
+	}
+
+	// measurements usually take longer than expected, so the actual rate is used once known
+	remaining := t.remaining
+	if elapsed := now.Sub(t.started); t.done > 0 && elapsed > 0 {
+		remaining = time.Duration(float64(t.remaining) * float64(elapsed) / float64(t.done))
+	}
+
+	return &InitializationProgress{
+		Phase:   t.phase,
+		Percent: percent,
+		Started: t.started,
+		Eta:     now.Add(remaining),
+	}
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/persistence"
+	"github.com/markusressel/fan2go/internal/ui"
+	"time"
+)
+
+// recalibrator gradually updates the fan curve data of a fan with RPM samples taken while it is controlled,
+// so changes due to aging or dust are reflected over time
+type recalibrator struct {
+	persistence persistence.Persistence
+	fan         fans.Fan
+
+	smoothing    float64
+	settleTime   time.Duration
+	saveInterval time.Duration
+
+	// the pwm value of the last sample and the time since when it is unchanged
+	pwm      int
+	pwmSince time.Time
+	// the time the fan curve data was saved last
+	lastSave time.Time
+	// indicates whether the fan curve data has changed since it was saved last
+	changed bool
+}
+
+func newRecalibrator(p persistence.Persistence, fan fans.Fan, config configuration.RecalibrationConfig, now time.Time) *recalibrator {
+	smoothing := config.Smoothing
+	if smoothing <= 0 {
+		smoothing = configuration.DefaultRecalibrationSmoothing
+	}
+	settleTime := config.SettleTime
+	if settleTime <= 0 {
+		settleTime = configuration.DefaultRecalibrationSettleTime
+	}
+	saveInterval := config.SaveInterval
+	if saveInterval <= 0 {
+		saveInterval = configuration.DefaultRecalibrationSaveInterval
+	}
+
+	return &recalibrator{
+		persistence:  p,
+		fan:          fan,
+		smoothing:    smoothing,
+		settleTime:   settleTime,
+		saveInterval: saveInterval,
+		pwm:          -1,
+		lastSave:     now,
+	}
+}
+
+// update smooths the given RPM sample into the fan curve data, once the pwm value has been unchanged
+// long enough for the fan to settle. The fan curve data is saved if the save interval has passed.
+func (r *recalibrator) update(pwm int, rpm int, now time.Time) {
+	if pwm != r.pwm {
+		r.pwm = pwm
+		r.pwmSince = now
+		return
+	}
+	if now.Sub(r.pwmSince) < r.settleTime {
+		return
+	}
+
+	curveData := r.fan.GetFanCurveData()
+	if curveData == nil {
+		return
+	}
+	if stored, exists := (*curveData)[pwm]; exists {
+		(*curveData)[pwm] = stored + r.smoothing*(float64(rpm)-stored)
+	} else {
+		(*curveData)[pwm] = float64(rpm)
+	}
+	r.changed = true
+
+	if now.Sub(r.lastSave) >= r.saveInterval {
+		r.save(now)
+	}
+}
+
+// save writes the fan curve data to persistence, if it has changed since it was saved last
+func (r *recalibrator) save(now time.Time) {
+	if !r.changed {
+		return
+	}
+	ui.Debug("Saving recalibrated fan curve data of fan %s", r.fan.GetId())
+	if err := r.persistence.SaveFanPwmData(r.fan); err != nil {
+		ui.Warning("Failed to save recalibrated fan curve data of fan %s: %v", r.fan.GetId(), err)
+		return
+	}
+	r.changed = false
+	r.lastSave = now
+}
@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"context"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/curves"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/markusressel/fan2go/internal/ui"
+	"github.com/markusressel/fan2go/internal/util"
+	"time"
+)
+
+// Scheduler runs the control loop of all sensors, curves, fan groups and fan controllers.
+// In every iteration it reads the sensors, evaluates each curve exactly once, updates all fan controllers
+// and publishes a Snapshot of the result. Each fan controller is updated in its own goroutine,
+// so a fan that is slow to respond (f.ex. a hanging cmd fan) can't delay any other fan or the Snapshot.
+type Scheduler struct {
+	// rate of the iterations, which is the rate at which fan speeds are adjusted
+	tickRate time.Duration
+	// rate at which sensors are read
+	sensorPollingRate time.Duration
+	// rate at which the RPM of fans is measured
+	rpmPollingRate time.Duration
+
+	lastSensorRead time.Time
+	lastRpmRead    time.Time
+	// the value of the last successful read, by sensor id
+	sensorValues map[string]float64
+	// the update that was started last, by fan id
+	fanUpdates map[string]*fanUpdate
+	// the state of each fan after its last finished update, by fan id
+	fanStates map[string]FanState
+}
+
+// fanUpdate is a single update of a fan controller, running in its own goroutine
+type fanUpdate struct {
+	// closed once the update has finished
+	done chan struct{}
+	// the state of the fan after the update, only valid if ok is true
+	state FanState
+	ok    bool
+}
+
+// finished returns true if the update has finished
+func (u *fanUpdate) finished() bool {
+	select {
+	case <-u.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func NewScheduler(tickRate time.Duration, sensorPollingRate time.Duration, rpmPollingRate time.Duration) *Scheduler {
+	return &Scheduler{
+		tickRate:          tickRate,
+		sensorPollingRate: sensorPollingRate,
+		rpmPollingRate:    rpmPollingRate,
+		sensorValues:      map[string]float64{},
+		fanUpdates:        map[string]*fanUpdate{},
+		fanStates:         map[string]FanState{},
+	}
+}
+
+// Run starts the control loop, until the given context is cancelled
+func (s *Scheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.tickRate)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			ui.Info("Stopping scheduler...")
+			return nil
+		case now := <-ticker.C:
+			s.tick(now)
+		}
+	}
+}
+
+// tick runs a single iteration of the control loop
+func (s *Scheduler) tick(now time.Time) {
+	sensorMap := sensors.SnapshotSensorMap()
+	controllers := SnapshotFanControllerMap()
+
+	if now.Sub(s.lastSensorRead) >= s.sensorPollingRate {
+		s.lastSensorRead = now
+		for id, sensor := range sensorMap {
+			value, err := updateSensor(sensor, now)
+			if err != nil {
+				ui.Warning("Error updating sensor: %v", err)
+				continue
+			}
+			s.sensorValues[id] = value
+		}
+	}
+
+	readRpm := now.Sub(s.lastRpmRead) >= s.rpmPollingRate
+	if readRpm {
+		s.lastRpmRead = now
+	}
+
+	evaluation := curves.NewEvaluation()
+	for _, curve := range curves.SnapshotSpeedCurveMap() {
+		if _, err := evaluation.Evaluate(curve); err != nil {
+			ui.Debug("Error evaluating curve %s: %v", curve.GetId(), err)
+		}
+	}
+
+	groups := SnapshotFanGroupMap()
+	snapshot := &Snapshot{
+		Time:    now,
+		Sensors: make(map[string]SensorState, len(sensorMap)),
+		Fans:    make(map[string]FanState, len(controllers)),
+		Groups:  make(map[string]GroupDecision, len(groups)),
+	}
+
+	for id, g := range groups {
+		snapshot.Groups[id] = g.decide(evaluation, now)
+	}
+
+	s.updateFans(controllers, evaluation, readRpm, now)
+	for id, state := range s.fanStates {
+		snapshot.Fans[id] = state
+	}
+
+	snapshot.Curves = evaluation.Values()
+	for id := range s.sensorValues {
+		if _, exists := sensorMap[id]; !exists {
+			delete(s.sensorValues, id)
+		}
+	}
+	for id, sensor := range sensorMap {
+		snapshot.Sensors[id] = SensorState{
+			Config:    sensor.GetConfig(),
+			Value:     s.sensorValues[id],
+			MovingAvg: sensor.GetMovingAvg(),
+			Health:    sensors.GetHealth(sensor),
+		}
+	}
+
+	publishSnapshot(snapshot)
+}
+
+// updateFans updates all fan controllers concurrently and waits for them to finish, for at most one tick.
+// A fan whose previous update is still running is skipped, its last known state is kept in the snapshot.
+func (s *Scheduler) updateFans(controllers map[string]FanController, evaluation *curves.Evaluation, readRpm bool, now time.Time) {
+	started := map[string]*fanUpdate{}
+	for id, c := range controllers {
+		pidController, ok := c.(*PidFanController)
+		if !ok {
+			continue
+		}
+		if previous, exists := s.fanUpdates[id]; exists && !previous.finished() {
+			ui.Debug("Fan %s: previous update is still running, skipping", id)
+			continue
+		}
+
+		update := &fanUpdate{done: make(chan struct{})}
+		s.fanUpdates[id] = update
+		started[id] = update
+		go func() {
+			defer close(update.done)
+			if readRpm {
+				pidController.updateRpm(now)
+			}
+			pidController.update(evaluation)
+			update.state, update.ok = pidController.getState()
+		}()
+	}
+
+	timeout := time.NewTimer(s.tickRate)
+	defer timeout.Stop()
+	timedOut := false
+	for id, update := range started {
+		if !timedOut {
+			select {
+			case <-update.done:
+			case <-timeout.C:
+				timedOut = true
+			}
+		}
+		if !update.finished() {
+			ui.Warning("Fan %s: update did not finish within %v, keeping its last known state", id, s.tickRate)
+			continue
+		}
+		if update.ok {
+			s.fanStates[id] = update.state
+		} else {
+			delete(s.fanStates, id)
+		}
+	}
+
+	for id := range s.fanUpdates {
+		if _, exists := controllers[id]; !exists {
+			delete(s.fanUpdates, id)
+			delete(s.fanStates, id)
+		}
+	}
+}
+
+// updateSensor reads the current value of a sensor and appends it to the moving window
+func updateSensor(s sensors.Sensor, now time.Time) (value float64, err error) {
+	value, err = s.GetValue()
+	if err != nil {
+		sensors.RecordSensorError(s.GetId(), err)
+		return value, err
+	}
+	sensors.RecordSensorRead(s.GetId(), value, now)
+	sensors.RecordSensorHistory(s.GetId(), value, now)
+
+	var n = configuration.CurrentConfig.TempRollingWindowSize
+	lastAvg := s.GetMovingAvg()
+	newAvg := util.UpdateSimpleMovingAvg(lastAvg, n, value)
+	s.SetMovingAvg(newAvg)
+
+	return value, nil
+}
@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"sync"
+	"time"
+)
+
+var (
+	currentSnapshot      *Snapshot
+	currentSnapshotMutex sync.RWMutex
+)
+
+// Snapshot is the state of all sensors, curves, fans and fan groups at the end of an iteration of the scheduler.
+// It is never modified after it has been published, so it can be read from any goroutine.
+type Snapshot struct {
+	// Time is the point in time at which the iteration ended
+	Time time.Time `json:"time"`
+	// Sensors contains the state of all sensors, by sensor id
+	Sensors map[string]SensorState `json:"sensors"`
+	// Curves contains the value of all curves that could be evaluated, by curve id
+	Curves map[string]int `json:"curves"`
+	// Fans contains the state of all fans whose controller is running, by fan id
+	Fans map[string]FanState `json:"fans"`
+	// Groups contains the decision of all fan groups, by group id
+	Groups map[string]GroupDecision `json:"groups"`
+}
+
+type SensorState struct {
+	Config configuration.SensorConfig `json:"config"`
+	// Value is the value of the last successful read
+	Value float64 `json:"value"`
+	// MovingAvg is the moving average of the sensor values, which is used by the curves
+	MovingAvg float64 `json:"movingAvg"`
+	// Health is the health state of the sensor
+	Health sensors.Health `json:"health"`
+}
+
+type FanState struct {
+	Config configuration.FanConfig `json:"config"`
+	// Pwm is the pwm value that was set last
+	Pwm int `json:"pwm"`
+	// Rpm is the RPM of the last measurement, nil if the fan has no RPM sensor
+	Rpm *int `json:"rpm"`
+	// RpmMovingAvg is the moving average of the RPM measurements
+	RpmMovingAvg float64 `json:"rpmMovingAvg"`
+	MinPwm       int     `json:"minPwm"`
+	StartPwm     int     `json:"startPwm"`
+	MaxPwm       int     `json:"maxPwm"`
+	// FanCurveData is a copy of the measured RPM by pwm value
+	FanCurveData map[int]float64 `json:"fanCurveData"`
+	// Statistics are the statistics of the fan controller
+	Statistics FanControllerStatistics `json:"statistics"`
+}
+
+// GetSnapshot returns the snapshot that was published last, or an empty snapshot if there is none yet
+func GetSnapshot() *Snapshot {
+	currentSnapshotMutex.RLock()
+	defer currentSnapshotMutex.RUnlock()
+	if currentSnapshot == nil {
+		return &Snapshot{
+			Sensors: map[string]SensorState{},
+			Curves:  map[string]int{},
+			Fans:    map[string]FanState{},
+			Groups:  map[string]GroupDecision{},
+		}
+	}
+	return currentSnapshot
+}
+
+func publishSnapshot(snapshot *Snapshot) {
+	currentSnapshotMutex.Lock()
+	defer currentSnapshotMutex.Unlock()
+	currentSnapshot = snapshot
+}
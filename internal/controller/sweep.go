@@ -0,0 +1,190 @@
+package controller
+
+import (
+	"github.com/markusressel/fan2go/internal/configuration"
+	"math"
+	"sort"
+)
+
+// measureFunc sets the given pwm value and returns the RPM of the fan after it has settled.
+// ok is false if the pwm value could not be applied, so no RPM has been measured.
+type measureFunc func(pwm int) (rpm float64, ok bool, err error)
+
+// sweepFanCurve measures the RPM of a fan at the pwm values selected by the strategy of the given config.
+// pwmValues are the sorted distinct pwm values of the fan. measured contains the RPM of pwm values which
+// have already been measured (f.ex. restored from a checkpoint), these are not measured again.
+// Every new measurement is added to measured, before onMeasured is called.
+func sweepFanCurve(
+	config configuration.SweepConfig,
+	pwmValues []int,
+	measured map[int]float64,
+	measure measureFunc,
+	onMeasured func(),
+) error {
+	// returns the RPM of the pwm value at the given index, ok is false if it couldn't be measured
+	get := func(idx int) (float64, bool, error) {
+		pwm := pwmValues[idx]
+		if rpm, exists := measured[pwm]; exists {
+			return rpm, true, nil
+		}
+		rpm, ok, err := measure(pwm)
+		if err != nil || !ok {
+			return 0, false, err
+		}
+		measured[pwm] = rpm
+		onMeasured()
+		return rpm, true, nil
+	}
+
+	var indices []int
+	switch config.Strategy {
+	case configuration.SweepStrategyCoarse, configuration.SweepStrategyAdaptive:
+		indices = selectEvenly(len(pwmValues), getSweepSteps(config))
+	default:
+		indices = selectEvenly(len(pwmValues), len(pwmValues))
+	}
+
+	// indices of the pwm values which could be measured
+	var available []int
+	for _, idx := range indices {
+		_, ok, err := get(idx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			available = append(available, idx)
+		}
+	}
+
+	if config.Strategy != configuration.SweepStrategyAdaptive {
+		return nil
+	}
+
+	tolerance := config.Tolerance
+	if tolerance <= 0 {
+		tolerance = configuration.DefaultSweepTolerance
+	}
+
+	// refine all segments between two measured pwm values, whose middle doesn't match the linear
+	// interpolation of its ends, until there are no more pwm values in between
+	type segment struct{ low, high int }
+	var segments []segment
+	for i := 0; i+1 < len(available); i++ {
+		segments = append(segments, segment{available[i], available[i+1]})
+	}
+	for len(segments) > 0 {
+		var next []segment
+		for _, s := range segments {
+			if s.high-s.low < 2 {
+				continue
+			}
+			mid := (s.low + s.high) / 2
+			rpm, ok, err := get(mid)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			lowPwm, highPwm := pwmValues[s.low], pwmValues[s.high]
+			lowRpm, highRpm := measured[lowPwm], measured[highPwm]
+			expected := lowRpm + (highRpm-lowRpm)*float64(pwmValues[mid]-lowPwm)/float64(highPwm-lowPwm)
+			if math.Abs(rpm-expected) > tolerance {
+				next = append(next, segment{s.low, mid}, segment{mid, s.high})
+			}
+		}
+		segments = next
+	}
+	return nil
+}
+
+// getSweepSteps returns the number of pwm values the coarse and adaptive strategies start with
+func getSweepSteps(config configuration.SweepConfig) int {
+	if config.Steps <= 0 {
+		return configuration.DefaultSweepSteps
+	}
+	return config.Steps
+}
+
+// estimateSweepMeasurements returns the expected number of measurements needed to complete the sweep
+// of the given pwm values, of which the given number has already been measured
+func estimateSweepMeasurements(config configuration.SweepConfig, pwmValues int, measured int) int {
+	total := pwmValues
+	switch config.Strategy {
+	case configuration.SweepStrategyCoarse:
+		total = getSweepSteps(config)
+	case configuration.SweepStrategyAdaptive:
+		// assume every initial segment is refined once
+		total = 2*getSweepSteps(config) - 1
+	}
+	if total > pwmValues {
+		total = pwmValues
+	}
+	if measured >= total {
+		return 0
+	}
+	return total - measured
+}
+
+// selectEvenly returns count evenly spaced indices of a slice with the given length,
+// always including the first and the last one
+func selectEvenly(length int, count int) []int {
+	if count >= length {
+		count = length
+	}
+	if count <= 0 {
+		return []int{}
+	}
+	if count == 1 {
+		return []int{0}
+	}
+	result := make([]int, 0, count)
+	for i := 0; i < count; i++ {
+		idx := int(math.Round(float64(i) * float64(length-1) / float64(count-1)))
+		if len(result) > 0 && result[len(result)-1] == idx {
+			continue
+		}
+		result = append(result, idx)
+	}
+	return result
+}
+
+// interpolateFanCurve returns the fan curve data for all given pwm values, by linearly interpolating
+// between the measured ones. Values between a stopped and a spinning fan are assumed to be 0,
+// since it is unknown at which pwm value in between the fan starts to rotate.
+func interpolateFanCurve(pwmValues []int, measured map[int]float64) map[int]float64 {
+	result := make(map[int]float64, len(pwmValues))
+	if len(measured) <= 0 {
+		return result
+	}
+
+	measuredPwmValues := make([]int, 0, len(measured))
+	for pwm, rpm := range measured {
+		measuredPwmValues = append(measuredPwmValues, pwm)
+		result[pwm] = rpm
+	}
+	sort.Ints(measuredPwmValues)
+
+	for _, pwm := range pwmValues {
+		if _, exists := measured[pwm]; exists {
+			continue
+		}
+		upperIdx := sort.SearchInts(measuredPwmValues, pwm)
+		if upperIdx <= 0 {
+			result[pwm] = measured[measuredPwmValues[0]]
+			continue
+		}
+		if upperIdx >= len(measuredPwmValues) {
+			result[pwm] = measured[measuredPwmValues[len(measuredPwmValues)-1]]
+			continue
+		}
+		lowPwm, highPwm := measuredPwmValues[upperIdx-1], measuredPwmValues[upperIdx]
+		lowRpm, highRpm := measured[lowPwm], measured[highPwm]
+		if lowRpm <= 0 {
+			result[pwm] = 0
+			continue
+		}
+		result[pwm] = lowRpm + (highRpm-lowRpm)*float64(pwm-lowPwm)/float64(highPwm-lowPwm)
+	}
+	return result
+}
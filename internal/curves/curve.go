@@ -3,18 +3,25 @@ package curves
 import (
 	"fmt"
 	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/expression"
+	"github.com/markusressel/fan2go/internal/sensors"
 	"github.com/markusressel/fan2go/internal/util"
+	"sync"
 )
 
 type SpeedCurve interface {
 	GetId() string
+
+	GetConfig() configuration.CurveConfig
+
 	// Evaluate calculates the current value of the given curve,
 	// returns a value in [0..255]
 	Evaluate() (value int, err error)
 }
 
 var (
-	SpeedCurveMap = map[string]SpeedCurve{}
+	SpeedCurveMap      = map[string]SpeedCurve{}
+	speedCurveMapMutex sync.RWMutex
 )
 
 func NewSpeedCurve(config configuration.CurveConfig) (SpeedCurve, error) {
@@ -30,6 +37,11 @@ func NewSpeedCurve(config configuration.CurveConfig) (SpeedCurve, error) {
 			config.PID.I,
 			config.PID.D,
 		)
+		// the loop works on (0..1), which is mapped to the curve value range
+		outputMin, outputMax := config.PID.GetOutputRange()
+		pidLoop.SetOutputLimits(float64(outputMin)/255, float64(outputMax)/255)
+		pidLoop.SetDerivativeFilter(config.PID.DerivativeFilter)
+		pidLoop.SetReverse(config.PID.Reverse)
 		return &PidSpeedCurve{
 			Config:  config,
 			pidLoop: pidLoop,
@@ -42,5 +54,96 @@ func NewSpeedCurve(config configuration.CurveConfig) (SpeedCurve, error) {
 		}, nil
 	}
 
+	if config.Derivative != nil {
+		return &DerivativeSpeedCurve{
+			Config: config,
+		}, nil
+	}
+
+	if config.Expression != nil {
+		parsed, err := expression.Parse(config.Expression.Formula)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression of curve %s: %v", config.ID, err)
+		}
+		return &ExpressionSpeedCurve{
+			Config:     config,
+			expression: parsed,
+		}, nil
+	}
+
 	return nil, fmt.Errorf("no matching curve type for curve: %s", config.ID)
 }
+
+// GetSpeedCurve returns the curve with the given id
+func GetSpeedCurve(id string) (SpeedCurve, bool) {
+	speedCurveMapMutex.RLock()
+	defer speedCurveMapMutex.RUnlock()
+	curve, exists := SpeedCurveMap[id]
+	return curve, exists
+}
+
+// RegisterSpeedCurve adds the given curve to the SpeedCurveMap, replacing any existing curve with the same id
+func RegisterSpeedCurve(curve SpeedCurve) {
+	speedCurveMapMutex.Lock()
+	defer speedCurveMapMutex.Unlock()
+	SpeedCurveMap[curve.GetId()] = curve
+}
+
+// RemoveSpeedCurve removes the curve with the given id from the SpeedCurveMap
+func RemoveSpeedCurve(id string) {
+	speedCurveMapMutex.Lock()
+	defer speedCurveMapMutex.Unlock()
+	delete(SpeedCurveMap, id)
+}
+
+// SnapshotSpeedCurveMap returns a copy of the SpeedCurveMap
+func SnapshotSpeedCurveMap() map[string]SpeedCurve {
+	speedCurveMapMutex.RLock()
+	defer speedCurveMapMutex.RUnlock()
+	result := make(map[string]SpeedCurve, len(SpeedCurveMap))
+	for id, curve := range SpeedCurveMap {
+		result[id] = curve
+	}
+	return result
+}
+
+// GetSensorIds returns the ids of all sensors the given curve depends on,
+// including those used by the curves of a function or expression curve
+func GetSensorIds(curve SpeedCurve) []string {
+	var result []string
+	collectSensorIds(curve.GetConfig(), map[string]bool{}, &result)
+	return result
+}
+
+func collectSensorIds(config configuration.CurveConfig, visited map[string]bool, result *[]string) {
+	if visited[config.ID] {
+		return
+	}
+	visited[config.ID] = true
+
+	if config.Linear != nil {
+		*result = append(*result, config.Linear.Sensor)
+	}
+	if config.PID != nil {
+		*result = append(*result, config.PID.Sensor)
+	}
+	if config.Derivative != nil {
+		*result = append(*result, config.Derivative.Sensor)
+	}
+	if config.Function != nil {
+		for _, curveId := range config.Function.Curves {
+			if curve, exists := GetSpeedCurve(curveId); exists {
+				collectSensorIds(curve.GetConfig(), visited, result)
+			}
+		}
+	}
+	if config.Expression != nil {
+		for _, id := range config.Expression.GetReferencedIds() {
+			if _, exists := sensors.GetSensor(id); exists {
+				*result = append(*result, id)
+			} else if curve, exists := GetSpeedCurve(id); exists {
+				collectSensorIds(curve.GetConfig(), visited, result)
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package curves
+
+import (
+	"fmt"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/sensors"
+)
+
+// DerivativeSpeedCurve maps the rate of change of a sensor value to a speed,
+// which allows reacting to load spikes before the temperature itself gets too high
+type DerivativeSpeedCurve struct {
+	Config configuration.CurveConfig `json:"config"`
+	Value  int                       `json:"value"`
+}
+
+func (c *DerivativeSpeedCurve) GetId() string {
+	return c.Config.ID
+}
+
+func (c *DerivativeSpeedCurve) GetConfig() configuration.CurveConfig {
+	return c.Config
+}
+
+func (c *DerivativeSpeedCurve) Evaluate() (value int, err error) {
+	config := c.Config.Derivative
+	if _, exists := sensors.GetSensor(config.Sensor); !exists {
+		return 0, fmt.Errorf("curve %s: no sensor with id '%s' found", c.GetId(), config.Sensor)
+	}
+
+	window := config.Window
+	if window <= 0 {
+		window = configuration.DefaultDerivativeWindow
+	}
+
+	rateOfChange, ok := sensors.GetRateOfChange(config.Sensor, window)
+	if !ok || rateOfChange <= config.Min {
+		// not enough sensor history yet, or no relevant increase
+		value = 0
+	} else if rateOfChange >= config.Max {
+		value = 255
+	} else {
+		ratio := (rateOfChange - config.Min) / (config.Max - config.Min)
+		value = int(ratio * 255)
+	}
+
+	c.Value = value
+	return value, nil
+}
@@ -0,0 +1,85 @@
+package curves
+
+import (
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// helper function to create a derivative curve configuration
+func createDerivativeCurveConfig(
+	id string,
+	sensorId string,
+	min float64,
+	max float64,
+) (curve configuration.CurveConfig) {
+	curve = configuration.CurveConfig{
+		ID: id,
+		Derivative: &configuration.DerivativeCurveConfig{
+			Sensor: sensorId,
+			Window: 10 * time.Second,
+			Min:    min,
+			Max:    max,
+		},
+	}
+	return curve
+}
+
+func TestDerivativeCurve(t *testing.T) {
+	tests := []struct {
+		name string
+		// rate of change of the sensor in °C/s
+		rateOfChange float64
+		expected     int
+	}{
+		{name: "falling", rateOfChange: -1, expected: 0},
+		{name: "below min", rateOfChange: 0.1, expected: 0},
+		{name: "between min and max", rateOfChange: 0.5, expected: 95},
+		{name: "above max", rateOfChange: 2, expected: 255},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			s := MockSensor{
+				ID:   "derivative_sensor_" + tt.name,
+				Name: "sensor",
+			}
+			sensors.SensorMap[s.GetId()] = &s
+			now := time.Now()
+			for i := 10; i >= 0; i-- {
+				value := 50000 - float64(i)*tt.rateOfChange*1000
+				sensors.RecordSensorHistory(s.GetId(), value, now.Add(-time.Duration(i)*time.Second))
+			}
+
+			curve, _ := NewSpeedCurve(createDerivativeCurveConfig("derivative_curve", s.GetId(), 0.2, 1))
+
+			// WHEN
+			result, err := curve.Evaluate()
+
+			// THEN
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, tt.expected, curve.(*DerivativeSpeedCurve).Value)
+		})
+	}
+}
+
+func TestDerivativeCurveWithoutHistory(t *testing.T) {
+	// GIVEN
+	s := MockSensor{
+		ID:   "derivative_sensor_without_history",
+		Name: "sensor",
+	}
+	sensors.SensorMap[s.GetId()] = &s
+	curve, _ := NewSpeedCurve(createDerivativeCurveConfig("derivative_curve", s.GetId(), 0.2, 1))
+
+	// WHEN
+	result, err := curve.Evaluate()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result)
+}
@@ -0,0 +1,64 @@
+package curves
+
+import "sync"
+
+// Evaluation evaluates each curve at most once, so all fans and curves which use the same curve share its value,
+// and stateful curves (f.ex. pid) are only advanced once. An Evaluation is meant to be used for a single
+// iteration of the control loop and may be shared by all fan controllers running in it.
+type Evaluation struct {
+	mutex   sync.Mutex
+	results map[string]evaluationResult
+}
+
+type evaluationResult struct {
+	value int
+	err   error
+}
+
+// compositeCurve is a curve whose value depends on other curves,
+// which are evaluated using the given evaluation
+type compositeCurve interface {
+	evaluate(evaluation *Evaluation) (value int, err error)
+}
+
+func NewEvaluation() *Evaluation {
+	return &Evaluation{
+		results: map[string]evaluationResult{},
+	}
+}
+
+// Evaluate returns the value of the given curve, which is only calculated on the first call
+func (e *Evaluation) Evaluate(curve SpeedCurve) (value int, err error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.evaluate(curve)
+}
+
+// evaluate is the implementation of Evaluate, which expects the mutex to be held.
+// Composite curves use it to evaluate the curves they depend on.
+func (e *Evaluation) evaluate(curve SpeedCurve) (value int, err error) {
+	if result, exists := e.results[curve.GetId()]; exists {
+		return result.value, result.err
+	}
+
+	if composite, ok := curve.(compositeCurve); ok {
+		value, err = composite.evaluate(e)
+	} else {
+		value, err = curve.Evaluate()
+	}
+	e.results[curve.GetId()] = evaluationResult{value: value, err: err}
+	return value, err
+}
+
+// Values returns the values of all curves that have been evaluated successfully
+func (e *Evaluation) Values() map[string]int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	result := make(map[string]int, len(e.results))
+	for id, r := range e.results {
+		if r.err == nil {
+			result[id] = r.value
+		}
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+package curves
+
+import (
+	"fmt"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/expression"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/markusressel/fan2go/internal/util"
+	"math"
+)
+
+// ExpressionSpeedCurve calculates its value using a formula, which can reference sensors (in °C) and other curves
+type ExpressionSpeedCurve struct {
+	Config configuration.CurveConfig `json:"config"`
+	Value  int                       `json:"value"`
+
+	expression *expression.Expression
+}
+
+func (c *ExpressionSpeedCurve) GetId() string {
+	return c.Config.ID
+}
+
+func (c *ExpressionSpeedCurve) GetConfig() configuration.CurveConfig {
+	return c.Config
+}
+
+func (c *ExpressionSpeedCurve) Evaluate() (value int, err error) {
+	return c.evaluate(NewEvaluation())
+}
+
+func (c *ExpressionSpeedCurve) evaluate(evaluation *Evaluation) (value int, err error) {
+	variables := map[string]float64{}
+	for _, id := range c.expression.Variables() {
+		if sensor, exists := sensors.GetSensor(id); exists {
+			variables[id] = sensor.GetMovingAvg() / 1000
+		} else if curve, exists := GetSpeedCurve(id); exists {
+			curveValue, err := evaluation.evaluate(curve)
+			if err != nil {
+				return 0, err
+			}
+			variables[id] = float64(curveValue)
+		} else {
+			return 0, fmt.Errorf("curve %s: no sensor or curve with id '%s' found", c.GetId(), id)
+		}
+	}
+
+	result, err := c.expression.Evaluate(variables)
+	if err != nil {
+		return 0, fmt.Errorf("curve %s: %v", c.GetId(), err)
+	}
+
+	value = int(math.Round(util.Coerce(result, 0, 255)))
+	c.Value = value
+	return value, nil
+}
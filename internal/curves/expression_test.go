@@ -0,0 +1,120 @@
+package curves
+
+import (
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// helper function to create an expression curve configuration
+func createExpressionCurveConfig(
+	id string,
+	formula string,
+) (curve configuration.CurveConfig) {
+	curve = configuration.CurveConfig{
+		ID: id,
+		Expression: &configuration.ExpressionCurveConfig{
+			Formula: formula,
+		},
+	}
+	return curve
+}
+
+func TestExpressionCurve(t *testing.T) {
+	// GIVEN
+	cpu := MockSensor{
+		ID:        "expression_cpu",
+		Name:      "cpu",
+		MovingAvg: 60000,
+	}
+	sensors.SensorMap[cpu.GetId()] = &cpu
+	gpu := MockSensor{
+		ID:        "expression_gpu",
+		Name:      "gpu",
+		MovingAvg: 75000,
+	}
+	sensors.SensorMap[gpu.GetId()] = &gpu
+
+	linearCurve, _ := NewSpeedCurve(createLinearCurveConfig("expression_linear", gpu.GetId(), 40, 80))
+	SpeedCurveMap[linearCurve.GetId()] = linearCurve
+
+	tests := []struct {
+		formula  string
+		expected int
+	}{
+		{formula: "max(expression_cpu, expression_gpu - 10) * 1.2", expected: 78},
+		{formula: "expression_linear / 2", expected: 112},
+		{formula: "expression_cpu > 70 ? 255 : expression_linear", expected: 223},
+		// results are limited to [0..255]
+		{formula: "expression_gpu * 10", expected: 255},
+		{formula: "-expression_gpu", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.formula, func(t *testing.T) {
+			curve, err := NewSpeedCurve(createExpressionCurveConfig("expression_curve", tt.formula))
+			assert.NoError(t, err)
+
+			// WHEN
+			result, err := curve.Evaluate()
+
+			// THEN
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestExpressionCurveStoresValue(t *testing.T) {
+	// GIVEN
+	sensor := MockSensor{
+		ID:        "expression_value_sensor",
+		Name:      "sensor",
+		MovingAvg: 60000,
+	}
+	sensors.SensorMap[sensor.GetId()] = &sensor
+	curve, err := NewSpeedCurve(createExpressionCurveConfig("expression_value_curve", "expression_value_sensor * 2"))
+	assert.NoError(t, err)
+
+	// WHEN
+	result, err := curve.Evaluate()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 120, result)
+	assert.Equal(t, 120, curve.(*ExpressionSpeedCurve).Value)
+
+	// WHEN
+	sensor.MovingAvg = 50000
+	result, err = NewEvaluation().Evaluate(curve)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 100, result)
+	assert.Equal(t, 100, curve.(*ExpressionSpeedCurve).Value)
+}
+
+func TestExpressionCurveInvalidFormula(t *testing.T) {
+	// WHEN
+	_, err := NewSpeedCurve(createExpressionCurveConfig("invalid_expression_curve", "max("))
+
+	// THEN
+	assert.EqualError(t, err, "invalid expression of curve invalid_expression_curve: unexpected 'end of expression' at position 4")
+}
+
+func TestGetSensorIdsOfExpressionCurve(t *testing.T) {
+	// GIVEN
+	s := MockSensor{ID: "expression_sensor_ids_sensor1"}
+	sensors.SensorMap[s.GetId()] = &s
+	linearCurve, _ := NewSpeedCurve(createLinearCurveConfig("expression_sensor_ids_linear", "expression_sensor_ids_sensor2", 40, 80))
+	SpeedCurveMap[linearCurve.GetId()] = linearCurve
+
+	curve, _ := NewSpeedCurve(createExpressionCurveConfig("expression_sensor_ids_curve", "expression_sensor_ids_sensor1 + expression_sensor_ids_linear"))
+
+	// WHEN
+	result := GetSensorIds(curve)
+
+	// THEN
+	assert.Equal(t, []string{"expression_sensor_ids_sensor2", "expression_sensor_ids_sensor1"}, result)
+}
@@ -1,9 +1,11 @@
 package curves
 
 import (
+	"fmt"
 	"github.com/markusressel/fan2go/internal/configuration"
 	"github.com/markusressel/fan2go/internal/ui"
 	"math"
+	"sort"
 )
 
 type FunctionSpeedCurve struct {
@@ -15,15 +17,27 @@ func (c FunctionSpeedCurve) GetId() string {
 	return c.Config.ID
 }
 
+func (c FunctionSpeedCurve) GetConfig() configuration.CurveConfig {
+	return c.Config
+}
+
 func (c FunctionSpeedCurve) Evaluate() (value int, err error) {
+	return c.evaluate(NewEvaluation())
+}
+
+func (c FunctionSpeedCurve) evaluate(evaluation *Evaluation) (value int, err error) {
 	var curves []SpeedCurve
 	for _, curveId := range c.Config.Function.Curves {
-		curves = append(curves, SpeedCurveMap[curveId])
+		curve, exists := GetSpeedCurve(curveId)
+		if !exists {
+			return 0, fmt.Errorf("curve %s: no curve with id '%s' found", c.GetId(), curveId)
+		}
+		curves = append(curves, curve)
 	}
 
 	var values []int
 	for _, curve := range curves {
-		v, err := curve.Evaluate()
+		v, err := evaluation.evaluate(curve)
 		if err != nil {
 			return 0, err
 		}
@@ -59,6 +73,32 @@ func (c FunctionSpeedCurve) Evaluate() (value int, err error) {
 		}
 		avg := total / len(curves)
 		value = avg
+	case configuration.FunctionWeighted:
+		weights := c.Config.Function.Weights
+		if len(weights) != len(values) {
+			return 0, fmt.Errorf("curve %s: expected %d weights, got %d", c.GetId(), len(values), len(weights))
+		}
+		var total, totalWeight float64
+		for idx, v := range values {
+			total += weights[idx] * float64(v)
+			totalWeight += weights[idx]
+		}
+		value = int(math.Round(total / totalWeight))
+	case configuration.FunctionSum:
+		var sum = 0
+		for _, v := range values {
+			sum += v
+		}
+		value = int(math.Min(float64(sum), 255))
+	case configuration.FunctionMedian:
+		sorted := append([]int{}, values...)
+		sort.Ints(sorted)
+		middle := len(sorted) / 2
+		if len(sorted)%2 == 0 {
+			value = int(math.Round(float64(sorted[middle-1]+sorted[middle]) / 2))
+		} else {
+			value = sorted[middle]
+		}
 	default:
 		ui.Fatal("Unknown curve function: %s", c.Config.Function.Type)
 	}
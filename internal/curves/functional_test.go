@@ -1,6 +1,7 @@
 package curves
 
 import (
+	"fmt"
 	"github.com/markusressel/fan2go/internal/configuration"
 	"github.com/markusressel/fan2go/internal/sensors"
 	"github.com/stretchr/testify/assert"
@@ -256,3 +257,211 @@ func TestFunctionCurveMaximum(t *testing.T) {
 	// THEN
 	assert.Equal(t, 255, result)
 }
+
+// helper function to register a linear curve (40°C - 80°C) for each of the given temperatures,
+// returns the ids of the created curves
+func registerLinearCurves(prefix string, temps ...float64) []string {
+	var curveIds []string
+	for idx, temp := range temps {
+		s := MockSensor{
+			ID:        fmt.Sprintf("%s_sensor%d", prefix, idx),
+			Name:      fmt.Sprintf("sensor%d", idx),
+			MovingAvg: temp,
+		}
+		sensors.SensorMap[s.GetId()] = &s
+
+		curveConfig := createLinearCurveConfig(
+			fmt.Sprintf("%s_curve%d", prefix, idx),
+			s.GetId(),
+			40,
+			80,
+		)
+		c, _ := NewSpeedCurve(curveConfig)
+		SpeedCurveMap[c.GetId()] = c
+		curveIds = append(curveIds, c.GetId())
+	}
+	return curveIds
+}
+
+func TestFunctionCurveWeighted(t *testing.T) {
+	// GIVEN
+	curveIds := registerLinearCurves("weighted", 40000, 80000)
+	functionCurveConfig := createFunctionCurveConfig(
+		"weighted_function_curve",
+		configuration.FunctionWeighted,
+		curveIds,
+	)
+	functionCurveConfig.Function.Weights = []float64{3, 1}
+	functionCurve, _ := NewSpeedCurve(functionCurveConfig)
+
+	// WHEN
+	result, err := functionCurve.Evaluate()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 64, result)
+}
+
+func TestFunctionCurveSum(t *testing.T) {
+	tests := []struct {
+		name     string
+		temps    []float64
+		expected int
+	}{
+		{name: "below max", temps: []float64{50000, 60000}, expected: 63 + 127},
+		{name: "clamped", temps: []float64{60000, 60000, 80000}, expected: 255},
+	}
+
+	for idx, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			curveIds := registerLinearCurves(fmt.Sprintf("sum%d", idx), tt.temps...)
+			functionCurve, _ := NewSpeedCurve(createFunctionCurveConfig(
+				fmt.Sprintf("sum_function_curve%d", idx),
+				configuration.FunctionSum,
+				curveIds,
+			))
+
+			// WHEN
+			result, err := functionCurve.Evaluate()
+
+			// THEN
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestFunctionCurveMedian(t *testing.T) {
+	tests := []struct {
+		name     string
+		temps    []float64
+		expected int
+	}{
+		{name: "odd number of curves", temps: []float64{80000, 40000, 60000}, expected: 127},
+		{name: "even number of curves", temps: []float64{80000, 40000, 60000, 50000}, expected: 95},
+	}
+
+	for idx, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			curveIds := registerLinearCurves(fmt.Sprintf("median%d", idx), tt.temps...)
+			functionCurve, _ := NewSpeedCurve(createFunctionCurveConfig(
+				fmt.Sprintf("median_function_curve%d", idx),
+				configuration.FunctionMedian,
+				curveIds,
+			))
+
+			// WHEN
+			result, err := functionCurve.Evaluate()
+
+			// THEN
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestGetSensorIdsOfFunctionCurve(t *testing.T) {
+	// GIVEN
+	curve1 := createLinearCurveConfig(
+		"sensor_ids_linear1",
+		"sensor_ids_sensor1",
+		40,
+		80,
+	)
+	c1, _ := NewSpeedCurve(curve1)
+	SpeedCurveMap[c1.GetId()] = c1
+
+	curve2 := createLinearCurveConfig(
+		"sensor_ids_linear2",
+		"sensor_ids_sensor2",
+		40,
+		80,
+	)
+	c2, _ := NewSpeedCurve(curve2)
+	SpeedCurveMap[c2.GetId()] = c2
+
+	functionCurveConfig := createFunctionCurveConfig(
+		"sensor_ids_function_curve",
+		configuration.FunctionMaximum,
+		[]string{
+			c1.GetId(),
+			c2.GetId(),
+		},
+	)
+	functionCurve, _ := NewSpeedCurve(functionCurveConfig)
+	SpeedCurveMap[functionCurve.GetId()] = functionCurve
+
+	// WHEN
+	result := GetSensorIds(functionCurve)
+
+	// THEN
+	assert.Equal(t, []string{"sensor_ids_sensor1", "sensor_ids_sensor2"}, result)
+}
+
+// countingCurve is a curve with a fixed value, which counts how often it has been evaluated
+type countingCurve struct {
+	id          string
+	value       int
+	evaluations *int
+}
+
+func (c countingCurve) GetId() string {
+	return c.id
+}
+
+func (c countingCurve) GetConfig() configuration.CurveConfig {
+	return configuration.CurveConfig{ID: c.id}
+}
+
+func (c countingCurve) Evaluate() (value int, err error) {
+	*c.evaluations++
+	return c.value, nil
+}
+
+func TestEvaluationEvaluatesSharedCurvesOnce(t *testing.T) {
+	// GIVEN
+	evaluations := 0
+	shared := countingCurve{
+		id:          "evaluation_shared",
+		value:       100,
+		evaluations: &evaluations,
+	}
+	SpeedCurveMap[shared.GetId()] = shared
+
+	maxCurve, _ := NewSpeedCurve(createFunctionCurveConfig(
+		"evaluation_max",
+		configuration.FunctionMaximum,
+		[]string{shared.GetId()},
+	))
+	SpeedCurveMap[maxCurve.GetId()] = maxCurve
+
+	avgCurve, _ := NewSpeedCurve(createFunctionCurveConfig(
+		"evaluation_avg",
+		configuration.FunctionAverage,
+		[]string{shared.GetId(), maxCurve.GetId()},
+	))
+	SpeedCurveMap[avgCurve.GetId()] = avgCurve
+
+	evaluation := NewEvaluation()
+
+	// WHEN
+	avgValue, avgErr := evaluation.Evaluate(avgCurve)
+	maxValue, maxErr := evaluation.Evaluate(maxCurve)
+	sharedValue, sharedErr := evaluation.Evaluate(shared)
+
+	// THEN
+	assert.NoError(t, avgErr)
+	assert.NoError(t, maxErr)
+	assert.NoError(t, sharedErr)
+	assert.Equal(t, 100, avgValue)
+	assert.Equal(t, 100, maxValue)
+	assert.Equal(t, 100, sharedValue)
+	assert.Equal(t, 1, evaluations)
+	assert.Equal(t, map[string]int{
+		shared.GetId():   100,
+		maxCurve.GetId(): 100,
+		avgCurve.GetId(): 100,
+	}, evaluation.Values())
+}
@@ -1,6 +1,7 @@
 package curves
 
 import (
+	"fmt"
 	"github.com/markusressel/fan2go/internal/configuration"
 	"github.com/markusressel/fan2go/internal/sensors"
 	"github.com/markusressel/fan2go/internal/util"
@@ -16,13 +17,22 @@ func (c LinearSpeedCurve) GetId() string {
 	return c.Config.ID
 }
 
+func (c LinearSpeedCurve) GetConfig() configuration.CurveConfig {
+	return c.Config
+}
+
 func (c LinearSpeedCurve) Evaluate() (value int, err error) {
-	sensor := sensors.SensorMap[c.Config.Linear.Sensor]
+	sensor, exists := sensors.GetSensor(c.Config.Linear.Sensor)
+	if !exists {
+		return 0, fmt.Errorf("curve %s: no sensor with id '%s' found", c.GetId(), c.Config.Linear.Sensor)
+	}
 	var avgTemp = sensor.GetMovingAvg()
 
 	steps := c.Config.Linear.Steps
 	if steps != nil {
-		value = int(math.Round(util.CalculateInterpolatedCurveValue(steps, util.InterpolationTypeLinear, avgTemp/1000)))
+		interpolated := util.CalculateInterpolatedCurveValue(steps, c.getInterpolationType(), avgTemp/1000)
+		// splines may overshoot the range of the steps
+		value = int(math.Round(util.Coerce(interpolated, 0, 255)))
 	} else {
 		minTemp := float64(c.Config.Linear.Min) * 1000 // degree to milli-degree
 		maxTemp := float64(c.Config.Linear.Max) * 1000
@@ -42,3 +52,10 @@ func (c LinearSpeedCurve) Evaluate() (value int, err error) {
 	c.Value = value
 	return value, nil
 }
+
+func (c LinearSpeedCurve) getInterpolationType() string {
+	if len(c.Config.Linear.Interpolation) > 0 {
+		return c.Config.Linear.Interpolation
+	}
+	return util.InterpolationTypeLinear
+}
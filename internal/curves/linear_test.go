@@ -3,6 +3,7 @@ package curves
 import (
 	"github.com/markusressel/fan2go/internal/configuration"
 	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/markusressel/fan2go/internal/util"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -99,3 +100,48 @@ func TestLinearCurveWithSteps(t *testing.T) {
 	// THEN
 	assert.Equal(t, 100, result)
 }
+
+func TestLinearCurveWithStepsInterpolation(t *testing.T) {
+	tests := []struct {
+		interpolation string
+		temp          float64
+		expected      int
+	}{
+		{interpolation: "", temp: 65000, expected: 228},
+		{interpolation: util.InterpolationTypeStep, temp: 65000, expected: 200},
+		{interpolation: util.InterpolationTypeMonotone, temp: 45000, expected: 0},
+		// the undershoot of the cubic spline is clamped
+		{interpolation: util.InterpolationTypeCubic, temp: 45000, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.interpolation, func(t *testing.T) {
+			// GIVEN
+			s := MockSensor{
+				Name:      "sensor",
+				MovingAvg: tt.temp,
+			}
+			sensors.SensorMap[s.GetId()] = &s
+
+			curveConfig := createLinearCurveConfigWithSteps(
+				"curve",
+				s.GetId(),
+				map[int]float64{
+					40: 0,
+					50: 0,
+					60: 200,
+					70: 255,
+				},
+			)
+			curveConfig.Linear.Interpolation = tt.interpolation
+			curve, _ := NewSpeedCurve(curveConfig)
+
+			// WHEN
+			result, err := curve.Evaluate()
+
+			// THEN
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -1,39 +1,77 @@
 package curves
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/markusressel/fan2go/internal/configuration"
 	"github.com/markusressel/fan2go/internal/sensors"
 	"github.com/markusressel/fan2go/internal/util"
+	"sync"
 )
 
 type PidSpeedCurve struct {
 	Config configuration.CurveConfig `json:"config"`
 	Value  int                       `json:"value"`
+	// Terms are the individual parts of the last loop output, mapped to the range of the curve value
+	Terms util.PidTerms `json:"terms"`
 
+	mutex   sync.Mutex
 	pidLoop *util.PidLoop
 }
 
-func (c PidSpeedCurve) GetId() string {
+func (c *PidSpeedCurve) GetId() string {
 	return c.Config.ID
 }
 
-func (c PidSpeedCurve) Evaluate() (value int, err error) {
-	sensor := sensors.SensorMap[c.Config.PID.Sensor]
-	measured, err := sensor.GetValue()
+func (c *PidSpeedCurve) GetConfig() configuration.CurveConfig {
+	return c.Config
+}
+
+func (c *PidSpeedCurve) Evaluate() (value int, err error) {
+	sensor, exists := sensors.GetSensor(c.Config.PID.Sensor)
+	if !exists {
+		return 0, fmt.Errorf("curve %s: no sensor with id '%s' found", c.GetId(), c.Config.PID.Sensor)
+	}
+	measured := sensor.GetMovingAvg()
 	pidTarget := c.Config.PID.SetPoint
 
-	loopValue := c.pidLoop.Loop(pidTarget, measured/1000.0)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 
-	// clamp to (0..1)
-	if loopValue > 1 {
-		loopValue = 1
-	} else if loopValue < 0 {
-		loopValue = 0
-	}
+	loopValue := c.pidLoop.Loop(pidTarget, measured/1000.0)
 
 	// map to expected output range
 	curveValue := int(loopValue * 255)
 
+	terms := c.pidLoop.Terms()
 	c.Value = curveValue
+	c.Terms = util.PidTerms{
+		Error:  terms.Error,
+		P:      terms.P * 255,
+		I:      terms.I * 255,
+		D:      terms.D * 255,
+		Output: terms.Output * 255,
+	}
 	return curveValue, nil
 }
+
+// GetTerms returns the individual parts of the last output of the curve
+func (c *PidSpeedCurve) GetTerms() util.PidTerms {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.Terms
+}
+
+func (c *PidSpeedCurve) MarshalJSON() ([]byte, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return json.Marshal(struct {
+		Config configuration.CurveConfig `json:"config"`
+		Value  int                       `json:"value"`
+		Terms  util.PidTerms             `json:"terms"`
+	}{
+		Config: c.Config,
+		Value:  c.Value,
+		Terms:  c.Terms,
+	})
+}
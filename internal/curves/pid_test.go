@@ -487,3 +487,54 @@ func TestPidCurveWayAboveTarget(t *testing.T) {
 		time.Sleep(200 * time.Millisecond)
 	}
 }
+
+func TestPidCurveReverseWithOutputLimits(t *testing.T) {
+	// GIVEN
+	s := MockSensor{
+		Name:      "sensor",
+		MovingAvg: 90000.0,
+	}
+	sensors.SensorMap[s.GetId()] = &s
+
+	curveConfig := createPidCurveConfig(
+		"curve",
+		s.GetId(),
+		60,
+		0.05,
+		0,
+		0,
+	)
+	outputMin := 50
+	outputMax := 200
+	curveConfig.PID.OutputMin = &outputMin
+	curveConfig.PID.OutputMax = &outputMax
+	curveConfig.PID.Reverse = true
+
+	curve, err := NewSpeedCurve(curveConfig)
+	if err != nil {
+		assert.Fail(t, err.Error())
+	}
+	_, _ = curve.Evaluate()
+	time.Sleep(10 * time.Millisecond)
+
+	// WHEN
+	result, err := curve.Evaluate()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 200, result)
+	pidCurve := curve.(*PidSpeedCurve)
+	assert.Equal(t, 200, pidCurve.Value)
+	terms := pidCurve.GetTerms()
+	assert.Equal(t, 30.0, terms.Error)
+	assert.InDelta(t, 382.5, terms.P, 0.000001)
+
+	// WHEN
+	s.MovingAvg = 40000.0
+	time.Sleep(10 * time.Millisecond)
+	result, err = curve.Evaluate()
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 50, result)
+}
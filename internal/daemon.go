@@ -0,0 +1,511 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/controller"
+	"github.com/markusressel/fan2go/internal/curves"
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/hwmon"
+	"github.com/markusressel/fan2go/internal/persistence"
+	"github.com/markusressel/fan2go/internal/profiles"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/markusressel/fan2go/internal/ui"
+	"github.com/markusressel/fan2go/internal/util"
+	"reflect"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// daemon keeps track of all sensors, curves, fan groups and fan controllers which are currently running
+// and allows them to be added, replaced or removed without restarting the whole process.
+// The control loop of all of them is run by the scheduler.
+type daemon struct {
+	ctx         context.Context
+	persistence persistence.Persistence
+
+	// serializes configuration changes and guards all fields below
+	mutex sync.Mutex
+	// the configuration of all currently running objects
+	config configuration.Configuration
+	// running fan controllers by fan id
+	fanControllers map[string]*task
+	// waits for all running tasks
+	wg sync.WaitGroup
+}
+
+// task is a goroutine that can be stopped individually
+type task struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// stop cancels the task and waits for it to finish
+func (t *task) stop() {
+	t.cancel()
+	<-t.done
+}
+
+func newDaemon(ctx context.Context, pers persistence.Persistence) *daemon {
+	return &daemon{
+		ctx:            ctx,
+		persistence:    pers,
+		fanControllers: map[string]*task{},
+	}
+}
+
+// Wait blocks until all running tasks have stopped
+func (d *daemon) Wait() {
+	d.wg.Wait()
+}
+
+// Reload re-reads the configuration file and applies all changes to sensors, curves and fans.
+// If the new configuration is invalid, it is rejected and the currently running configuration is kept.
+func (d *daemon) Reload() error {
+	configPath := configuration.GetFilePath()
+	ui.Info("Reloading configuration file at: %s", configPath)
+
+	configFile, err := configuration.ReadConfigFile()
+	if err != nil {
+		return fmt.Errorf("unable to read config file: %v", err)
+	}
+	newConfig := configFile.Config
+	if err = configuration.ValidateConfig(&newConfig, configPath); err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(withoutObjects(newConfig), withoutObjects(configuration.CurrentConfig)) {
+		ui.Warning("Changes to global settings have been detected, these are only applied after a restart")
+	}
+
+	if err = d.ApplyConfig(newConfig); err != nil {
+		return err
+	}
+
+	// only keep the values of the new file once it has been applied, so a rejected file doesn't leave any trace
+	if err = configFile.Commit(); err != nil {
+		ui.Warning("Unable to update configuration values from %s: %v", configPath, err)
+	}
+	ui.Info("Configuration reloaded successfully")
+	return nil
+}
+
+// withoutObjects returns a copy of the given configuration without any sensor, curve, fan, fan group or profile definitions
+func withoutObjects(config configuration.Configuration) configuration.Configuration {
+	config.Sensors = nil
+	config.Curves = nil
+	config.Fans = nil
+	config.FanGroups = nil
+	config.Profiles = nil
+	config.Schedule = nil
+	return config
+}
+
+// UpdateConfig applies the given modification to a copy of the configuration of all currently running objects
+// and applies the result. If modify returns an error, nothing is changed.
+func (d *daemon) UpdateConfig(modify func(config *configuration.Configuration) error) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	config := d.copyConfig()
+	if err := modify(&config); err != nil {
+		return err
+	}
+	return d.applyConfig(config)
+}
+
+// copyConfig returns a copy of the configuration of all currently running objects
+func (d *daemon) copyConfig() configuration.Configuration {
+	config := configuration.CurrentConfig
+	config.Sensors = append([]configuration.SensorConfig{}, d.config.Sensors...)
+	config.Curves = append([]configuration.CurveConfig{}, d.config.Curves...)
+	config.Fans = append([]configuration.FanConfig{}, d.config.Fans...)
+	config.FanGroups = append([]configuration.FanGroupConfig{}, d.config.FanGroups...)
+	config.Profiles = append([]configuration.ProfileConfig{}, d.config.Profiles...)
+	config.Schedule = append([]configuration.ScheduleConfig{}, d.config.Schedule...)
+	return config
+}
+
+// ApplyConfig compares the sensors, curves, fans and fan groups of the given configuration with the running ones
+// and only starts, replaces or stops the objects that have been changed.
+// The given configuration is expected to be validated already.
+func (d *daemon) ApplyConfig(newConfig configuration.Configuration) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.applyConfig(newConfig)
+}
+
+func (d *daemon) applyConfig(newConfig configuration.Configuration) error {
+	if len(newConfig.Fans) == 0 {
+		return errors.New("no valid fan configurations")
+	}
+
+	oldSensors := map[string]configuration.SensorConfig{}
+	for _, config := range d.config.Sensors {
+		oldSensors[config.ID] = config
+	}
+	oldCurves := map[string]configuration.CurveConfig{}
+	for _, config := range d.config.Curves {
+		oldCurves[config.ID] = config
+	}
+	oldFans := map[string]configuration.FanConfig{}
+	for _, config := range d.config.Fans {
+		oldFans[config.ID] = config
+	}
+
+	// fan controllers pick up their group when they are started, so if any group has changed,
+	// all groups are replaced and the controllers of all old and new member fans are restarted
+	groupsChanged := !reflect.DeepEqual(d.config.FanGroups, newConfig.FanGroups)
+	groupMembers := map[string]bool{}
+	if groupsChanged {
+		for _, config := range append(append([]configuration.FanGroupConfig{}, d.config.FanGroups...), newConfig.FanGroups...) {
+			for _, fanId := range config.Fans {
+				groupMembers[fanId] = true
+			}
+		}
+	}
+
+	hwmonControllers := hwmon.GetChips()
+
+	// create all new objects first, so nothing is changed if one of them fails
+	var newSensors []sensors.Sensor
+	newSensorIds := map[string]bool{}
+	for _, config := range newConfig.Sensors {
+		newSensorIds[config.ID] = true
+		if old, exists := oldSensors[config.ID]; exists && reflect.DeepEqual(old, config) {
+			continue
+		}
+		sensor, err := createSensor(config, hwmonControllers)
+		if err != nil {
+			return err
+		}
+		newSensors = append(newSensors, sensor)
+	}
+
+	var newCurves []curves.SpeedCurve
+	newCurveIds := map[string]bool{}
+	for _, config := range newConfig.Curves {
+		newCurveIds[config.ID] = true
+		if old, exists := oldCurves[config.ID]; exists && reflect.DeepEqual(old, config) {
+			continue
+		}
+		curve, err := curves.NewSpeedCurve(config)
+		if err != nil {
+			return fmt.Errorf("unable to process curve configuration of '%s': %v", config.ID, err)
+		}
+		newCurves = append(newCurves, curve)
+	}
+
+	var newFans []fans.Fan
+	newFanConfigs := map[string]configuration.FanConfig{}
+	newFanIds := map[string]bool{}
+	for _, config := range newConfig.Fans {
+		newFanIds[config.ID] = true
+		if old, exists := oldFans[config.ID]; exists && reflect.DeepEqual(old, config) && !groupMembers[config.ID] {
+			continue
+		}
+		fan, err := createFan(config, hwmonControllers)
+		if err != nil {
+			return err
+		}
+		newFans = append(newFans, fan)
+		newFanConfigs[config.ID] = config
+	}
+
+	// start or replace sensors
+	for _, sensor := range newSensors {
+		currentValue, err := sensor.GetValue()
+		if err != nil {
+			ui.Warning("Error reading sensor %s: %v", sensor.GetId(), err)
+		}
+		sensor.SetMovingAvg(currentValue)
+
+		if _, exists := oldSensors[sensor.GetId()]; exists {
+			ui.Info("Replacing sensor %s...", sensor.GetId())
+		}
+		sensors.RegisterSensor(sensor)
+	}
+
+	// add or replace curves
+	for _, curve := range newCurves {
+		if _, exists := oldCurves[curve.GetId()]; exists {
+			ui.Info("Replacing curve %s...", curve.GetId())
+		}
+		curves.RegisterSpeedCurve(curve)
+	}
+
+	// stop fan controllers of changed or removed fans
+	for id := range oldFans {
+		if _, changed := newFanConfigs[id]; changed || !newFanIds[id] {
+			d.stopFanController(id)
+		}
+	}
+
+	// replace fan groups, before the controllers of their member fans are started
+	if groupsChanged {
+		for _, config := range d.config.FanGroups {
+			ui.Info("Removing fan group %s...", config.ID)
+			controller.RemoveFanGroup(config.ID)
+		}
+		for _, config := range newConfig.FanGroups {
+			createFanGroup(config, newConfig.Fans)
+		}
+	}
+
+	// start fan controllers of changed or added fans
+	for _, fan := range newFans {
+		if _, exists := oldFans[fan.GetId()]; exists {
+			ui.Info("Replacing fan %s...", fan.GetId())
+		}
+		fans.RegisterFan(fan)
+		d.startFanController(newFanConfigs[fan.GetId()], fan)
+	}
+
+	// let all fans pick up replaced curves and the curves of the active profile
+	profiles.SetConfig(newConfig.Profiles, newConfig.Schedule)
+	profiles.Update(time.Now())
+	applyProfileCurves(newConfig.Fans)
+
+	// remove curves and sensors that are no longer used
+	for id := range oldCurves {
+		if !newCurveIds[id] {
+			ui.Info("Removing curve %s...", id)
+			curves.RemoveSpeedCurve(id)
+		}
+	}
+	for id := range oldSensors {
+		if !newSensorIds[id] {
+			ui.Info("Removing sensor %s...", id)
+			sensors.RemoveSensor(id)
+		}
+	}
+
+	d.config.Sensors = newConfig.Sensors
+	d.config.Curves = newConfig.Curves
+	d.config.Fans = newConfig.Fans
+	d.config.FanGroups = newConfig.FanGroups
+	d.config.Profiles = newConfig.Profiles
+	d.config.Schedule = newConfig.Schedule
+	configuration.CurrentConfig.Sensors = newConfig.Sensors
+	configuration.CurrentConfig.Curves = newConfig.Curves
+	configuration.CurrentConfig.Fans = newConfig.Fans
+	configuration.CurrentConfig.FanGroups = newConfig.FanGroups
+	configuration.CurrentConfig.Profiles = newConfig.Profiles
+	configuration.CurrentConfig.Schedule = newConfig.Schedule
+
+	return nil
+}
+
+// UpdateProfile evaluates the triggers and the schedule and switches the curves of all fans if the active profile has changed
+func (d *daemon) UpdateProfile() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if profiles.Update(time.Now()) {
+		logActiveProfile()
+		applyProfileCurves(d.config.Fans)
+	}
+}
+
+// ForceProfile activates the profile with the given id until ClearForcedProfile is called
+func (d *daemon) ForceProfile(id string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if err := profiles.Force(id); err != nil {
+		return err
+	}
+	logActiveProfile()
+	applyProfileCurves(d.config.Fans)
+	return nil
+}
+
+// ClearForcedProfile hands control over the active profile back to the triggers and the schedule
+func (d *daemon) ClearForcedProfile() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	profiles.ClearForced()
+	profiles.Update(time.Now())
+	logActiveProfile()
+	applyProfileCurves(d.config.Fans)
+}
+
+func logActiveProfile() {
+	if active := profiles.GetActiveProfile(); len(active) > 0 {
+		ui.Info("Switched to profile %s", active)
+	} else {
+		ui.Info("No profile active, using the default curves of all fans")
+	}
+}
+
+// applyProfileCurves lets all fan controllers and fan groups use the curve the active profile defines for them,
+// or their own curve if there is none. The curve ids are taken from the given fan configurations, since the fans
+// themselves are owned by their running controllers.
+func applyProfileCurves(fanConfigs []configuration.FanConfig) {
+	for id, c := range controller.SnapshotFanControllerMap() {
+		fanConfig, exists := findFanConfig(fanConfigs, id)
+		if !exists {
+			continue
+		}
+		curveId := profiles.GetCurveId(id, fanConfig.Curve)
+		if curve, exists := curves.GetSpeedCurve(curveId); exists {
+			c.SetCurve(curve)
+		}
+	}
+	for id, g := range controller.SnapshotFanGroupMap() {
+		curveId := profiles.GetCurveId(id, g.GetConfig().GetCurveId(fanConfigs))
+		if curve, exists := curves.GetSpeedCurve(curveId); exists {
+			g.SetCurve(curve)
+		}
+	}
+}
+
+// findFanConfig returns the configuration of the fan with the given id
+func findFanConfig(fanConfigs []configuration.FanConfig, fanId string) (configuration.FanConfig, bool) {
+	for _, config := range fanConfigs {
+		if config.ID == fanId {
+			return config, true
+		}
+	}
+	return configuration.FanConfig{}, false
+}
+
+func (d *daemon) startFanController(config configuration.FanConfig, fan fans.Fan) {
+	updateRate := configuration.CurrentConfig.ControllerAdjustmentTickRate
+
+	var pidLoop util.PidLoop
+	if config.ControlLoop != nil {
+		pidLoop = *util.NewPidLoop(
+			config.ControlLoop.P,
+			config.ControlLoop.I,
+			config.ControlLoop.D,
+		)
+	} else {
+		pidLoop = *util.NewPidLoop(
+			0.03,
+			0.002,
+			0.0005,
+		)
+	}
+	fanController := controller.NewFanController(d.persistence, fan, pidLoop, updateRate)
+	controller.RegisterFanController(fanController)
+
+	d.fanControllers[fan.GetId()] = d.startTask(func(ctx context.Context) {
+		err := fanController.Run(ctx)
+		ui.Info("Fan controller for fan %s stopped.", fan.GetId())
+		if err != nil {
+			ui.NotifyError(fmt.Sprintf("Fan Controller: %s", fan.GetId()), err.Error())
+			panic(err)
+		}
+	})
+}
+
+// stopFanController stops the controller of the given fan and waits until it
+// has restored the original fan settings
+func (d *daemon) stopFanController(fanId string) {
+	t, exists := d.fanControllers[fanId]
+	if !exists {
+		return
+	}
+	ui.Info("Stopping fan controller for fan %s...", fanId)
+	t.stop()
+	delete(d.fanControllers, fanId)
+	controller.RemoveFanController(fanId)
+	fans.RemoveFan(fanId)
+}
+
+// createFanGroup registers a fan group, its decisions are made by the scheduler
+func createFanGroup(config configuration.FanGroupConfig, fanConfigs []configuration.FanConfig) {
+	ui.Info("Adding fan group %s with fans %v...", config.ID, config.Fans)
+	curve, _ := curves.GetSpeedCurve(config.GetCurveId(fanConfigs))
+	controller.RegisterFanGroup(controller.NewFanGroup(config, curve))
+}
+
+// startTask runs the given function in a new goroutine, which is stopped when either the task
+// or the daemon context is cancelled
+func (d *daemon) startTask(run func(ctx context.Context)) *task {
+	ctx, cancel := context.WithCancel(d.ctx)
+	t := &task{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		defer close(t.done)
+		run(ctx)
+	}()
+
+	return t
+}
+
+func createSensor(config configuration.SensorConfig, controllers []*hwmon.HwMonController) (sensors.Sensor, error) {
+	if config.HwMon != nil {
+		found := false
+		for _, c := range controllers {
+			matched, err := regexp.MatchString("(?i)"+config.HwMon.Platform, c.Platform)
+			if err != nil {
+				return nil, fmt.Errorf("failed to match platform regex of %s (%s) against controller platform %s", config.ID, config.HwMon.Platform, c.Platform)
+			}
+			if matched {
+				sensor, exists := c.Sensors[config.HwMon.Index]
+				if !exists {
+					continue
+				}
+				found = true
+				// copy the hwmon config to keep the original configuration untouched
+				hwMonConfig := *config.HwMon
+				hwMonConfig.TempInput = sensor.Input
+				config.HwMon = &hwMonConfig
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("couldn't find hwmon device with platform '%s' for sensor: %s. Run 'fan2go detect' again and correct any mistake", config.HwMon.Platform, config.ID)
+		}
+	}
+
+	sensor, err := sensors.NewSensor(config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to process sensor configuration of '%s': %v", config.ID, err)
+	}
+	return sensor, nil
+}
+
+func createFan(config configuration.FanConfig, controllers []*hwmon.HwMonController) (fans.Fan, error) {
+	if config.HwMon != nil {
+		found := false
+		for _, c := range controllers {
+			matched, err := regexp.MatchString("(?i)"+config.HwMon.Platform, c.Platform)
+			if err != nil {
+				return nil, fmt.Errorf("failed to match platform regex of %s (%s) against controller platform %s", config.ID, config.HwMon.Platform, c.Platform)
+			}
+			if matched {
+				fan, exists := c.Fans[config.HwMon.Index]
+				if !exists {
+					continue
+				}
+				found = true
+				// copy the hwmon config to keep the original configuration untouched
+				hwMonConfig := *config.HwMon
+				hwMonConfig.PwmOutput = fan.Config.HwMon.PwmOutput
+				hwMonConfig.RpmInput = fan.Config.HwMon.RpmInput
+				config.HwMon = &hwMonConfig
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("couldn't find hwmon device with platform '%s' for fan: %s", config.HwMon.Platform, config.ID)
+		}
+	}
+
+	fan, err := fans.NewFan(config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to process fan configuration of '%s': %v", config.ID, err)
+	}
+	return fan, nil
+}
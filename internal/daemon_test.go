@@ -0,0 +1,324 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/controller"
+	"github.com/markusressel/fan2go/internal/curves"
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/persistence"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestDaemon creates a daemon using a database in a temporary directory, which is stopped after the test
+func newTestDaemon(t *testing.T) *daemon {
+	configuration.CurrentConfig = configuration.Configuration{
+		TempSensorPollingRate:        time.Second,
+		ControllerAdjustmentTickRate: time.Second,
+		TempRollingWindowSize:        10,
+		RpmRollingWindowSize:         10,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d := newDaemon(ctx, persistence.NewPersistence(filepath.Join(t.TempDir(), "fan2go.db")))
+	t.Cleanup(func() {
+		cancel()
+		d.Wait()
+		for _, config := range d.config.Fans {
+			controller.RemoveFanController(config.ID)
+			fans.RemoveFan(config.ID)
+		}
+		for _, config := range d.config.FanGroups {
+			controller.RemoveFanGroup(config.ID)
+		}
+		for _, config := range d.config.Curves {
+			curves.RemoveSpeedCurve(config.ID)
+		}
+		for _, config := range d.config.Sensors {
+			sensors.RemoveSensor(config.ID)
+		}
+	})
+	return d
+}
+
+// createTestFile creates a file with the given content in a temporary directory and returns its path
+func createTestFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(content), 0644)
+	assert.NoError(t, err)
+	return path
+}
+
+func createFileSensorConfig(t *testing.T, id string) configuration.SensorConfig {
+	return configuration.SensorConfig{
+		ID:   id,
+		File: &configuration.FileSensorConfig{Path: createTestFile(t, id, "40000")},
+	}
+}
+
+func createLinearCurveConfig(id string, sensorId string, max int) configuration.CurveConfig {
+	return configuration.CurveConfig{
+		ID: id,
+		Linear: &configuration.LinearCurveConfig{
+			Sensor: sensorId,
+			Min:    40,
+			Max:    max,
+		},
+	}
+}
+
+func createFileFanConfig(t *testing.T, id string, curveId string) configuration.FanConfig {
+	return configuration.FanConfig{
+		ID:    id,
+		Curve: curveId,
+		File:  &configuration.FileFanConfig{Path: createTestFile(t, id, "100")},
+	}
+}
+
+func TestApplyConfigStartsAllObjects(t *testing.T) {
+	// GIVEN
+	d := newTestDaemon(t)
+	config := configuration.Configuration{
+		Sensors: []configuration.SensorConfig{createFileSensorConfig(t, "daemon_sensor")},
+		Curves:  []configuration.CurveConfig{createLinearCurveConfig("daemon_curve", "daemon_sensor", 80)},
+		Fans:    []configuration.FanConfig{createFileFanConfig(t, "daemon_fan", "daemon_curve")},
+	}
+
+	// WHEN
+	err := d.ApplyConfig(config)
+
+	// THEN
+	assert.NoError(t, err)
+	_, exists := sensors.GetSensor("daemon_sensor")
+	assert.True(t, exists)
+	_, exists = curves.GetSpeedCurve("daemon_curve")
+	assert.True(t, exists)
+	_, exists = fans.GetFan("daemon_fan")
+	assert.True(t, exists)
+	_, exists = controller.GetFanController("daemon_fan")
+	assert.True(t, exists)
+	assert.Contains(t, d.fanControllers, "daemon_fan")
+	assert.Equal(t, config.Fans, configuration.CurrentConfig.Fans)
+}
+
+func TestApplyConfigReplacesOnlyChangedObjects(t *testing.T) {
+	// GIVEN
+	d := newTestDaemon(t)
+	keptSensor := createFileSensorConfig(t, "kept_sensor")
+	removedSensor := createFileSensorConfig(t, "removed_sensor")
+	keptCurve := createLinearCurveConfig("kept_curve", keptSensor.ID, 80)
+	changedCurve := createLinearCurveConfig("changed_curve", keptSensor.ID, 80)
+	removedCurve := createLinearCurveConfig("removed_curve", removedSensor.ID, 80)
+	keptFan := createFileFanConfig(t, "kept_fan", keptCurve.ID)
+	changedFan := createFileFanConfig(t, "changed_fan", keptCurve.ID)
+	removedFan := createFileFanConfig(t, "removed_fan", removedCurve.ID)
+
+	configA := configuration.Configuration{
+		Sensors: []configuration.SensorConfig{keptSensor, removedSensor},
+		Curves:  []configuration.CurveConfig{keptCurve, changedCurve, removedCurve},
+		Fans:    []configuration.FanConfig{keptFan, changedFan, removedFan},
+	}
+	err := d.ApplyConfig(configA)
+	assert.NoError(t, err)
+
+	sensorA, _ := sensors.GetSensor(keptSensor.ID)
+	keptCurveA, _ := curves.GetSpeedCurve(keptCurve.ID)
+	changedCurveA, _ := curves.GetSpeedCurve(changedCurve.ID)
+	keptControllerA, _ := controller.GetFanController(keptFan.ID)
+	changedControllerA, _ := controller.GetFanController(changedFan.ID)
+
+	changedCurve = createLinearCurveConfig(changedCurve.ID, keptSensor.ID, 90)
+	changedFan.Curve = changedCurve.ID
+	addedFan := createFileFanConfig(t, "added_fan", changedCurve.ID)
+	configB := configuration.Configuration{
+		Sensors: []configuration.SensorConfig{keptSensor},
+		Curves:  []configuration.CurveConfig{keptCurve, changedCurve},
+		Fans:    []configuration.FanConfig{keptFan, changedFan, addedFan},
+	}
+
+	// WHEN
+	err = d.ApplyConfig(configB)
+
+	// THEN
+	assert.NoError(t, err)
+
+	sensorB, exists := sensors.GetSensor(keptSensor.ID)
+	assert.True(t, exists)
+	assert.Same(t, sensorA, sensorB)
+	_, exists = sensors.GetSensor(removedSensor.ID)
+	assert.False(t, exists)
+
+	keptCurveB, _ := curves.GetSpeedCurve(keptCurve.ID)
+	assert.Same(t, keptCurveA, keptCurveB)
+	changedCurveB, exists := curves.GetSpeedCurve(changedCurve.ID)
+	assert.True(t, exists)
+	assert.NotSame(t, changedCurveA, changedCurveB)
+	assert.Equal(t, 90, changedCurveB.GetConfig().Linear.Max)
+	_, exists = curves.GetSpeedCurve(removedCurve.ID)
+	assert.False(t, exists)
+
+	keptControllerB, _ := controller.GetFanController(keptFan.ID)
+	assert.Same(t, keptControllerA, keptControllerB)
+	changedControllerB, exists := controller.GetFanController(changedFan.ID)
+	assert.True(t, exists)
+	assert.NotSame(t, changedControllerA, changedControllerB)
+	_, exists = controller.GetFanController(addedFan.ID)
+	assert.True(t, exists)
+	_, exists = controller.GetFanController(removedFan.ID)
+	assert.False(t, exists)
+	_, exists = fans.GetFan(removedFan.ID)
+	assert.False(t, exists)
+	assert.NotContains(t, d.fanControllers, removedFan.ID)
+
+	assert.Equal(t, configB.Fans, d.config.Fans)
+	assert.Equal(t, configB.Curves, configuration.CurrentConfig.Curves)
+}
+
+func TestApplyConfigRestartsGroupMembers(t *testing.T) {
+	// GIVEN
+	d := newTestDaemon(t)
+	sensor := createFileSensorConfig(t, "group_sensor")
+	curve := createLinearCurveConfig("group_curve", sensor.ID, 80)
+	memberFan := createFileFanConfig(t, "member_fan", curve.ID)
+	otherFan := createFileFanConfig(t, "other_fan", curve.ID)
+	configA := configuration.Configuration{
+		Sensors: []configuration.SensorConfig{sensor},
+		Curves:  []configuration.CurveConfig{curve},
+		Fans:    []configuration.FanConfig{memberFan, otherFan},
+	}
+	err := d.ApplyConfig(configA)
+	assert.NoError(t, err)
+
+	memberControllerA, _ := controller.GetFanController(memberFan.ID)
+	otherControllerA, _ := controller.GetFanController(otherFan.ID)
+
+	configB := configA
+	configB.FanGroups = []configuration.FanGroupConfig{
+		{ID: "daemon_group", Fans: []string{memberFan.ID}},
+	}
+
+	// WHEN
+	err = d.ApplyConfig(configB)
+
+	// THEN
+	assert.NoError(t, err)
+	group, exists := controller.GetFanGroup("daemon_group")
+	assert.True(t, exists)
+	assert.Equal(t, []string{memberFan.ID}, group.GetFanIds())
+
+	memberControllerB, _ := controller.GetFanController(memberFan.ID)
+	assert.NotSame(t, memberControllerA, memberControllerB)
+	otherControllerB, _ := controller.GetFanController(otherFan.ID)
+	assert.Same(t, otherControllerA, otherControllerB)
+
+	// WHEN
+	err = d.ApplyConfig(configA)
+
+	// THEN
+	assert.NoError(t, err)
+	_, exists = controller.GetFanGroup("daemon_group")
+	assert.False(t, exists)
+	memberControllerC, _ := controller.GetFanController(memberFan.ID)
+	assert.NotSame(t, memberControllerB, memberControllerC)
+}
+
+func TestApplyConfigRejectedKeepsRunningState(t *testing.T) {
+	// GIVEN
+	d := newTestDaemon(t)
+	sensor := createFileSensorConfig(t, "rejected_sensor")
+	curve := createLinearCurveConfig("rejected_curve", sensor.ID, 80)
+	fan := createFileFanConfig(t, "rejected_fan", curve.ID)
+	configA := configuration.Configuration{
+		Sensors: []configuration.SensorConfig{sensor},
+		Curves:  []configuration.CurveConfig{curve},
+		Fans:    []configuration.FanConfig{fan},
+	}
+	err := d.ApplyConfig(configA)
+	assert.NoError(t, err)
+
+	sensorA, _ := sensors.GetSensor(sensor.ID)
+	curveA, _ := curves.GetSpeedCurve(curve.ID)
+	controllerA, _ := controller.GetFanController(fan.ID)
+
+	changedCurve := createLinearCurveConfig(curve.ID, sensor.ID, 90)
+	missingSensor := configuration.SensorConfig{
+		ID:    "missing_sensor",
+		HwMon: &configuration.HwMonSensorConfig{Platform: "does-not-exist", Index: 1},
+	}
+
+	tests := []struct {
+		name   string
+		config configuration.Configuration
+	}{
+		{
+			name: "sensor can't be created",
+			config: configuration.Configuration{
+				Sensors: []configuration.SensorConfig{sensor, missingSensor},
+				Curves:  []configuration.CurveConfig{changedCurve},
+				Fans:    []configuration.FanConfig{fan},
+			},
+		},
+		{
+			name: "no fans",
+			config: configuration.Configuration{
+				Sensors: []configuration.SensorConfig{sensor},
+				Curves:  []configuration.CurveConfig{changedCurve},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// WHEN
+			err := d.ApplyConfig(tt.config)
+
+			// THEN
+			assert.Error(t, err)
+
+			sensorB, _ := sensors.GetSensor(sensor.ID)
+			assert.Same(t, sensorA, sensorB)
+			_, exists := sensors.GetSensor(missingSensor.ID)
+			assert.False(t, exists)
+			curveB, _ := curves.GetSpeedCurve(curve.ID)
+			assert.Same(t, curveA, curveB)
+			controllerB, _ := controller.GetFanController(fan.ID)
+			assert.Same(t, controllerA, controllerB)
+			assert.Equal(t, configA.Curves, d.config.Curves)
+			assert.Equal(t, configA.Curves, configuration.CurrentConfig.Curves)
+		})
+	}
+}
+
+func TestUpdateConfigModifyErrorKeepsRunningState(t *testing.T) {
+	// GIVEN
+	d := newTestDaemon(t)
+	sensor := createFileSensorConfig(t, "update_sensor")
+	curve := createLinearCurveConfig("update_curve", sensor.ID, 80)
+	fan := createFileFanConfig(t, "update_fan", curve.ID)
+	err := d.ApplyConfig(configuration.Configuration{
+		Sensors: []configuration.SensorConfig{sensor},
+		Curves:  []configuration.CurveConfig{curve},
+		Fans:    []configuration.FanConfig{fan},
+	})
+	assert.NoError(t, err)
+	controllerA, _ := controller.GetFanController(fan.ID)
+
+	// WHEN
+	err = d.UpdateConfig(func(config *configuration.Configuration) error {
+		config.Fans = nil
+		return errors.New("rejected")
+	})
+
+	// THEN
+	assert.EqualError(t, err, "rejected")
+	controllerB, exists := controller.GetFanController(fan.ID)
+	assert.True(t, exists)
+	assert.Same(t, controllerA, controllerB)
+	assert.Len(t, d.config.Fans, 1)
+}
@@ -0,0 +1,268 @@
+package expression
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Expression is a parsed arithmetic expression, which can be evaluated with different variable values.
+//
+// Supported are numbers, variables, the operators + - * / % < <= > >= == != && || ! and "?:",
+// as well as the functions min, max, clamp, abs and if. Comparisons and logical operators
+// result in 1 (true) or 0 (false), any value other than 0 is considered true.
+type Expression struct {
+	source string
+	root   node
+}
+
+// Parse parses the given source into an Expression
+func Parse(source string) (*Expression, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEnd {
+		return nil, fmt.Errorf("unexpected '%s' at position %d", t.text, t.position)
+	}
+
+	return &Expression{
+		source: source,
+		root:   root,
+	}, nil
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Variables returns the sorted names of all variables used in the expression
+func (e *Expression) Variables() []string {
+	names := map[string]bool{}
+	e.root.collectVariables(names)
+
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Evaluate calculates the value of the expression using the given variable values
+func (e *Expression) Evaluate(variables map[string]float64) (float64, error) {
+	result, err := e.root.evaluate(variables)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return 0, fmt.Errorf("expression '%s' does not result in a finite number", e.source)
+	}
+	return result, nil
+}
+
+type node interface {
+	evaluate(variables map[string]float64) (float64, error)
+	collectVariables(names map[string]bool)
+}
+
+type numberNode struct {
+	value float64
+}
+
+func (n numberNode) evaluate(map[string]float64) (float64, error) {
+	return n.value, nil
+}
+
+func (n numberNode) collectVariables(map[string]bool) {}
+
+type variableNode struct {
+	name string
+}
+
+func (n variableNode) evaluate(variables map[string]float64) (float64, error) {
+	value, exists := variables[n.name]
+	if !exists {
+		return 0, fmt.Errorf("unknown variable '%s'", n.name)
+	}
+	return value, nil
+}
+
+func (n variableNode) collectVariables(names map[string]bool) {
+	names[n.name] = true
+}
+
+type unaryNode struct {
+	operator string
+	operand  node
+}
+
+func (n unaryNode) evaluate(variables map[string]float64) (float64, error) {
+	value, err := n.operand.evaluate(variables)
+	if err != nil {
+		return 0, err
+	}
+	if n.operator == "!" {
+		return fromBool(value == 0), nil
+	}
+	return -value, nil
+}
+
+func (n unaryNode) collectVariables(names map[string]bool) {
+	n.operand.collectVariables(names)
+}
+
+type binaryNode struct {
+	operator string
+	left     node
+	right    node
+}
+
+func (n binaryNode) evaluate(variables map[string]float64) (float64, error) {
+	left, err := n.left.evaluate(variables)
+	if err != nil {
+		return 0, err
+	}
+
+	// logical operators only evaluate the right side if necessary
+	switch n.operator {
+	case "&&":
+		if left == 0 {
+			return 0, nil
+		}
+	case "||":
+		if left != 0 {
+			return 1, nil
+		}
+	}
+
+	right, err := n.right.evaluate(variables)
+	if err != nil {
+		return 0, err
+	}
+
+	switch n.operator {
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	case "/":
+		if right == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return left / right, nil
+	case "%":
+		if right == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return math.Mod(left, right), nil
+	case "<":
+		return fromBool(left < right), nil
+	case "<=":
+		return fromBool(left <= right), nil
+	case ">":
+		return fromBool(left > right), nil
+	case ">=":
+		return fromBool(left >= right), nil
+	case "==":
+		return fromBool(left == right), nil
+	case "!=":
+		return fromBool(left != right), nil
+	case "&&", "||":
+		return fromBool(right != 0), nil
+	}
+	return 0, fmt.Errorf("unknown operator '%s'", n.operator)
+}
+
+func (n binaryNode) collectVariables(names map[string]bool) {
+	n.left.collectVariables(names)
+	n.right.collectVariables(names)
+}
+
+type conditionalNode struct {
+	condition node
+	then      node
+	otherwise node
+}
+
+func (n conditionalNode) evaluate(variables map[string]float64) (float64, error) {
+	condition, err := n.condition.evaluate(variables)
+	if err != nil {
+		return 0, err
+	}
+	if condition != 0 {
+		return n.then.evaluate(variables)
+	}
+	return n.otherwise.evaluate(variables)
+}
+
+func (n conditionalNode) collectVariables(names map[string]bool) {
+	n.condition.collectVariables(names)
+	n.then.collectVariables(names)
+	n.otherwise.collectVariables(names)
+}
+
+type callNode struct {
+	function  string
+	arguments []node
+}
+
+func (n callNode) evaluate(variables map[string]float64) (float64, error) {
+	if n.function == "if" {
+		return conditionalNode{
+			condition: n.arguments[0],
+			then:      n.arguments[1],
+			otherwise: n.arguments[2],
+		}.evaluate(variables)
+	}
+
+	values := make([]float64, len(n.arguments))
+	for idx, argument := range n.arguments {
+		value, err := argument.evaluate(variables)
+		if err != nil {
+			return 0, err
+		}
+		values[idx] = value
+	}
+
+	switch n.function {
+	case "min":
+		result := values[0]
+		for _, value := range values[1:] {
+			result = math.Min(result, value)
+		}
+		return result, nil
+	case "max":
+		result := values[0]
+		for _, value := range values[1:] {
+			result = math.Max(result, value)
+		}
+		return result, nil
+	case "clamp":
+		return math.Min(math.Max(values[0], values[1]), values[2]), nil
+	case "abs":
+		return math.Abs(values[0]), nil
+	}
+	return 0, fmt.Errorf("unknown function '%s'", n.function)
+}
+
+func (n callNode) collectVariables(names map[string]bool) {
+	for _, argument := range n.arguments {
+		argument.collectVariables(names)
+	}
+}
+
+func fromBool(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,112 @@
+package expression
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	variables := map[string]float64{
+		"cpu": 60,
+		"gpu": 75,
+	}
+
+	tests := []struct {
+		expression string
+		expected   float64
+	}{
+		{expression: "1 + 2 * 3", expected: 7},
+		{expression: "(1 + 2) * 3", expected: 9},
+		{expression: "10 - 4 - 3", expected: 3},
+		{expression: "-cpu + 100", expected: 40},
+		{expression: "7 % 4", expected: 3},
+		{expression: "max(cpu, gpu - 10) * 1.2", expected: 78},
+		{expression: "min(cpu, gpu, 50)", expected: 50},
+		{expression: "clamp(gpu * 4, 0, 255)", expected: 255},
+		{expression: "abs(cpu - gpu)", expected: 15},
+		{expression: "cpu > 70 ? 255 : 100", expected: 100},
+		{expression: "gpu > 70 ? 255 : cpu > 50 ? 128 : 0", expected: 255},
+		{expression: "if(cpu >= 60 && !(gpu < 70), 1, 2)", expected: 1},
+		{expression: "cpu == 60 || unknown > 0", expected: 1},
+		{expression: "cpu != 60", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			// GIVEN
+			expression, err := Parse(tt.expression)
+			assert.NoError(t, err)
+
+			// WHEN
+			result, err := expression.Evaluate(variables)
+
+			// THEN
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.expected, result, 0.000001)
+		})
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   string
+	}{
+		{expression: "cpu + gpu", expected: "unknown variable 'gpu'"},
+		{expression: "cpu / 0", expected: "division by zero"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			// GIVEN
+			expression, err := Parse(tt.expression)
+			assert.NoError(t, err)
+
+			// WHEN
+			_, err = expression.Evaluate(map[string]float64{"cpu": 60})
+
+			// THEN
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   string
+	}{
+		{expression: "", expected: "unexpected 'end of expression' at position 0"},
+		{expression: "cpu +", expected: "unexpected 'end of expression' at position 5"},
+		{expression: "(cpu", expected: "expected ')' at position 4, got 'end of expression'"},
+		{expression: "cpu $ 2", expected: "unexpected character '$' at position 4"},
+		{expression: "cpu 2", expected: "unexpected '2' at position 4"},
+		{expression: "exec(cpu)", expected: "unknown function 'exec' at position 0"},
+		{expression: "clamp(cpu, 0)", expected: "wrong number of arguments for function 'clamp' at position 0"},
+		{expression: "1.2.3", expected: "invalid number '1.2.3' at position 0"},
+		{expression: "cpu > 1 ? 2", expected: "expected ':' at position 11, got 'end of expression'"},
+		{expression: strings.Repeat("(", 200) + "1" + strings.Repeat(")", 200), expected: "expression is nested too deeply"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			// WHEN
+			_, err := Parse(tt.expression)
+
+			// THEN
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+func TestVariables(t *testing.T) {
+	// GIVEN
+	expression, _ := Parse("max(gpu_temp, cpu_curve) > 50 ? cpu_curve : min(gpu_temp, 10)")
+
+	// WHEN
+	result := expression.Variables()
+
+	// THEN
+	assert.Equal(t, []string{"cpu_curve", "gpu_temp"}, result)
+}
@@ -0,0 +1,253 @@
+package expression
+
+import (
+	"fmt"
+	"github.com/markusressel/fan2go/internal/util"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxDepth limits the nesting of an expression, to protect against stack exhaustion
+const maxDepth = 100
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenNumber
+	tokenIdentifier
+	tokenOperator
+)
+
+type token struct {
+	kind     tokenKind
+	text     string
+	position int
+}
+
+// functions maps the name of each supported function to its min and max number of arguments (-1 = unlimited)
+var functions = map[string][2]int{
+	"min":   {1, -1},
+	"max":   {1, -1},
+	"clamp": {3, 3},
+	"abs":   {1, 1},
+	"if":    {3, 3},
+}
+
+var twoCharOperators = []string{"<=", ">=", "==", "!=", "&&", "||"}
+
+const singleCharOperators = "+-*/%<>!?:(),"
+
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), position: start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdentifier, text: string(runes[start:i]), position: start})
+		default:
+			if i+1 < len(runes) && util.ContainsString(twoCharOperators, string(runes[i:i+2])) {
+				tokens = append(tokens, token{kind: tokenOperator, text: string(runes[i : i+2]), position: i})
+				i += 2
+			} else if strings.ContainsRune(singleCharOperators, r) {
+				tokens = append(tokens, token{kind: tokenOperator, text: string(r), position: i})
+				i++
+			} else {
+				return nil, fmt.Errorf("unexpected character '%c' at position %d", r, i)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEnd, text: "end of expression", position: len(runes)}), nil
+}
+
+// parser is a recursive descent parser, each parse function handles one level of operator precedence
+type parser struct {
+	tokens   []token
+	position int
+	depth    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.position]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.position]
+	if t.kind != tokenEnd {
+		p.position++
+	}
+	return t
+}
+
+// accept consumes the next token if it is one of the given operators
+func (p *parser) accept(operators ...string) (string, bool) {
+	t := p.peek()
+	if t.kind == tokenOperator && util.ContainsString(operators, t.text) {
+		p.next()
+		return t.text, true
+	}
+	return "", false
+}
+
+func (p *parser) expect(operator string) error {
+	if _, ok := p.accept(operator); !ok {
+		t := p.peek()
+		return fmt.Errorf("expected '%s' at position %d, got '%s'", operator, t.position, t.text)
+	}
+	return nil
+}
+
+func (p *parser) parseExpression() (node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, fmt.Errorf("expression is nested too deeply")
+	}
+
+	condition, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("?"); !ok {
+		return condition, nil
+	}
+
+	then, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	return conditionalNode{condition: condition, then: then, otherwise: otherwise}, nil
+}
+
+// binaryPrecedence lists all binary operators, from the lowest to the highest precedence
+var binaryPrecedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) parseBinary(level int) (node, error) {
+	if level >= len(binaryPrecedence) {
+		return p.parseUnary()
+	}
+
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := p.accept(binaryPrecedence[level]...)
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{operator: operator, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	operator, ok := p.accept("-", "+", "!")
+	if !ok {
+		return p.parsePrimary()
+	}
+
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, fmt.Errorf("expression is nested too deeply")
+	}
+
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if operator == "+" {
+		return operand, nil
+	}
+	return unaryNode{operator: operator, operand: operand}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		value, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s' at position %d", t.text, t.position)
+		}
+		return numberNode{value: value}, nil
+	case tokenIdentifier:
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(t)
+		}
+		return variableNode{name: t.text}, nil
+	case tokenOperator:
+		if t.text == "(" {
+			inner, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected '%s' at position %d", t.text, t.position)
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	arity, exists := functions[name.text]
+	if !exists {
+		return nil, fmt.Errorf("unknown function '%s' at position %d", name.text, name.position)
+	}
+
+	var arguments []node
+	if _, ok := p.accept(")"); !ok {
+		for {
+			argument, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			arguments = append(arguments, argument)
+			if _, ok := p.accept(","); !ok {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(arguments) < arity[0] || (arity[1] >= 0 && len(arguments) > arity[1]) {
+		return nil, fmt.Errorf("wrong number of arguments for function '%s' at position %d", name.text, name.position)
+	}
+	return callNode{function: name.text, arguments: arguments}, nil
+}
@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/controller"
+	"github.com/markusressel/fan2go/internal/ui"
+	"github.com/markusressel/fan2go/internal/util"
+	"golang.org/x/exp/slices"
+	"math"
+	"time"
+)
+
+const failsafeCommandTimeout = 30 * time.Second
+
+// failsafeMonitor sets all fans to max speed while any sensor exceeds the critical temperature
+type failsafeMonitor struct {
+	config      configuration.FailsafeConfig
+	pollingRate time.Duration
+	active      bool
+	// runs the configured exec command when the failsafe is triggered
+	runCommand func(config configuration.ExecConfig)
+}
+
+func newFailsafeMonitor(config configuration.FailsafeConfig, pollingRate time.Duration) *failsafeMonitor {
+	return &failsafeMonitor{
+		config:      config,
+		pollingRate: pollingRate,
+		runCommand:  runFailsafeCommand,
+	}
+}
+
+func (m *failsafeMonitor) Run(ctx context.Context) error {
+	tick := time.Tick(m.pollingRate)
+	for {
+		select {
+		case <-ctx.Done():
+			ui.Info("Stopping failsafe monitor...")
+			return nil
+		case <-tick:
+			m.check(controller.GetSnapshot().Sensors)
+		}
+	}
+}
+
+// check compares the hottest monitored sensor of the given sensor states against the critical and recovery temperature
+func (m *failsafeMonitor) check(sensors map[string]controller.SensorState) {
+	sensorId, temp := m.getHottestSensor(sensors)
+
+	if !m.active && temp >= m.config.CriticalTemp {
+		m.active = true
+		controller.SetFailsafeActive(true)
+
+		message := fmt.Sprintf("Sensor %s reached %.1f°C (critical: %.1f°C), setting all fans to max speed", sensorId, temp, m.config.CriticalTemp)
+		ui.Error("%s", message)
+		ui.NotifyError("Critical Temperature", message)
+
+		if m.config.Exec != nil {
+			go m.runCommand(*m.config.Exec)
+		}
+	} else if m.active && temp < m.config.RecoveryTemp {
+		m.active = false
+		controller.SetFailsafeActive(false)
+
+		message := fmt.Sprintf("All sensors are below %.1f°C again, resuming normal fan control", m.config.RecoveryTemp)
+		ui.Warning("%s", message)
+		ui.NotifyWarn("Temperature Recovered", message)
+	}
+}
+
+// getHottestSensor returns the id and value (in °C) of the monitored sensor with the highest temperature
+func (m *failsafeMonitor) getHottestSensor(sensors map[string]controller.SensorState) (sensorId string, temp float64) {
+	temp = math.Inf(-1)
+	for id, sensor := range sensors {
+		if len(m.config.Sensors) > 0 && !slices.Contains(m.config.Sensors, id) {
+			continue
+		}
+		value := sensor.MovingAvg / 1000
+		if value > temp {
+			sensorId = id
+			temp = value
+		}
+	}
+	return sensorId, temp
+}
+
+func runFailsafeCommand(config configuration.ExecConfig) {
+	ui.Info("Running failsafe command: %s", config.Exec)
+	_, err := util.SafeCmdExecution(config.Exec, config.Args, failsafeCommandTimeout)
+	if err != nil {
+		ui.ErrorAndNotify("Failsafe Command Error", "Unable to run failsafe command %s: %v", config.Exec, err)
+	}
+}
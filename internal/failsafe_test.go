@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/controller"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// sensorStates creates the sensor states of a snapshot with the given temperatures (in °C), by sensor id
+func sensorStates(temps map[string]float64) map[string]controller.SensorState {
+	result := map[string]controller.SensorState{}
+	for id, temp := range temps {
+		result[id] = controller.SensorState{
+			Config:    configuration.SensorConfig{ID: id},
+			Value:     temp * 1000,
+			MovingAvg: temp * 1000,
+		}
+	}
+	return result
+}
+
+func TestFailsafeMonitorCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		sensors []string
+		// temperatures of consecutive checks
+		steps []map[string]float64
+		// expected failsafe state after each check
+		expected []bool
+		// expected number of times the exec command is run
+		expectedExecs int
+	}{
+		{
+			name:          "below critical",
+			steps:         []map[string]float64{{"cpu": 60, "gpu": 70}},
+			expected:      []bool{false},
+			expectedExecs: 0,
+		},
+		{
+			name:          "crossing critical",
+			steps:         []map[string]float64{{"cpu": 60, "gpu": 70}, {"cpu": 60, "gpu": 90}},
+			expected:      []bool{false, true},
+			expectedExecs: 1,
+		},
+		{
+			name: "between recovery and critical",
+			steps: []map[string]float64{
+				{"cpu": 90, "gpu": 70},
+				{"cpu": 80, "gpu": 70},
+				{"cpu": 75, "gpu": 70},
+			},
+			expected:      []bool{true, true, true},
+			expectedExecs: 1,
+		},
+		{
+			name: "below recovery",
+			steps: []map[string]float64{
+				{"cpu": 90, "gpu": 70},
+				{"cpu": 74, "gpu": 70},
+				{"cpu": 74, "gpu": 80},
+				{"cpu": 90, "gpu": 70},
+			},
+			expected:      []bool{true, false, false, true},
+			expectedExecs: 2,
+		},
+		{
+			name:          "unmonitored sensor",
+			sensors:       []string{"cpu"},
+			steps:         []map[string]float64{{"cpu": 60, "gpu": 100}},
+			expected:      []bool{false},
+			expectedExecs: 0,
+		},
+		{
+			name:          "monitored sensor",
+			sensors:       []string{"cpu"},
+			steps:         []map[string]float64{{"cpu": 85, "gpu": 60}},
+			expected:      []bool{true},
+			expectedExecs: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			controller.SetFailsafeActive(false)
+			defer controller.SetFailsafeActive(false)
+
+			execs := make(chan configuration.ExecConfig, len(tt.steps))
+			m := newFailsafeMonitor(configuration.FailsafeConfig{
+				CriticalTemp: 85,
+				RecoveryTemp: 75,
+				Sensors:      tt.sensors,
+				Exec:         &configuration.ExecConfig{Exec: "/usr/bin/true"},
+			}, time.Second)
+			m.runCommand = func(config configuration.ExecConfig) {
+				execs <- config
+			}
+
+			for i, step := range tt.steps {
+				// WHEN
+				m.check(sensorStates(step))
+
+				// THEN
+				assert.Equal(t, tt.expected[i], m.active, "step %d", i)
+				assert.Equal(t, tt.expected[i], controller.IsFailsafeActive(), "step %d", i)
+			}
+
+			for i := 0; i < tt.expectedExecs; i++ {
+				select {
+				case config := <-execs:
+					assert.Equal(t, "/usr/bin/true", config.Exec)
+				case <-time.After(time.Second):
+					assert.Fail(t, "exec command was not run")
+				}
+			}
+			assert.Empty(t, execs)
+		})
+	}
+}
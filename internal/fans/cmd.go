@@ -133,6 +133,10 @@ func (fan CmdFan) GetCurveId() string {
 	return fan.Config.Curve
 }
 
+func (fan CmdFan) GetConfig() configuration.FanConfig {
+	return fan.Config
+}
+
 func (fan CmdFan) ShouldNeverStop() bool {
 	return fan.Config.NeverStop
 }
@@ -3,7 +3,9 @@ package fans
 import (
 	"fmt"
 	"github.com/markusressel/fan2go/internal/configuration"
+	"math"
 	"sort"
+	"sync"
 )
 
 const (
@@ -30,12 +32,15 @@ const (
 )
 
 var (
-	FanMap = map[string]Fan{}
+	FanMap      = map[string]Fan{}
+	fanMapMutex sync.RWMutex
 )
 
 type Fan interface {
 	GetId() string
 
+	GetConfig() configuration.FanConfig
+
 	// GetMinPwm returns the lowest PWM value where the fans are still spinning, when spinning previously
 	GetMinPwm() int
 	SetMinPwm(pwm int, force bool)
@@ -103,6 +108,39 @@ func NewFan(config configuration.FanConfig) (Fan, error) {
 	return nil, fmt.Errorf("no matching fan type for fan: %s", config.ID)
 }
 
+// GetFan returns the fan with the given id
+func GetFan(id string) (Fan, bool) {
+	fanMapMutex.RLock()
+	defer fanMapMutex.RUnlock()
+	fan, exists := FanMap[id]
+	return fan, exists
+}
+
+// RegisterFan adds the given fan to the FanMap, replacing any existing fan with the same id
+func RegisterFan(fan Fan) {
+	fanMapMutex.Lock()
+	defer fanMapMutex.Unlock()
+	FanMap[fan.GetId()] = fan
+}
+
+// RemoveFan removes the fan with the given id from the FanMap
+func RemoveFan(id string) {
+	fanMapMutex.Lock()
+	defer fanMapMutex.Unlock()
+	delete(FanMap, id)
+}
+
+// SnapshotFanMap returns a copy of the FanMap
+func SnapshotFanMap() map[string]Fan {
+	fanMapMutex.RLock()
+	defer fanMapMutex.RUnlock()
+	result := make(map[string]Fan, len(FanMap))
+	for id, fan := range FanMap {
+		result[id] = fan
+	}
+	return result
+}
+
 // ComputePwmBoundaries calculates the startPwm and maxPwm values for a fan based on its fan curve data
 func ComputePwmBoundaries(fan Fan) (startPwm int, maxPwm int) {
 	userStartPwm := fan.GetStartPwm()
@@ -135,3 +173,56 @@ func ComputePwmBoundaries(fan Fan) (startPwm int, maxPwm int) {
 
 	return startPwm, maxPwm
 }
+
+// GetMaxRpm returns the highest RPM value within the fan curve data of the given fan
+func GetMaxRpm(fan Fan) (maxRpm float64) {
+	pwmRpmMap := fan.GetFanCurveData()
+	if pwmRpmMap == nil {
+		return 0
+	}
+
+	for _, rpm := range *pwmRpmMap {
+		if rpm > maxRpm {
+			maxRpm = rpm
+		}
+	}
+	return maxRpm
+}
+
+// ComputePwmForRpm calculates the lowest PWM value within [minPwm..maxPwm] at which the given fan reaches
+// the given RPM value, by inverting its fan curve data. Returns maxPwm if the RPM value is not reachable.
+func ComputePwmForRpm(fan Fan, rpm float64, minPwm int, maxPwm int) int {
+	pwmRpmMap := fan.GetFanCurveData()
+	if pwmRpmMap == nil {
+		return maxPwm
+	}
+
+	var keys []int
+	for pwm := range *pwmRpmMap {
+		if pwm >= minPwm && pwm <= maxPwm {
+			keys = append(keys, pwm)
+		}
+	}
+	sort.Ints(keys)
+
+	for i, pwm := range keys {
+		currentRpm := (*pwmRpmMap)[pwm]
+		if currentRpm < rpm {
+			continue
+		}
+		if i == 0 {
+			return pwm
+		}
+
+		// interpolate between the measured points, since the data might be sparse
+		previousPwm := keys[i-1]
+		previousRpm := (*pwmRpmMap)[previousPwm]
+		if currentRpm <= previousRpm {
+			return pwm
+		}
+		ratio := (rpm - previousRpm) / (currentRpm - previousRpm)
+		return previousPwm + int(math.Ceil(ratio*float64(pwm-previousPwm)))
+	}
+
+	return maxPwm
+}
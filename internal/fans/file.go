@@ -114,6 +114,10 @@ func (fan FileFan) GetCurveId() string {
 	return fan.Config.Curve
 }
 
+func (fan FileFan) GetConfig() configuration.FanConfig {
+	return fan.Config
+}
+
 func (fan FileFan) ShouldNeverStop() bool {
 	return fan.Config.NeverStop
 }
@@ -128,7 +128,8 @@ func (fan *HwMonFan) AttachFanCurveData(curveData *map[int]float64) (err error)
 	fan.SetStartPwm(startPwm, false)
 	fan.SetMaxPwm(maxPwm, false)
 
-	// TODO: we don't have a way to determine this yet
+	// the lowest pwm at which a spinning fan keeps spinning is measured separately during initialization,
+	// until then the start pwm is the safe choice
 	fan.SetMinPwm(startPwm, false)
 
 	return err
@@ -138,6 +139,10 @@ func (fan HwMonFan) GetCurveId() string {
 	return fan.Config.Curve
 }
 
+func (fan HwMonFan) GetConfig() configuration.FanConfig {
+	return fan.Config
+}
+
 func (fan HwMonFan) ShouldNeverStop() bool {
 	return fan.Config.NeverStop
 }
@@ -99,3 +99,29 @@ func TestHwMonFan_SetMaxPwm(t *testing.T) {
 	// THEN
 	assert.Equal(t, expected, maxPwm)
 }
+
+func TestHwMonFan_ComputePwmForRpm(t *testing.T) {
+	// GIVEN
+	fan := HwMonFan{
+		FanCurveData: &map[int]float64{
+			0:   0,
+			50:  1000,
+			100: 1500,
+			255: 2000,
+		},
+	}
+
+	// WHEN
+	maxRpm := GetMaxRpm(&fan)
+	exact := ComputePwmForRpm(&fan, 1000, 0, 255)
+	interpolated := ComputePwmForRpm(&fan, 1250, 0, 255)
+	belowMin := ComputePwmForRpm(&fan, 0, 30, 255)
+	unreachable := ComputePwmForRpm(&fan, 1800, 0, 100)
+
+	// THEN
+	assert.Equal(t, 2000.0, maxRpm)
+	assert.Equal(t, 50, exact)
+	assert.Equal(t, 75, interpolated)
+	assert.Equal(t, 50, belowMin)
+	assert.Equal(t, 100, unreachable)
+}
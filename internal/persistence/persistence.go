@@ -3,6 +3,7 @@ package persistence
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/markusressel/fan2go/internal/configuration"
 	"github.com/markusressel/fan2go/internal/fans"
 	"github.com/markusressel/fan2go/internal/ui"
 	bolt "go.etcd.io/bbolt"
@@ -11,10 +12,33 @@ import (
 )
 
 const (
-	BucketFans      = "fans"
-	BucketFanPwmMap = "fanPwmMap"
+	BucketFans           = "fans"
+	BucketFanPwmMap      = "fanPwmMap"
+	BucketFanControlLoop = "fanControlLoop"
+	BucketFanBoundaries  = "fanPwmBoundaries"
+	// BucketFanInitialization contains the checkpoints of unfinished initialization sequences
+	BucketFanInitialization = "fanInitialization"
 )
 
+// FanPwmBoundaries are the pwm values of a fan learned during initialization and at runtime
+type FanPwmBoundaries struct {
+	// StartPwm is the lowest pwm at which the fan starts to rotate from a stand still
+	StartPwm int `json:"startPwm"`
+	// MinPwm is the lowest pwm at which the fan keeps rotating, when it was rotating before
+	MinPwm int `json:"minPwm"`
+	// MinPwmOffset is the amount MinPwm was increased by at runtime, to prevent the fan from stopping
+	MinPwmOffset int `json:"minPwmOffset"`
+}
+
+// FanInitializationCheckpoint contains the measurements of an unfinished initialization sequence of a fan,
+// so it can be resumed later on
+type FanInitializationCheckpoint struct {
+	// CurveData contains the RPM measured so far for each pwm value
+	CurveData map[int]float64 `json:"curveData"`
+	// MinPwm is the measured min pwm of the fan, nil if it has not been measured yet
+	MinPwm *int `json:"minPwm,omitempty"`
+}
+
 type Persistence interface {
 	LoadFanPwmData(fan fans.Fan) (map[int]float64, error)
 	SaveFanPwmData(fan fans.Fan) (err error)
@@ -23,6 +47,18 @@ type Persistence interface {
 	LoadFanPwmMap(fanId string) (map[int]int, error)
 	SaveFanPwmMap(fanId string, pwmMap map[int]int) (err error)
 	DeleteFanPwmMap(fanId string) (err error)
+
+	LoadFanControlLoop(fanId string) (configuration.ControlLoopConfig, error)
+	SaveFanControlLoop(fanId string, config configuration.ControlLoopConfig) (err error)
+	DeleteFanControlLoop(fanId string) (err error)
+
+	LoadFanPwmBoundaries(fanId string) (FanPwmBoundaries, error)
+	SaveFanPwmBoundaries(fanId string, boundaries FanPwmBoundaries) (err error)
+	DeleteFanPwmBoundaries(fanId string) (err error)
+
+	LoadFanInitializationCheckpoint(fanId string) (FanInitializationCheckpoint, error)
+	SaveFanInitializationCheckpoint(fanId string, checkpoint FanInitializationCheckpoint) (err error)
+	DeleteFanInitializationCheckpoint(fanId string) (err error)
 }
 
 type persistence struct {
@@ -230,3 +266,192 @@ func (p persistence) DeleteFanPwmMap(fanId string) error {
 		return b.Delete([]byte(key))
 	})
 }
+
+// SaveFanControlLoop saves the control loop constants measured for the given fan to persistence
+func (p persistence) SaveFanControlLoop(fanId string, config configuration.ControlLoopConfig) (err error) {
+	db, err := p.openPersistence()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(BucketFanControlLoop))
+		if err != nil {
+			return fmt.Errorf("create bucket: %s", err)
+		}
+		return b.Put([]byte(fanId), data)
+	})
+}
+
+// LoadFanControlLoop loads the control loop constants measured for the given fan from persistence
+func (p persistence) LoadFanControlLoop(fanId string) (configuration.ControlLoopConfig, error) {
+	db, err := p.openPersistence()
+	if err != nil {
+		return configuration.ControlLoopConfig{}, err
+	}
+	defer db.Close()
+
+	var config configuration.ControlLoopConfig
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(BucketFanControlLoop))
+		if b == nil {
+			return os.ErrNotExist
+		}
+		v := b.Get([]byte(fanId))
+		if v == nil {
+			return os.ErrNotExist
+		}
+		return json.Unmarshal(v, &config)
+	})
+
+	return config, err
+}
+
+func (p persistence) DeleteFanControlLoop(fanId string) error {
+	db, err := p.openPersistence()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(BucketFanControlLoop))
+		if b == nil {
+			// no fan bucket yet
+			return nil
+		}
+		return b.Delete([]byte(fanId))
+	})
+}
+
+// SaveFanPwmBoundaries saves the learned pwm boundaries of the given fan to persistence
+func (p persistence) SaveFanPwmBoundaries(fanId string, boundaries FanPwmBoundaries) (err error) {
+	db, err := p.openPersistence()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	data, err := json.Marshal(boundaries)
+	if err != nil {
+		return err
+	}
+
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(BucketFanBoundaries))
+		if err != nil {
+			return fmt.Errorf("create bucket: %s", err)
+		}
+		return b.Put([]byte(fanId), data)
+	})
+}
+
+// LoadFanPwmBoundaries loads the learned pwm boundaries of the given fan from persistence
+func (p persistence) LoadFanPwmBoundaries(fanId string) (FanPwmBoundaries, error) {
+	db, err := p.openPersistence()
+	if err != nil {
+		return FanPwmBoundaries{}, err
+	}
+	defer db.Close()
+
+	var boundaries FanPwmBoundaries
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(BucketFanBoundaries))
+		if b == nil {
+			return os.ErrNotExist
+		}
+		v := b.Get([]byte(fanId))
+		if v == nil {
+			return os.ErrNotExist
+		}
+		return json.Unmarshal(v, &boundaries)
+	})
+
+	return boundaries, err
+}
+
+func (p persistence) DeleteFanPwmBoundaries(fanId string) error {
+	db, err := p.openPersistence()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(BucketFanBoundaries))
+		if b == nil {
+			// no fan bucket yet
+			return nil
+		}
+		return b.Delete([]byte(fanId))
+	})
+}
+
+// SaveFanInitializationCheckpoint saves the measurements of an unfinished initialization sequence of the given fan
+func (p persistence) SaveFanInitializationCheckpoint(fanId string, checkpoint FanInitializationCheckpoint) (err error) {
+	db, err := p.openPersistence()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(BucketFanInitialization))
+		if err != nil {
+			return fmt.Errorf("create bucket: %s", err)
+		}
+		return b.Put([]byte(fanId), data)
+	})
+}
+
+// LoadFanInitializationCheckpoint loads the measurements of an unfinished initialization sequence of the given fan
+func (p persistence) LoadFanInitializationCheckpoint(fanId string) (FanInitializationCheckpoint, error) {
+	db, err := p.openPersistence()
+	if err != nil {
+		return FanInitializationCheckpoint{}, err
+	}
+	defer db.Close()
+
+	var checkpoint FanInitializationCheckpoint
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(BucketFanInitialization))
+		if b == nil {
+			return os.ErrNotExist
+		}
+		v := b.Get([]byte(fanId))
+		if v == nil {
+			return os.ErrNotExist
+		}
+		return json.Unmarshal(v, &checkpoint)
+	})
+
+	return checkpoint, err
+}
+
+func (p persistence) DeleteFanInitializationCheckpoint(fanId string) error {
+	db, err := p.openPersistence()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(BucketFanInitialization))
+		if b == nil {
+			// no fan bucket yet
+			return nil
+		}
+		return b.Delete([]byte(fanId))
+	})
+}
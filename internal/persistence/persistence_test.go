@@ -5,12 +5,14 @@ import (
 	"github.com/markusressel/fan2go/internal/fans"
 	"github.com/markusressel/fan2go/internal/util"
 	"github.com/stretchr/testify/assert"
+	"path/filepath"
 	"testing"
 )
 
-const (
-	dbTestingPath = "./test.db"
-)
+// dbTestingPath returns the path of a database in a temporary directory, which is removed after the test
+func dbTestingPath(t *testing.T) string {
+	return filepath.Join(t.TempDir(), "test.db")
+}
 
 var (
 	LinearFan = map[int]float64{
@@ -27,7 +29,7 @@ var (
 
 func TestPersistence_DeleteFanPwmData(t *testing.T) {
 	// GIVEN
-	p := NewPersistence(dbTestingPath)
+	p := NewPersistence(dbTestingPath(t))
 	fan, _ := createFan(false, LinearFan)
 	err := p.SaveFanPwmData(fan)
 
@@ -41,9 +43,82 @@ func TestPersistence_DeleteFanPwmData(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestPersistence_FanControlLoop(t *testing.T) {
+	// GIVEN
+	p := NewPersistence(dbTestingPath(t))
+	expected := configuration.ControlLoopConfig{P: 0.03, I: 0.002, D: 0}
+
+	// WHEN
+	err := p.SaveFanControlLoop("fan", expected)
+	assert.NoError(t, err)
+	result, err := p.LoadFanControlLoop("fan")
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	// WHEN
+	err = p.DeleteFanControlLoop("fan")
+	assert.NoError(t, err)
+	_, err = p.LoadFanControlLoop("fan")
+
+	// THEN
+	assert.Error(t, err)
+}
+
+func TestPersistence_FanPwmBoundaries(t *testing.T) {
+	// GIVEN
+	p := NewPersistence(dbTestingPath(t))
+	expected := FanPwmBoundaries{StartPwm: 50, MinPwm: 30, MinPwmOffset: 2}
+
+	// WHEN
+	err := p.SaveFanPwmBoundaries("fan", expected)
+	assert.NoError(t, err)
+	result, err := p.LoadFanPwmBoundaries("fan")
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	// WHEN
+	err = p.DeleteFanPwmBoundaries("fan")
+	assert.NoError(t, err)
+	_, err = p.LoadFanPwmBoundaries("fan")
+
+	// THEN
+	assert.Error(t, err)
+}
+
+func TestPersistence_FanInitializationCheckpoint(t *testing.T) {
+	// GIVEN
+	p := NewPersistence(dbTestingPath(t))
+	minPwm := 30
+	expected := FanInitializationCheckpoint{
+		CurveData: map[int]float64{0: 0, 50: 800, 100: 1400},
+		MinPwm:    &minPwm,
+	}
+
+	// WHEN
+	err := p.SaveFanInitializationCheckpoint("fan", expected)
+	assert.NoError(t, err)
+	result, err := p.LoadFanInitializationCheckpoint("fan")
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	// WHEN
+	err = p.DeleteFanInitializationCheckpoint("fan")
+	assert.NoError(t, err)
+	_, err = p.LoadFanInitializationCheckpoint("fan")
+
+	// THEN
+	assert.Error(t, err)
+}
+
 func TestPersistence_SaveFanPwmData_LinearFanInterpolated(t *testing.T) {
 	// GIVEN
-	p := NewPersistence(dbTestingPath)
+	p := NewPersistence(dbTestingPath(t))
 
 	expected := util.InterpolateLinearly(&LinearFan, 0, 255)
 	fan, _ := createFan(false, expected)
@@ -57,7 +132,7 @@ func TestPersistence_SaveFanPwmData_LinearFanInterpolated(t *testing.T) {
 
 func TestPersistence_LoadFanPwmData_LinearFanInterpolated(t *testing.T) {
 	// GIVEN
-	persistence := NewPersistence(dbTestingPath)
+	persistence := NewPersistence(dbTestingPath(t))
 
 	expected := util.InterpolateLinearly(&LinearFan, 0, 255)
 	fan, _ := createFan(false, expected)
@@ -76,7 +151,7 @@ func TestPersistence_LoadFanPwmData_LinearFanInterpolated(t *testing.T) {
 
 func TestPersistence_SaveFanPwmData_SamplesNotInterpolated(t *testing.T) {
 	// GIVEN
-	p := NewPersistence(dbTestingPath)
+	p := NewPersistence(dbTestingPath(t))
 
 	expected := NeverStoppingFan
 	fan, _ := createFan(false, expected)
@@ -90,7 +165,7 @@ func TestPersistence_SaveFanPwmData_SamplesNotInterpolated(t *testing.T) {
 
 func TestPersistence_LoadFanPwmData_SamplesNotInterpolated(t *testing.T) {
 	// GIVEN
-	persistence := NewPersistence(dbTestingPath)
+	persistence := NewPersistence(dbTestingPath(t))
 
 	expected := NeverStoppingFan
 	fan, _ := createFan(false, expected)
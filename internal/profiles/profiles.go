@@ -0,0 +1,215 @@
+package profiles
+
+import (
+	"fmt"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"sync"
+	"time"
+)
+
+type scheduleEntry struct {
+	profile string
+	from    time.Duration
+	to      time.Duration
+	// weekdays on which the time frame starts, all days if empty
+	days map[time.Weekday]bool
+}
+
+var (
+	mutex    sync.RWMutex
+	profiles = map[string]configuration.ProfileConfig{}
+	schedule []scheduleEntry
+	// triggers of all profiles that are activated automatically, in the order of their definition
+	triggers []*trigger
+	// forced is the id of the profile which was selected manually, empty if none
+	forced string
+	// triggered is the id of the profile activated by its trigger, empty if none
+	triggered string
+	// scheduled is the id of the profile selected by the schedule, empty if none
+	scheduled string
+)
+
+// SetConfig replaces the known profiles and the schedule,
+// a forced profile that no longer exists is cleared
+func SetConfig(profileConfigs []configuration.ProfileConfig, scheduleConfigs []configuration.ScheduleConfig) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	lastMatches := map[string]time.Time{}
+	for _, t := range triggers {
+		lastMatches[t.profile] = t.lastMatch
+	}
+
+	profiles = map[string]configuration.ProfileConfig{}
+	triggers = nil
+	for _, profileConfig := range profileConfigs {
+		profiles[profileConfig.ID] = profileConfig
+		if profileConfig.Trigger != nil {
+			triggers = append(triggers, &trigger{
+				profile:   profileConfig.ID,
+				config:    *profileConfig.Trigger,
+				lastMatch: lastMatches[profileConfig.ID],
+			})
+		}
+	}
+
+	schedule = nil
+	for _, scheduleConfig := range scheduleConfigs {
+		entry, err := newScheduleEntry(scheduleConfig)
+		if err != nil {
+			continue
+		}
+		schedule = append(schedule, entry)
+	}
+
+	if _, exists := profiles[forced]; !exists {
+		forced = ""
+	}
+	if _, exists := profiles[triggered]; !exists {
+		triggered = ""
+	}
+	if _, exists := profiles[scheduled]; !exists {
+		scheduled = ""
+	}
+}
+
+func newScheduleEntry(config configuration.ScheduleConfig) (scheduleEntry, error) {
+	from, err := configuration.ParseTimeOfDay(config.From)
+	if err != nil {
+		return scheduleEntry{}, err
+	}
+	to, err := configuration.ParseTimeOfDay(config.To)
+	if err != nil {
+		return scheduleEntry{}, err
+	}
+	days := map[time.Weekday]bool{}
+	for _, day := range config.Days {
+		weekday, err := configuration.ParseWeekday(day)
+		if err != nil {
+			return scheduleEntry{}, err
+		}
+		days[weekday] = true
+	}
+	return scheduleEntry{
+		profile: config.Profile,
+		from:    from,
+		to:      to,
+		days:    days,
+	}, nil
+}
+
+// GetProfiles returns the configs of all known profiles
+func GetProfiles() []configuration.ProfileConfig {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	result := make([]configuration.ProfileConfig, 0, len(profiles))
+	for _, profile := range profiles {
+		result = append(result, profile)
+	}
+	return result
+}
+
+// Force activates the profile with the given id regardless of triggers and the schedule
+func Force(id string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if _, exists := profiles[id]; !exists {
+		return fmt.Errorf("no profile with id '%s' found", id)
+	}
+	forced = id
+	return nil
+}
+
+// ClearForced hands control over the active profile back to the triggers and the schedule
+func ClearForced() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	forced = ""
+}
+
+// GetForcedProfile returns the id of the manually selected profile, empty if none
+func GetForcedProfile() string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return forced
+}
+
+// GetTriggeredProfile returns the id of the profile activated by its trigger, empty if none
+func GetTriggeredProfile() string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return triggered
+}
+
+// GetActiveProfile returns the id of the currently active profile, empty if none
+func GetActiveProfile() string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return getActiveProfile()
+}
+
+func getActiveProfile() string {
+	if len(forced) > 0 {
+		return forced
+	}
+	if len(triggered) > 0 {
+		return triggered
+	}
+	return scheduled
+}
+
+// Update evaluates the triggers and the schedule at the given time and returns true if the active profile has changed
+func Update(now time.Time) bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	previous := getActiveProfile()
+	triggered = evaluateTriggers(now)
+	scheduled = ""
+	for _, entry := range schedule {
+		if entry.matches(now) {
+			scheduled = entry.profile
+			break
+		}
+	}
+	return previous != getActiveProfile()
+}
+
+// GetCurveId returns the id of the curve the fan with the given id should use with the active profile,
+// or defaultCurveId if the active profile does not define a curve for this fan
+func GetCurveId(fanId string, defaultCurveId string) string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	profile, exists := profiles[getActiveProfile()]
+	if !exists {
+		return defaultCurveId
+	}
+	for _, profileFan := range profile.Fans {
+		if profileFan.Fan == fanId {
+			return profileFan.Curve
+		}
+	}
+	return defaultCurveId
+}
+
+func (e scheduleEntry) matches(now time.Time) bool {
+	timeOfDay := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute
+	today := now.Weekday()
+
+	if e.from == e.to {
+		return e.isActiveOn(today)
+	}
+	if e.from < e.to {
+		return e.isActiveOn(today) && timeOfDay >= e.from && timeOfDay < e.to
+	}
+
+	// the time frame spans midnight, so the early part of it belongs to the previous day
+	yesterday := (today + 6) % 7
+	return (e.isActiveOn(today) && timeOfDay >= e.from) ||
+		(e.isActiveOn(yesterday) && timeOfDay < e.to)
+}
+
+func (e scheduleEntry) isActiveOn(day time.Weekday) bool {
+	return len(e.days) <= 0 || e.days[day]
+}
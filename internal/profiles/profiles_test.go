@@ -0,0 +1,111 @@
+package profiles
+
+import (
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var testProfiles = []configuration.ProfileConfig{
+	{
+		ID: "silent",
+		Fans: []configuration.ProfileFanConfig{
+			{Fan: "cpu_fan", Curve: "silent_curve"},
+		},
+	},
+	{
+		ID: "performance",
+		Fans: []configuration.ProfileFanConfig{
+			{Fan: "cpu_fan", Curve: "performance_curve"},
+		},
+	},
+}
+
+func TestUpdateSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule []configuration.ScheduleConfig
+		now      time.Time
+		expected string
+	}{
+		{
+			name:     "inside time frame",
+			schedule: []configuration.ScheduleConfig{{Profile: "performance", From: "08:00", To: "18:00"}},
+			// Wednesday
+			now:      time.Date(2022, 6, 1, 12, 0, 0, 0, time.Local),
+			expected: "performance",
+		},
+		{
+			name:     "outside time frame",
+			schedule: []configuration.ScheduleConfig{{Profile: "performance", From: "08:00", To: "18:00"}},
+			now:      time.Date(2022, 6, 1, 18, 0, 0, 0, time.Local),
+			expected: "",
+		},
+		{
+			name:     "other weekday",
+			schedule: []configuration.ScheduleConfig{{Profile: "performance", From: "08:00", To: "18:00", Days: []string{"mon", "tue"}}},
+			now:      time.Date(2022, 6, 1, 12, 0, 0, 0, time.Local),
+			expected: "",
+		},
+		{
+			name:     "across midnight, after midnight",
+			schedule: []configuration.ScheduleConfig{{Profile: "silent", From: "22:00", To: "07:00", Days: []string{"tue"}}},
+			now:      time.Date(2022, 6, 1, 3, 0, 0, 0, time.Local),
+			expected: "silent",
+		},
+		{
+			name:     "across midnight, before midnight on other weekday",
+			schedule: []configuration.ScheduleConfig{{Profile: "silent", From: "22:00", To: "07:00", Days: []string{"tue"}}},
+			now:      time.Date(2022, 6, 1, 23, 0, 0, 0, time.Local),
+			expected: "",
+		},
+		{
+			name: "first matching entry wins",
+			schedule: []configuration.ScheduleConfig{
+				{Profile: "silent", From: "10:00", To: "14:00"},
+				{Profile: "performance", From: "08:00", To: "18:00"},
+			},
+			now:      time.Date(2022, 6, 1, 12, 0, 0, 0, time.Local),
+			expected: "silent",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			SetConfig(testProfiles, tt.schedule)
+			ClearForced()
+
+			// WHEN
+			Update(tt.now)
+
+			// THEN
+			assert.Equal(t, tt.expected, GetActiveProfile())
+		})
+	}
+}
+
+func TestForceProfile(t *testing.T) {
+	// GIVEN
+	SetConfig(testProfiles, []configuration.ScheduleConfig{{Profile: "performance", From: "00:00", To: "00:00"}})
+	Update(time.Now())
+
+	// WHEN
+	err := Force("silent")
+	changed := Update(time.Now())
+
+	// THEN
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, "silent", GetActiveProfile())
+	assert.Equal(t, "silent_curve", GetCurveId("cpu_fan", "default_curve"))
+	assert.Equal(t, "default_curve", GetCurveId("gpu_fan", "default_curve"))
+
+	// WHEN
+	ClearForced()
+
+	// THEN
+	assert.Equal(t, "performance", GetActiveProfile())
+	assert.Error(t, Force("unknown"))
+}
@@ -0,0 +1,110 @@
+package profiles
+
+import (
+	"fmt"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/ui"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// procRoot is the mount point of the proc filesystem used to detect running processes and the system load
+var procRoot = "/proc"
+
+type trigger struct {
+	profile string
+	config  configuration.ProfileTriggerConfig
+	// the last time the conditions of this trigger were met
+	lastMatch time.Time
+}
+
+// evaluateTriggers checks the conditions of all triggers and returns the id of the first profile
+// whose trigger is active, including its cool-down, or an empty string if there is none
+func evaluateTriggers(now time.Time) string {
+	if len(triggers) <= 0 {
+		return ""
+	}
+
+	var processes map[string]bool
+	var load float64
+	var err error
+	for _, t := range triggers {
+		if len(t.config.Processes) > 0 && processes == nil {
+			processes, err = readProcessNames(procRoot)
+			if err != nil {
+				ui.Warning("Unable to read running processes: %v", err)
+				processes = map[string]bool{}
+			}
+		}
+		if t.config.Load > 0 && load == 0 {
+			load, err = readLoad(procRoot)
+			if err != nil {
+				ui.Warning("Unable to read system load: %v", err)
+			}
+		}
+
+		if t.matches(processes, load) {
+			t.lastMatch = now
+		}
+	}
+
+	for _, t := range triggers {
+		if !t.lastMatch.IsZero() && now.Sub(t.lastMatch) <= t.config.CoolDown {
+			return t.profile
+		}
+	}
+	return ""
+}
+
+func (t *trigger) matches(processes map[string]bool, load float64) bool {
+	if t.config.Load > 0 && load > t.config.Load {
+		return true
+	}
+	for _, process := range t.config.Processes {
+		if processes[process] {
+			return true
+		}
+	}
+	return false
+}
+
+// readProcessNames returns the names of all running processes, read from the proc filesystem at the given root
+func readProcessNames(root string) (map[string]bool, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]bool{}
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil || !entry.IsDir() {
+			continue
+		}
+		// errors are ignored, since the process may have exited in the meantime
+		if comm, err := os.ReadFile(filepath.Join(root, entry.Name(), "comm")); err == nil {
+			result[strings.TrimSpace(string(comm))] = true
+		}
+		// comm is truncated to 15 characters, so the executable name is taken from the command line as well
+		if cmdline, err := os.ReadFile(filepath.Join(root, entry.Name(), "cmdline")); err == nil && len(cmdline) > 0 {
+			executable := strings.SplitN(string(cmdline), "\x00", 2)[0]
+			result[filepath.Base(executable)] = true
+		}
+	}
+	return result, nil
+}
+
+// readLoad returns the 1 minute system load average, read from the proc filesystem at the given root
+func readLoad(root string) (float64, error) {
+	data, err := os.ReadFile(filepath.Join(root, "loadavg"))
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) <= 0 {
+		return 0, fmt.Errorf("unexpected content of %s", filepath.Join(root, "loadavg"))
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
@@ -0,0 +1,158 @@
+package profiles
+
+import (
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// createFakeProcRoot creates a directory with the same layout as the proc filesystem,
+// containing the given processes (pid -> command line) and load average
+func createFakeProcRoot(t *testing.T, processes map[string]string, loadavg string) string {
+	root := t.TempDir()
+	for pid, cmdline := range processes {
+		processDir := filepath.Join(root, pid)
+		assert.NoError(t, os.Mkdir(processDir, 0755))
+		comm := filepath.Base(cmdline)
+		if len(comm) > 15 {
+			comm = comm[:15]
+		}
+		assert.NoError(t, os.WriteFile(filepath.Join(processDir, "comm"), []byte(comm+"\n"), 0644))
+		assert.NoError(t, os.WriteFile(filepath.Join(processDir, "cmdline"), []byte(cmdline+"\x00--flag\x00"), 0644))
+	}
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "self"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "loadavg"), []byte(loadavg), 0644))
+	return root
+}
+
+var triggerProfiles = []configuration.ProfileConfig{
+	{
+		ID: "performance",
+		Trigger: &configuration.ProfileTriggerConfig{
+			Processes: []string{"blender", "very-long-process-name"},
+			CoolDown:  time.Minute,
+		},
+	},
+	{
+		ID: "busy",
+		Trigger: &configuration.ProfileTriggerConfig{
+			Load: 8,
+		},
+	},
+	{
+		ID: "silent",
+	},
+}
+
+func TestReadProcessNames(t *testing.T) {
+	// GIVEN
+	root := createFakeProcRoot(t, map[string]string{
+		"1":    "/sbin/init",
+		"1234": "/usr/bin/very-long-process-name",
+	}, "0.50 0.40 0.30 1/123 4567\n")
+
+	// WHEN
+	result, err := readProcessNames(root)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.True(t, result["init"])
+	assert.True(t, result["very-long-proce"])
+	assert.True(t, result["very-long-process-name"])
+	assert.False(t, result["self"])
+}
+
+func TestReadLoad(t *testing.T) {
+	// GIVEN
+	root := createFakeProcRoot(t, map[string]string{}, "12.34 8.00 4.00 1/123 4567\n")
+
+	// WHEN
+	result, err := readLoad(root)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Equal(t, 12.34, result)
+}
+
+func TestUpdateTriggers(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		name      string
+		processes map[string]string
+		loadavg   string
+		expected  string
+	}{
+		{
+			name:      "matching process",
+			processes: map[string]string{"42": "/usr/bin/blender"},
+			loadavg:   "20.00 1.00 1.00 1/123 4567",
+			expected:  "performance",
+		},
+		{
+			name:      "process name longer than comm",
+			processes: map[string]string{"42": "/opt/very-long-process-name"},
+			loadavg:   "0.10 1.00 1.00 1/123 4567",
+			expected:  "performance",
+		},
+		{
+			name:      "load above threshold",
+			processes: map[string]string{"42": "/usr/bin/bash"},
+			loadavg:   "8.50 1.00 1.00 1/123 4567",
+			expected:  "busy",
+		},
+		{
+			name:      "nothing matches",
+			processes: map[string]string{"42": "/usr/bin/bash"},
+			loadavg:   "0.10 1.00 1.00 1/123 4567",
+			expected:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			procRoot = createFakeProcRoot(t, tt.processes, tt.loadavg)
+			defer func() { procRoot = "/proc" }()
+			SetConfig(nil, nil)
+			SetConfig(triggerProfiles, []configuration.ScheduleConfig{{Profile: "silent", From: "00:00", To: "00:00"}})
+			ClearForced()
+
+			// WHEN
+			Update(now)
+
+			// THEN
+			assert.Equal(t, tt.expected, GetTriggeredProfile())
+			if len(tt.expected) > 0 {
+				assert.Equal(t, tt.expected, GetActiveProfile())
+			} else {
+				assert.Equal(t, "silent", GetActiveProfile())
+			}
+		})
+	}
+}
+
+func TestUpdateTriggersCoolDown(t *testing.T) {
+	// GIVEN
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.Local)
+	procRoot = createFakeProcRoot(t, map[string]string{"42": "/usr/bin/blender"}, "0.10 1.00 1.00 1/123 4567")
+	defer func() { procRoot = "/proc" }()
+	SetConfig(nil, nil)
+	SetConfig(triggerProfiles, nil)
+	ClearForced()
+	Update(now)
+
+	// WHEN
+	procRoot = createFakeProcRoot(t, map[string]string{}, "0.10 1.00 1.00 1/123 4567")
+	changedDuringCoolDown := Update(now.Add(30 * time.Second))
+	activeDuringCoolDown := GetActiveProfile()
+	changedAfterCoolDown := Update(now.Add(61 * time.Second))
+
+	// THEN
+	assert.False(t, changedDuringCoolDown)
+	assert.Equal(t, "performance", activeDuringCoolDown)
+	assert.True(t, changedAfterCoolDown)
+	assert.Equal(t, "", GetActiveProfile())
+}
@@ -3,10 +3,12 @@ package sensors
 import (
 	"fmt"
 	"github.com/markusressel/fan2go/internal/configuration"
+	"sync"
 )
 
 var (
-	SensorMap = map[string]Sensor{}
+	SensorMap      = map[string]Sensor{}
+	sensorMapMutex sync.RWMutex
 )
 
 type Sensor interface {
@@ -45,3 +47,40 @@ func NewSensor(config configuration.SensorConfig) (Sensor, error) {
 
 	return nil, fmt.Errorf("no matching sensor type for sensor: %s", config.ID)
 }
+
+// GetSensor returns the sensor with the given id
+func GetSensor(id string) (Sensor, bool) {
+	sensorMapMutex.RLock()
+	defer sensorMapMutex.RUnlock()
+	sensor, exists := SensorMap[id]
+	return sensor, exists
+}
+
+// RegisterSensor adds the given sensor to the SensorMap, replacing any existing sensor with the same id
+func RegisterSensor(sensor Sensor) {
+	sensorMapMutex.Lock()
+	defer sensorMapMutex.Unlock()
+	SensorMap[sensor.GetId()] = sensor
+	resetHealth(sensor.GetId())
+	resetHistory(sensor.GetId())
+}
+
+// RemoveSensor removes the sensor with the given id from the SensorMap
+func RemoveSensor(id string) {
+	sensorMapMutex.Lock()
+	defer sensorMapMutex.Unlock()
+	delete(SensorMap, id)
+	resetHealth(id)
+	resetHistory(id)
+}
+
+// SnapshotSensorMap returns a copy of the SensorMap
+func SnapshotSensorMap() map[string]Sensor {
+	sensorMapMutex.RLock()
+	defer sensorMapMutex.RUnlock()
+	result := make(map[string]Sensor, len(SensorMap))
+	for id, sensor := range SensorMap {
+		result[id] = sensor
+	}
+	return result
+}
@@ -1,8 +1,8 @@
 package sensors
 
 import (
+	"fmt"
 	"github.com/markusressel/fan2go/internal/configuration"
-	"github.com/markusressel/fan2go/internal/ui"
 	"github.com/markusressel/fan2go/internal/util"
 	"os/user"
 	"path/filepath"
@@ -36,8 +36,7 @@ func (sensor FileSensor) GetValue() (float64, error) {
 
 	integer, err := util.ReadIntFromFile(filePath)
 	if err != nil {
-		ui.Warning("Unable to read int from file sensor: %s", filePath)
-		return 0, nil
+		return 0, fmt.Errorf("unable to read int from file sensor %s: %v", filePath, err)
 	}
 
 	result := float64(integer)
@@ -0,0 +1,112 @@
+package sensors
+
+import (
+	"github.com/markusressel/fan2go/internal/configuration"
+	"sync"
+	"time"
+)
+
+// DefaultMaxFailedReads is the number of consecutive failed reads after which a sensor is considered stale,
+// if not configured otherwise
+const DefaultMaxFailedReads = 5
+
+type HealthState string
+
+const (
+	// HealthStateOk means the sensor provides valid values
+	HealthStateOk HealthState = "ok"
+	// HealthStateStale means the value of the sensor can't be trusted anymore
+	HealthStateStale HealthState = "stale"
+)
+
+type Health struct {
+	State HealthState `json:"state"`
+	// FailedReads is the number of consecutive failed reads
+	FailedReads int `json:"failedReads"`
+	// LastError is the error of the last failed read, if any
+	LastError string `json:"lastError,omitempty"`
+	// LastValueChange is the time the value of the sensor changed last
+	LastValueChange time.Time `json:"lastValueChange"`
+
+	lastValue float64
+}
+
+var (
+	healthMap      = map[string]*Health{}
+	healthMapMutex sync.RWMutex
+)
+
+// RecordSensorRead records a successful read of the given value for the sensor with the given id
+func RecordSensorRead(id string, value float64, now time.Time) {
+	healthMapMutex.Lock()
+	defer healthMapMutex.Unlock()
+	health := getOrCreateHealth(id)
+	health.FailedReads = 0
+	health.LastError = ""
+	if health.LastValueChange.IsZero() || value != health.lastValue {
+		health.lastValue = value
+		health.LastValueChange = now
+	}
+}
+
+// RecordSensorError records a failed read for the sensor with the given id
+func RecordSensorError(id string, err error) {
+	healthMapMutex.Lock()
+	defer healthMapMutex.Unlock()
+	health := getOrCreateHealth(id)
+	health.FailedReads++
+	health.LastError = err.Error()
+}
+
+// GetHealth returns the current health of the given sensor
+func GetHealth(sensor Sensor) Health {
+	return getHealth(sensor, time.Now())
+}
+
+func getHealth(sensor Sensor, now time.Time) Health {
+	healthMapMutex.RLock()
+	defer healthMapMutex.RUnlock()
+
+	health := Health{}
+	if h, exists := healthMap[sensor.GetId()]; exists {
+		health = *h
+	}
+	health.State = evaluateHealthState(health, sensor.GetConfig(), now)
+	return health
+}
+
+// IsStale indicates whether the value of the given sensor can't be trusted anymore
+func IsStale(sensor Sensor) bool {
+	return GetHealth(sensor).State == HealthStateStale
+}
+
+func evaluateHealthState(health Health, config configuration.SensorConfig, now time.Time) HealthState {
+	maxFailedReads := config.MaxFailedReads
+	if maxFailedReads <= 0 {
+		maxFailedReads = DefaultMaxFailedReads
+	}
+	if health.FailedReads >= maxFailedReads {
+		return HealthStateStale
+	}
+
+	if config.StaleTimeout > 0 && !health.LastValueChange.IsZero() && now.Sub(health.LastValueChange) >= config.StaleTimeout {
+		return HealthStateStale
+	}
+
+	return HealthStateOk
+}
+
+func getOrCreateHealth(id string) *Health {
+	health, exists := healthMap[id]
+	if !exists {
+		health = &Health{}
+		healthMap[id] = health
+	}
+	return health
+}
+
+func resetHealth(id string) {
+	healthMapMutex.Lock()
+	defer healthMapMutex.Unlock()
+	delete(healthMap, id)
+}
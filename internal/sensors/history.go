@@ -0,0 +1,77 @@
+package sensors
+
+import (
+	"github.com/markusressel/fan2go/internal/configuration"
+	"sync"
+	"time"
+)
+
+// historyDuration is the time span of values kept for each sensor
+const historyDuration = configuration.MaxDerivativeWindow
+
+type sample struct {
+	time  time.Time
+	value float64
+}
+
+var (
+	historyMap      = map[string][]sample{}
+	historyMapMutex sync.RWMutex
+)
+
+// RecordSensorHistory appends the given value to the history of the sensor with the given id
+// and drops all values older than historyDuration
+func RecordSensorHistory(id string, value float64, now time.Time) {
+	historyMapMutex.Lock()
+	defer historyMapMutex.Unlock()
+
+	history := append(historyMap[id], sample{time: now, value: value})
+	idx := 0
+	for idx < len(history) && now.Sub(history[idx].time) > historyDuration {
+		idx++
+	}
+	historyMap[id] = history[idx:]
+}
+
+// GetRateOfChange returns the rate of change (in °C/s) of the sensor with the given id within the given window,
+// calculated using a linear regression over all values in the window.
+// Returns false if there are not enough values to calculate it yet.
+func GetRateOfChange(id string, window time.Duration) (float64, bool) {
+	return getRateOfChange(id, window, time.Now())
+}
+
+func getRateOfChange(id string, window time.Duration, now time.Time) (float64, bool) {
+	historyMapMutex.RLock()
+	defer historyMapMutex.RUnlock()
+
+	var n, sumT, sumV, sumTT, sumTV float64
+	for _, s := range historyMap[id] {
+		age := now.Sub(s.time)
+		if age > window {
+			continue
+		}
+		// relative to now to keep the sums small
+		t := -age.Seconds()
+		v := s.value / 1000
+		n++
+		sumT += t
+		sumV += v
+		sumTT += t * t
+		sumTV += t * v
+	}
+	if n < 2 {
+		return 0, false
+	}
+
+	denominator := n*sumTT - sumT*sumT
+	if denominator == 0 {
+		return 0, false
+	}
+	return (n*sumTV - sumT*sumV) / denominator, true
+}
+
+func resetHistory(id string) {
+	historyMapMutex.Lock()
+	defer historyMapMutex.Unlock()
+	delete(historyMap, id)
+}
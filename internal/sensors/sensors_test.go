@@ -1,7 +1,11 @@
 package sensors
 
 import (
+	"errors"
 	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
 )
 
 func CreateSensor(
@@ -19,3 +23,91 @@ func CreateSensor(
 	SensorMap[sensor.GetId()] = sensor
 	return sensor
 }
+
+func TestHealthStaleAfterFailedReads(t *testing.T) {
+	// GIVEN
+	sensor := CreateSensor("failing_sensor", configuration.HwMonSensorConfig{Index: 1}, 50000)
+	now := time.Now()
+	RecordSensorRead(sensor.GetId(), 50000, now)
+
+	// WHEN
+	for i := 0; i < DefaultMaxFailedReads-1; i++ {
+		RecordSensorError(sensor.GetId(), errors.New("read error"))
+	}
+	beforeLimit := getHealth(sensor, now)
+	RecordSensorError(sensor.GetId(), errors.New("read error"))
+	atLimit := getHealth(sensor, now)
+	RecordSensorRead(sensor.GetId(), 51000, now)
+	recovered := getHealth(sensor, now)
+
+	// THEN
+	assert.Equal(t, HealthStateOk, beforeLimit.State)
+	assert.Equal(t, HealthStateStale, atLimit.State)
+	assert.Equal(t, "read error", atLimit.LastError)
+	assert.Equal(t, HealthStateOk, recovered.State)
+	assert.Equal(t, 0, recovered.FailedReads)
+}
+
+func TestHealthStaleAfterTimeout(t *testing.T) {
+	// GIVEN
+	sensor := &HwmonSensor{
+		Config: configuration.SensorConfig{
+			ID:           "frozen_sensor",
+			HwMon:        &configuration.HwMonSensorConfig{Index: 1},
+			StaleTimeout: time.Minute,
+		},
+	}
+	RegisterSensor(sensor)
+	start := time.Now()
+
+	// WHEN
+	RecordSensorRead(sensor.GetId(), 50000, start)
+	RecordSensorRead(sensor.GetId(), 50000, start.Add(30*time.Second))
+	unchanged := getHealth(sensor, start.Add(30*time.Second))
+	RecordSensorRead(sensor.GetId(), 50000, start.Add(time.Minute))
+	frozen := getHealth(sensor, start.Add(time.Minute))
+	RecordSensorRead(sensor.GetId(), 51000, start.Add(61*time.Second))
+	changed := getHealth(sensor, start.Add(61*time.Second))
+
+	// THEN
+	assert.Equal(t, HealthStateOk, unchanged.State)
+	assert.Equal(t, HealthStateStale, frozen.State)
+	assert.Equal(t, HealthStateOk, changed.State)
+}
+
+func TestRateOfChange(t *testing.T) {
+	// GIVEN
+	id := "rising_sensor"
+	resetHistory(id)
+	start := time.Now()
+
+	// WHEN
+	_, enoughHistoryAtStart := getRateOfChange(id, 10*time.Second, start)
+	// 0.5°C/s with some noise, as well as a value outside of the window
+	RecordSensorHistory(id, 20000, start)
+	for i := 0; i <= 10; i++ {
+		noise := float64(i%2) * 100
+		RecordSensorHistory(id, 40000+float64(i)*500+noise, start.Add(time.Duration(10+i)*time.Second))
+	}
+	rateOfChange, enoughHistory := getRateOfChange(id, 10*time.Second, start.Add(20*time.Second))
+
+	// THEN
+	assert.False(t, enoughHistoryAtStart)
+	assert.True(t, enoughHistory)
+	assert.InDelta(t, 0.5, rateOfChange, 0.01)
+}
+
+func TestHistoryIsLimited(t *testing.T) {
+	// GIVEN
+	id := "long_running_sensor"
+	resetHistory(id)
+	start := time.Now()
+
+	// WHEN
+	RecordSensorHistory(id, 40000, start)
+	RecordSensorHistory(id, 40000, start.Add(historyDuration))
+	RecordSensorHistory(id, 40000, start.Add(historyDuration+time.Second))
+
+	// THEN
+	assert.Len(t, historyMap[id], 2)
+}
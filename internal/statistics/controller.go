@@ -3,21 +3,24 @@ package statistics
 import (
 	"github.com/markusressel/fan2go/internal/controller"
 	"github.com/prometheus/client_golang/prometheus"
+	"time"
 )
 
 const controllerSubsystem = "controller"
 
 type ControllerCollector struct {
-	controllers []controller.FanController
-
 	unexpectedPwmValueCount *prometheus.Desc
 	increasedMinPwmCount    *prometheus.Desc
 	minPwmOffset            *prometheus.Desc
+	overrideActive          *prometheus.Desc
+	overrideRemaining       *prometheus.Desc
+	stalled                 *prometheus.Desc
+	stallCount              *prometheus.Desc
+	curveErrorCount         *prometheus.Desc
 }
 
-func NewControllerCollector(controllers []controller.FanController) *ControllerCollector {
+func NewControllerCollector() *ControllerCollector {
 	return &ControllerCollector{
-		controllers: controllers,
 		unexpectedPwmValueCount: prometheus.NewDesc(prometheus.BuildFQName(namespace, controllerSubsystem, "unexpected_pwm_value_count"),
 			"Counter for instances of a mismatch between expected PWM value and actual PWM value of for this controller",
 			[]string{"id"}, nil,
@@ -30,23 +33,73 @@ func NewControllerCollector(controllers []controller.FanController) *ControllerC
 			"Offset applied to the original minPwm of the fan due to a stalling fan",
 			[]string{"id"}, nil,
 		),
+		overrideActive: prometheus.NewDesc(prometheus.BuildFQName(namespace, controllerSubsystem, "override_active"),
+			"Whether the curve of this controller is currently overridden (1) or not (0)",
+			[]string{"id"}, nil,
+		),
+		overrideRemaining: prometheus.NewDesc(prometheus.BuildFQName(namespace, controllerSubsystem, "override_remaining_seconds"),
+			"Remaining time until the currently active override of this controller expires",
+			[]string{"id"}, nil,
+		),
+		stalled: prometheus.NewDesc(prometheus.BuildFQName(namespace, controllerSubsystem, "stalled"),
+			"Whether the fan of this controller is currently considered stalled (1) or not (0)",
+			[]string{"id"}, nil,
+		),
+		stallCount: prometheus.NewDesc(prometheus.BuildFQName(namespace, controllerSubsystem, "stall_count"),
+			"Counter for number of times the fan of this controller was detected as stalled",
+			[]string{"id"}, nil,
+		),
+		curveErrorCount: prometheus.NewDesc(prometheus.BuildFQName(namespace, controllerSubsystem, "curve_error_count"),
+			"Counter for number of times the curve of this controller couldn't be evaluated",
+			[]string{"id"}, nil,
+		),
 	}
 }
 
 func (collector *ControllerCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- collector.unexpectedPwmValueCount
 	ch <- collector.increasedMinPwmCount
+	ch <- collector.minPwmOffset
+	ch <- collector.overrideActive
+	ch <- collector.overrideRemaining
+	ch <- collector.stalled
+	ch <- collector.stallCount
+	ch <- collector.curveErrorCount
 }
 
 // Collect implements required collect function for all prometheus collectors
 func (collector *ControllerCollector) Collect(ch chan<- prometheus.Metric) {
-	for _, contr := range collector.controllers {
+	snapshot := controller.GetSnapshot()
+	for _, contr := range controller.SnapshotFanControllerMap() {
 		switch contr.(type) {
 		case *controller.PidFanController:
 			fanId := contr.GetFanId()
-			ch <- prometheus.MustNewConstMetric(collector.unexpectedPwmValueCount, prometheus.CounterValue, float64(contr.GetStatistics().UnexpectedPwmValueCount), fanId)
-			ch <- prometheus.MustNewConstMetric(collector.increasedMinPwmCount, prometheus.CounterValue, float64(contr.GetStatistics().IncreasedMinPwmCount), fanId)
-			ch <- prometheus.MustNewConstMetric(collector.minPwmOffset, prometheus.GaugeValue, float64(contr.GetStatistics().MinPwmOffset), fanId)
+			fan, exists := snapshot.Fans[fanId]
+			if !exists {
+				// the fan is not controlled yet
+				continue
+			}
+			stats := fan.Statistics
+			ch <- prometheus.MustNewConstMetric(collector.unexpectedPwmValueCount, prometheus.CounterValue, float64(stats.UnexpectedPwmValueCount), fanId)
+			ch <- prometheus.MustNewConstMetric(collector.increasedMinPwmCount, prometheus.CounterValue, float64(stats.IncreasedMinPwmCount), fanId)
+			ch <- prometheus.MustNewConstMetric(collector.minPwmOffset, prometheus.GaugeValue, float64(stats.MinPwmOffset), fanId)
+
+			overrideActive := 0.0
+			overrideRemaining := 0.0
+			if override := contr.GetOverride(); override != nil {
+				overrideActive = 1
+				overrideRemaining = time.Until(override.Expires).Seconds()
+			}
+			ch <- prometheus.MustNewConstMetric(collector.overrideActive, prometheus.GaugeValue, overrideActive, fanId)
+			ch <- prometheus.MustNewConstMetric(collector.overrideRemaining, prometheus.GaugeValue, overrideRemaining, fanId)
+
+			stalled := 0.0
+			if stats.Stalled {
+				stalled = 1
+			}
+			ch <- prometheus.MustNewConstMetric(collector.stalled, prometheus.GaugeValue, stalled, fanId)
+			ch <- prometheus.MustNewConstMetric(collector.stallCount, prometheus.CounterValue, float64(stats.StallCount), fanId)
+			ch <- prometheus.MustNewConstMetric(collector.curveErrorCount, prometheus.CounterValue, float64(stats.CurveErrorCount), fanId)
 		}
 	}
 }
@@ -1,21 +1,19 @@
 package statistics
 
 import (
-	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/controller"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 const fanSubsystem = "fan"
 
 type FanCollector struct {
-	fans []fans.Fan
-	pwm  *prometheus.Desc
-	rpm  *prometheus.Desc
+	pwm *prometheus.Desc
+	rpm *prometheus.Desc
 }
 
-func NewFanCollector(fans []fans.Fan) *FanCollector {
+func NewFanCollector() *FanCollector {
 	return &FanCollector{
-		fans: fans,
 		pwm: prometheus.NewDesc(prometheus.BuildFQName(namespace, fanSubsystem, "pwm"),
 			"Current PWM value of the fan",
 			[]string{"id"}, nil,
@@ -34,15 +32,11 @@ func (collector *FanCollector) Describe(ch chan<- *prometheus.Desc) {
 
 // Collect implements required collect function for all prometheus collectors
 func (collector *FanCollector) Collect(ch chan<- prometheus.Metric) {
-	for _, fan := range collector.fans {
-		fanId := fan.GetId()
+	for fanId, fan := range controller.GetSnapshot().Fans {
+		ch <- prometheus.MustNewConstMetric(collector.pwm, prometheus.GaugeValue, float64(fan.Pwm), fanId)
 
-		pwm, _ := fan.GetPwm()
-		ch <- prometheus.MustNewConstMetric(collector.pwm, prometheus.GaugeValue, float64(pwm), fanId)
-
-		if fan.Supports(fans.FeatureRpmSensor) {
-			rpm, _ := fan.GetRpm()
-			ch <- prometheus.MustNewConstMetric(collector.rpm, prometheus.GaugeValue, float64(rpm), fanId)
+		if fan.Rpm != nil {
+			ch <- prometheus.MustNewConstMetric(collector.rpm, prometheus.GaugeValue, float64(*fan.Rpm), fanId)
 		}
 	}
 }
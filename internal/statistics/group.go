@@ -0,0 +1,70 @@
+package statistics
+
+import (
+	"github.com/markusressel/fan2go/internal/controller"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const groupSubsystem = "group"
+
+type FanGroupCollector struct {
+	curveValue *prometheus.Desc
+	targetRpm  *prometheus.Desc
+	rpm        *prometheus.Desc
+	members    *prometheus.Desc
+}
+
+func NewFanGroupCollector() *FanGroupCollector {
+	return &FanGroupCollector{
+		curveValue: prometheus.NewDesc(prometheus.BuildFQName(namespace, groupSubsystem, "curve_value"),
+			"Curve value [0..255] the fan group has decided on last",
+			[]string{"id"}, nil,
+		),
+		targetRpm: prometheus.NewDesc(prometheus.BuildFQName(namespace, groupSubsystem, "target_rpm"),
+			"RPM all member fans of the fan group are driven at, if it uses a shared target RPM",
+			[]string{"id"}, nil,
+		),
+		rpm: prometheus.NewDesc(prometheus.BuildFQName(namespace, groupSubsystem, "rpm"),
+			"Average RPM of all member fans of the fan group which have an RPM sensor",
+			[]string{"id"}, nil,
+		),
+		members: prometheus.NewDesc(prometheus.BuildFQName(namespace, groupSubsystem, "members"),
+			"Number of member fans of the fan group",
+			[]string{"id"}, nil,
+		),
+	}
+}
+
+func (collector *FanGroupCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.curveValue
+	ch <- collector.targetRpm
+	ch <- collector.rpm
+	ch <- collector.members
+}
+
+// Collect implements required collect function for all prometheus collectors
+func (collector *FanGroupCollector) Collect(ch chan<- prometheus.Metric) {
+	snapshot := controller.GetSnapshot()
+	for id, g := range controller.SnapshotFanGroupMap() {
+		ch <- prometheus.MustNewConstMetric(collector.members, prometheus.GaugeValue, float64(len(g.GetFanIds())), id)
+
+		if decision, exists := snapshot.Groups[id]; exists {
+			ch <- prometheus.MustNewConstMetric(collector.curveValue, prometheus.GaugeValue, float64(decision.CurveValue), id)
+			if decision.TargetRpm != nil {
+				ch <- prometheus.MustNewConstMetric(collector.targetRpm, prometheus.GaugeValue, *decision.TargetRpm, id)
+			}
+		}
+
+		sum := 0
+		count := 0
+		for _, fanId := range g.GetFanIds() {
+			if fan, exists := snapshot.Fans[fanId]; exists && fan.Rpm != nil {
+				sum += *fan.Rpm
+				count++
+			}
+		}
+		if count > 0 {
+			ch <- prometheus.MustNewConstMetric(collector.rpm, prometheus.GaugeValue, float64(sum)/float64(count), id)
+		}
+	}
+}
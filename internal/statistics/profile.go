@@ -0,0 +1,37 @@
+package statistics
+
+import (
+	"github.com/markusressel/fan2go/internal/profiles"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const subsystemProfile = "profile"
+
+type ProfileCollector struct {
+	active *prometheus.Desc
+}
+
+func NewProfileCollector() *ProfileCollector {
+	return &ProfileCollector{
+		active: prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystemProfile, "active"),
+			"Whether the profile is currently active (1) or not (0)",
+			[]string{"id"}, nil,
+		),
+	}
+}
+
+func (collector *ProfileCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.active
+}
+
+// Collect implements required collect function for all prometheus collectors
+func (collector *ProfileCollector) Collect(ch chan<- prometheus.Metric) {
+	activeProfile := profiles.GetActiveProfile()
+	for _, profile := range profiles.GetProfiles() {
+		value := 0.0
+		if profile.ID == activeProfile {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(collector.active, prometheus.GaugeValue, value, profile.ID)
+	}
+}
@@ -1,6 +1,7 @@
 package statistics
 
 import (
+	"github.com/markusressel/fan2go/internal/controller"
 	"github.com/markusressel/fan2go/internal/sensors"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -8,29 +9,45 @@ import (
 const subsystemSensor = "sensor"
 
 type SensorCollector struct {
-	sensors []sensors.Sensor
-	value   *prometheus.Desc
+	value       *prometheus.Desc
+	healthy     *prometheus.Desc
+	failedReads *prometheus.Desc
 }
 
-func NewSensorCollector(sensors []sensors.Sensor) *SensorCollector {
+func NewSensorCollector() *SensorCollector {
 	return &SensorCollector{
-		sensors: sensors,
 		value: prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystemSensor, "value"),
 			"Current value of the sensor",
 			[]string{"id"}, nil,
 		),
+		healthy: prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystemSensor, "healthy"),
+			"Whether the sensor provides valid values (1) or is stale (0)",
+			[]string{"id"}, nil,
+		),
+		failedReads: prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystemSensor, "failed_reads"),
+			"Number of consecutive failed reads of the sensor",
+			[]string{"id"}, nil,
+		),
 	}
 }
 
 func (collector *SensorCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- collector.value
+	ch <- collector.healthy
+	ch <- collector.failedReads
 }
 
 // Collect implements required collect function for all prometheus collectors
 func (collector *SensorCollector) Collect(ch chan<- prometheus.Metric) {
-	for _, sensor := range collector.sensors {
-		sensorId := sensor.GetId()
-		value, _ := sensor.GetValue()
-		ch <- prometheus.MustNewConstMetric(collector.value, prometheus.GaugeValue, value, sensorId)
+	for sensorId, sensor := range controller.GetSnapshot().Sensors {
+		ch <- prometheus.MustNewConstMetric(collector.value, prometheus.GaugeValue, sensor.Value, sensorId)
+
+		health := sensor.Health
+		healthy := 0.0
+		if health.State == sensors.HealthStateOk {
+			healthy = 1
+		}
+		ch <- prometheus.MustNewConstMetric(collector.healthy, prometheus.GaugeValue, healthy, sensorId)
+		ch <- prometheus.MustNewConstMetric(collector.failedReads, prometheus.GaugeValue, float64(health.FailedReads), sensorId)
 	}
 }
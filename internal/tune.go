@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"github.com/markusressel/fan2go/internal/configuration"
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/hwmon"
+	"github.com/markusressel/fan2go/internal/persistence"
+	"github.com/markusressel/fan2go/internal/tuning"
+	"github.com/markusressel/fan2go/internal/ui"
+)
+
+// TuneCurve runs a relay feedback experiment on all fans using the given pid curve,
+// while watching the sensor referenced by the curve
+func TuneCurve(ctx context.Context, curveConfig configuration.CurveConfig, options tuning.RelayOptions) (tuning.Result, error) {
+	if curveConfig.PID == nil {
+		return tuning.Result{}, fmt.Errorf("curve %s is not a pid curve", curveConfig.ID)
+	}
+
+	controllers := hwmon.GetChips()
+
+	var sensorConfig *configuration.SensorConfig
+	for idx, config := range configuration.CurrentConfig.Sensors {
+		if config.ID == curveConfig.PID.Sensor {
+			sensorConfig = &configuration.CurrentConfig.Sensors[idx]
+		}
+	}
+	if sensorConfig == nil {
+		return tuning.Result{}, fmt.Errorf("no sensor with id '%s' found", curveConfig.PID.Sensor)
+	}
+	sensor, err := createSensor(*sensorConfig, controllers)
+	if err != nil {
+		return tuning.Result{}, err
+	}
+
+	pers := persistence.NewPersistence(configuration.CurrentConfig.DbPath)
+
+	var fanList []fans.Fan
+	for _, config := range configuration.CurrentConfig.Fans {
+		if config.Curve != curveConfig.ID {
+			continue
+		}
+		fan, err := createFan(config, controllers)
+		if err != nil {
+			return tuning.Result{}, err
+		}
+		// use the pwm boundaries measured during initialization, if available
+		if fanPwmData, err := pers.LoadFanPwmData(fan); err == nil {
+			if err = fan.AttachFanCurveData(&fanPwmData); err != nil {
+				return tuning.Result{}, err
+			}
+		} else {
+			ui.Warning("Fan '%s' has not yet been analyzed, using the full pwm range", fan.GetId())
+		}
+		fanList = append(fanList, fan)
+	}
+	if len(fanList) <= 0 {
+		return tuning.Result{}, fmt.Errorf("curve %s is not used by any fan", curveConfig.ID)
+	}
+
+	process, err := tuning.NewFanProcess(sensor, fanList)
+	if err != nil {
+		return tuning.Result{}, err
+	}
+	defer process.Restore()
+
+	return tuning.RunRelayExperiment(ctx, process, options)
+}
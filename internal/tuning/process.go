@@ -0,0 +1,94 @@
+package tuning
+
+import (
+	"github.com/markusressel/fan2go/internal/fans"
+	"github.com/markusressel/fan2go/internal/sensors"
+	"github.com/markusressel/fan2go/internal/ui"
+	"time"
+)
+
+// FanProcess applies the output of an experiment to a set of fans and measures the value of a sensor
+type FanProcess struct {
+	sensor sensors.Sensor
+	fans   []fans.Fan
+
+	originalPwm        map[string]int
+	originalPwmEnabled map[string]int
+}
+
+// NewFanProcess takes control of the given fans, use Restore to hand it back afterwards
+func NewFanProcess(sensor sensors.Sensor, fanList []fans.Fan) (*FanProcess, error) {
+	p := &FanProcess{
+		sensor:             sensor,
+		fans:               fanList,
+		originalPwm:        map[string]int{},
+		originalPwmEnabled: map[string]int{},
+	}
+
+	for _, fan := range fanList {
+		if pwm, err := fan.GetPwm(); err == nil {
+			p.originalPwm[fan.GetId()] = pwm
+		}
+		if fan.Supports(fans.FeatureControlMode) {
+			if pwmEnabled, err := fan.GetPwmEnabled(); err == nil {
+				p.originalPwmEnabled[fan.GetId()] = pwmEnabled
+			}
+			if err := fan.SetPwmEnabled(fans.ControlModePWM); err != nil {
+				p.Restore()
+				return nil, err
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// SetOutput maps the given output to the pwm range of each fan
+func (p *FanProcess) SetOutput(output float64) error {
+	for _, fan := range p.fans {
+		minPwm := fan.GetMinPwm()
+		maxPwm := fan.GetMaxPwm()
+		pwm := minPwm + int(output*float64(maxPwm-minPwm))
+		if err := fan.SetPwm(pwm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Measure returns the current sensor value in °C
+func (p *FanProcess) Measure() (float64, error) {
+	value, err := p.sensor.GetValue()
+	if err != nil {
+		return 0, err
+	}
+	return value / 1000, nil
+}
+
+func (p *FanProcess) Wait(duration time.Duration) {
+	time.Sleep(duration)
+}
+
+// Restore resets all fans to the state they had before the experiment
+func (p *FanProcess) Restore() {
+	for _, fan := range p.fans {
+		ui.Info("Trying to restore fan settings for %s...", fan.GetId())
+
+		if pwm, exists := p.originalPwm[fan.GetId()]; exists {
+			if err := fan.SetPwm(pwm); err != nil {
+				ui.Warning("Error restoring original PWM value for fan %s: %v", fan.GetId(), err)
+			}
+		}
+
+		pwmEnabled, exists := p.originalPwmEnabled[fan.GetId()]
+		if !exists || fans.ControlMode(pwmEnabled) == fans.ControlModePWM {
+			continue
+		}
+		if err := fan.SetPwmEnabled(fans.ControlMode(pwmEnabled)); err != nil {
+			// if this fails, try to set it to max speed instead
+			if err = fan.SetPwm(fans.MaxPwmValue); err != nil {
+				ui.Warning("Unable to restore fan %s, make sure it is running!", fan.GetId())
+			}
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package tuning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// RuleClassic are the classic Ziegler–Nichols rules, which react fast but overshoot
+	RuleClassic = "classic"
+	// RuleNoOvershoot are the Ziegler–Nichols rules for little to no overshoot, which react slower
+	RuleNoOvershoot = "no-overshoot"
+)
+
+// Process is the system the experiment is run on, f.ex. the fans that cool a temperature sensor
+type Process interface {
+	// SetOutput applies the given output in [0..1], where 1 means maximum cooling
+	SetOutput(output float64) error
+	// Measure returns the current value of the process, f.ex. a temperature in °C
+	Measure() (float64, error)
+	// Wait lets the given amount of time pass
+	Wait(duration time.Duration)
+}
+
+type RelayOptions struct {
+	// SetPoint is the value the process oscillates around
+	SetPoint float64
+	// Low is the output while the measured value is below the set point
+	Low float64
+	// High is the output while the measured value is above the set point
+	High float64
+	// Hysteresis around the set point, prevents switching the output because of measurement noise
+	Hysteresis float64
+	// Cycles is the number of oscillations used to calculate the result, the first one is always ignored
+	Cycles int
+	// SampleInterval is the time between two measurements
+	SampleInterval time.Duration
+	// Timeout is the maximum duration of the experiment, unlimited if 0
+	Timeout time.Duration
+}
+
+type Result struct {
+	// UltimateGain is the gain at which a proportional controller causes a stable oscillation
+	UltimateGain float64
+	// UltimatePeriod is the period of this oscillation
+	UltimatePeriod time.Duration
+	// Amplitude is the average amplitude of the measured oscillations
+	Amplitude float64
+}
+
+type Gains struct {
+	P float64 `json:"p"`
+	I float64 `json:"i"`
+	D float64 `json:"d"`
+}
+
+// RunRelayExperiment runs a relay feedback experiment (Åström–Hägglund) on the given process.
+//
+// The output is switched between options.Low and options.High whenever the measured value crosses
+// the set point, which causes the process to oscillate. The period and amplitude of this oscillation
+// are used to estimate the ultimate gain and period of the process.
+func RunRelayExperiment(ctx context.Context, process Process, options RelayOptions) (Result, error) {
+	if options.High <= options.Low {
+		return Result{}, errors.New("the high output must be greater than the low output")
+	}
+	if options.Cycles <= 0 {
+		return Result{}, errors.New("at least one cycle is required")
+	}
+	if options.SampleInterval <= 0 {
+		return Result{}, errors.New("the sample interval must be positive")
+	}
+
+	measured, err := process.Measure()
+	if err != nil {
+		return Result{}, err
+	}
+
+	high := measured > options.SetPoint
+	if err = setRelayOutput(process, options, high); err != nil {
+		return Result{}, err
+	}
+
+	var elapsed time.Duration
+	// time of the last switch to the high output, negative if none
+	lastSwitch := time.Duration(-1)
+	var periods []time.Duration
+	var amplitudes []float64
+	peak, valley := measured, measured
+
+	// one more cycle than requested is needed, since the first one is influenced by the starting conditions
+	for len(periods) <= options.Cycles {
+		select {
+		case <-ctx.Done():
+			return Result{}, ctx.Err()
+		default:
+		}
+
+		if options.Timeout > 0 && elapsed >= options.Timeout {
+			return Result{}, fmt.Errorf("no stable oscillation detected within %s, make sure the set point can be reached using the given outputs", options.Timeout)
+		}
+
+		process.Wait(options.SampleInterval)
+		elapsed += options.SampleInterval
+
+		measured, err = process.Measure()
+		if err != nil {
+			return Result{}, err
+		}
+		peak = math.Max(peak, measured)
+		valley = math.Min(valley, measured)
+
+		if !high && measured > options.SetPoint+options.Hysteresis {
+			// a full cycle ends every time the output is switched to high
+			if lastSwitch >= 0 {
+				periods = append(periods, elapsed-lastSwitch)
+				amplitudes = append(amplitudes, (peak-valley)/2)
+			}
+			lastSwitch = elapsed
+			peak, valley = measured, measured
+
+			high = true
+			err = setRelayOutput(process, options, high)
+		} else if high && measured < options.SetPoint-options.Hysteresis {
+			high = false
+			err = setRelayOutput(process, options, high)
+		}
+		if err != nil {
+			return Result{}, err
+		}
+	}
+
+	return calculateResult(options, periods[1:], amplitudes[1:]), nil
+}
+
+func setRelayOutput(process Process, options RelayOptions, high bool) error {
+	if high {
+		return process.SetOutput(options.High)
+	}
+	return process.SetOutput(options.Low)
+}
+
+func calculateResult(options RelayOptions, periods []time.Duration, amplitudes []float64) Result {
+	var periodSum time.Duration
+	amplitudeSum := 0.0
+	for idx := range periods {
+		periodSum += periods[idx]
+		amplitudeSum += amplitudes[idx]
+	}
+	period := periodSum / time.Duration(len(periods))
+	amplitude := amplitudeSum / float64(len(amplitudes))
+
+	// the hysteresis delays each switch, which is compensated for using the describing function of a relay with hysteresis
+	effectiveAmplitude := amplitude
+	if amplitude > options.Hysteresis {
+		effectiveAmplitude = math.Sqrt(amplitude*amplitude - options.Hysteresis*options.Hysteresis)
+	}
+	relayAmplitude := (options.High - options.Low) / 2
+
+	return Result{
+		UltimateGain:   4 * relayAmplitude / (math.Pi * effectiveAmplitude),
+		UltimatePeriod: period,
+		Amplitude:      amplitude,
+	}
+}
+
+// Gains calculates the PID constants for the given tuning rule,
+// the result increases the output while the measured value is above the set point
+func (r Result) Gains(rule string) (Gains, error) {
+	ku := r.UltimateGain
+	tu := r.UltimatePeriod.Seconds()
+
+	switch rule {
+	case RuleClassic:
+		return Gains{
+			P: 0.6 * ku,
+			I: 1.2 * ku / tu,
+			D: 0.075 * ku * tu,
+		}, nil
+	case RuleNoOvershoot:
+		return Gains{
+			P: 0.2 * ku,
+			I: 0.4 * ku / tu,
+			D: 0.066 * ku * tu,
+		}, nil
+	}
+	return Gains{}, fmt.Errorf("unsupported tuning rule '%s', use one of: %s | %s", rule, RuleClassic, RuleNoOvershoot)
+}
+
+// Negate returns the gains with an inverted sign
+func (g Gains) Negate() Gains {
+	return Gains{P: -g.P, I: -g.I, D: -g.D}
+}
@@ -0,0 +1,180 @@
+package tuning
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+	"time"
+)
+
+// simulatedPlant is a simple thermal model: a constant heat source is cooled by fans,
+// the cooling reacts to output changes only after a dead time
+type simulatedPlant struct {
+	temperature float64
+	ambient     float64
+	// heating in °C/s
+	heating float64
+	// cooling in °C/s at full output, proportional to the difference to the ambient temperature if conductance is > 0
+	cooling     float64
+	conductance float64
+	deadTime    time.Duration
+
+	step    time.Duration
+	outputs []float64
+}
+
+func (p *simulatedPlant) SetOutput(output float64) error {
+	p.outputs[len(p.outputs)-1] = output
+	return nil
+}
+
+func (p *simulatedPlant) Measure() (float64, error) {
+	return p.temperature, nil
+}
+
+func (p *simulatedPlant) Wait(duration time.Duration) {
+	delaySteps := int(p.deadTime / p.step)
+	for elapsed := time.Duration(0); elapsed < duration; elapsed += p.step {
+		// the output history is used to delay the effect of output changes
+		effective := p.outputs[0]
+		if len(p.outputs) > delaySteps {
+			effective = p.outputs[len(p.outputs)-1-delaySteps]
+		}
+
+		cooling := p.cooling * effective
+		if p.conductance > 0 {
+			cooling = p.conductance * (0.2 + effective) * (p.temperature - p.ambient)
+		}
+		p.temperature += (p.heating - cooling) * p.step.Seconds()
+
+		p.outputs = append(p.outputs, p.outputs[len(p.outputs)-1])
+	}
+}
+
+func newIntegratingPlant() *simulatedPlant {
+	return &simulatedPlant{
+		temperature: 55,
+		heating:     0.1,
+		cooling:     0.2,
+		deadTime:    10 * time.Second,
+		step:        10 * time.Millisecond,
+		outputs:     []float64{0},
+	}
+}
+
+func TestRelayExperimentIntegratingPlant(t *testing.T) {
+	// GIVEN
+	plant := newIntegratingPlant()
+	options := RelayOptions{
+		SetPoint:       60,
+		Low:            0,
+		High:           1,
+		Cycles:         3,
+		SampleInterval: 100 * time.Millisecond,
+		Timeout:        30 * time.Minute,
+	}
+
+	// WHEN
+	result, err := RunRelayExperiment(context.Background(), plant, options)
+
+	// THEN
+	assert.NoError(t, err)
+	// a relay with amplitude d causes an oscillation with period 4*L and amplitude k*d*L,
+	// where L is the dead time and k the slope of the plant
+	assert.InDelta(t, 40*time.Second, result.UltimatePeriod, float64(1*time.Second))
+	assert.InDelta(t, 1.0, result.Amplitude, 0.05)
+	assert.InDelta(t, 4*0.5/(math.Pi*1.0), result.UltimateGain, 0.03)
+}
+
+func TestRelayExperimentThermalPlant(t *testing.T) {
+	// GIVEN
+	plant := &simulatedPlant{
+		temperature: 40,
+		ambient:     25,
+		heating:     1,
+		conductance: 0.05,
+		deadTime:    3 * time.Second,
+		step:        10 * time.Millisecond,
+		outputs:     []float64{0},
+	}
+	options := RelayOptions{
+		SetPoint:       50,
+		Low:            0.2,
+		High:           0.8,
+		Hysteresis:     0.2,
+		Cycles:         3,
+		SampleInterval: 500 * time.Millisecond,
+		Timeout:        30 * time.Minute,
+	}
+
+	// WHEN
+	result, err := RunRelayExperiment(context.Background(), plant, options)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.Greater(t, result.UltimateGain, 0.0)
+	assert.Greater(t, result.UltimatePeriod, 2*plant.deadTime)
+
+	gains, err := result.Gains(RuleClassic)
+	assert.NoError(t, err)
+	assert.Greater(t, gains.P, 0.0)
+	assert.Greater(t, gains.I, 0.0)
+	assert.Greater(t, gains.D, 0.0)
+}
+
+func TestRelayExperimentTimeout(t *testing.T) {
+	// GIVEN
+	plant := newIntegratingPlant()
+	// even the lowest output cools more than the plant is heated, so the set point is never reached
+	options := RelayOptions{
+		SetPoint:       60,
+		Low:            0.6,
+		High:           1,
+		Cycles:         3,
+		SampleInterval: 1 * time.Second,
+		Timeout:        10 * time.Minute,
+	}
+
+	// WHEN
+	_, err := RunRelayExperiment(context.Background(), plant, options)
+
+	// THEN
+	assert.EqualError(t, err, "no stable oscillation detected within 10m0s, make sure the set point can be reached using the given outputs")
+}
+
+func TestRelayExperimentCanceled(t *testing.T) {
+	// GIVEN
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// WHEN
+	_, err := RunRelayExperiment(ctx, newIntegratingPlant(), RelayOptions{SetPoint: 60, High: 1, Cycles: 1, SampleInterval: time.Second})
+
+	// THEN
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestGains(t *testing.T) {
+	// GIVEN
+	result := Result{
+		UltimateGain:   0.5,
+		UltimatePeriod: 40 * time.Second,
+	}
+
+	// WHEN
+	classic, err := result.Gains(RuleClassic)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.3, classic.P, 0.000001)
+	assert.InDelta(t, 0.015, classic.I, 0.000001)
+	assert.InDelta(t, 1.5, classic.D, 0.000001)
+	assert.Equal(t, Gains{P: -classic.P, I: -classic.I, D: -classic.D}, classic.Negate())
+
+	// WHEN
+	_, err = result.Gains("unknown")
+
+	// THEN
+	assert.EqualError(t, err, "unsupported tuning rule 'unknown', use one of: classic | no-overshoot")
+}
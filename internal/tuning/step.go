@@ -0,0 +1,108 @@
+package tuning
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Sample is a value measured at the given time after the start of an experiment
+type Sample struct {
+	Time  time.Duration
+	Value float64
+}
+
+// StepResponse is a first order plus dead time model of a process
+type StepResponse struct {
+	// Gain is the change of the measured value per change of the output
+	Gain float64
+	// DeadTime is the delay until the process starts to react to a change of the output
+	DeadTime time.Duration
+	// TimeConstant is the time the process needs to reach ~63% of its final value, after the dead time
+	TimeConstant time.Duration
+}
+
+// AnalyzeStepResponse fits a first order plus dead time model to the reaction of a process to a step of
+// its output. initial is the value before the step, outputChange the size of the step and samples
+// must cover the time until the process has settled again.
+//
+// The two point method of Sundaresan and Krishnaswamy is used, which only relies on the times at which
+// 35.3% and 85.3% of the total change are reached.
+func AnalyzeStepResponse(initial float64, outputChange float64, samples []Sample) (StepResponse, error) {
+	if len(samples) < 2 {
+		return StepResponse{}, errors.New("not enough samples")
+	}
+	if outputChange == 0 {
+		return StepResponse{}, errors.New("the output did not change")
+	}
+
+	// the final value is the average of the last quarter of all samples
+	settled := samples[len(samples)*3/4:]
+	final := 0.0
+	for _, sample := range settled {
+		final += sample.Value
+	}
+	final = final / float64(len(settled))
+
+	change := final - initial
+	if change == 0 {
+		return StepResponse{}, errors.New("the process did not react to the change of the output")
+	}
+
+	t1, ok1 := findCrossing(initial, change, 0.353, samples)
+	t2, ok2 := findCrossing(initial, change, 0.853, samples)
+	if !ok1 || !ok2 {
+		return StepResponse{}, errors.New("the process did not settle")
+	}
+
+	timeConstant := 0.67 * (t2 - t1)
+	deadTime := math.Max(0, 1.3*t1-0.29*t2)
+
+	return StepResponse{
+		Gain:         change / outputChange,
+		DeadTime:     time.Duration(deadTime * float64(time.Second)),
+		TimeConstant: time.Duration(timeConstant * float64(time.Second)),
+	}, nil
+}
+
+// findCrossing returns the time (in seconds) at which the given fraction of the total change is first reached,
+// interpolating linearly between samples
+func findCrossing(initial float64, change float64, fraction float64, samples []Sample) (float64, bool) {
+	previousTime := 0.0
+	previousFraction := 0.0
+	for _, sample := range samples {
+		currentTime := sample.Time.Seconds()
+		currentFraction := (sample.Value - initial) / change
+		if currentFraction >= fraction {
+			if currentFraction == previousFraction {
+				return currentTime, true
+			}
+			ratio := (fraction - previousFraction) / (currentFraction - previousFraction)
+			return previousTime + ratio*(currentTime-previousTime), true
+		}
+		previousTime = currentTime
+		previousFraction = currentFraction
+	}
+	return 0, false
+}
+
+// ControlLoopGains calculates the constants of the pwm control loop of a fan, which is updated
+// with the given tick rate.
+//
+// The control loop adds its output to the pwm of the fan twice per tick, so it behaves like an
+// integrating process. The SIMC rules (Skogestad) for such a process result in a PI controller,
+// whose closed loop time constant is chosen to match the time constant of the fan, so the pwm
+// is never changed faster than the fan is able to follow.
+func (r StepResponse) ControlLoopGains(tickRate time.Duration) Gains {
+	tick := tickRate.Seconds()
+	integratorGain := 2 / tick
+	closedLoopTime := math.Max(r.TimeConstant.Seconds(), tick) + r.DeadTime.Seconds()
+
+	// larger values would overshoot within a single tick
+	p := math.Min(1/(integratorGain*closedLoopTime), 0.5)
+	return Gains{
+		P: p,
+		I: p / (4 * closedLoopTime),
+		D: 0,
+	}
+}
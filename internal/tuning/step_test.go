@@ -0,0 +1,72 @@
+package tuning
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+	"time"
+)
+
+// createStepResponse samples the response of a first order plus dead time process to a step
+func createStepResponse(initial float64, change float64, deadTime time.Duration, timeConstant time.Duration, interval time.Duration, duration time.Duration) []Sample {
+	var samples []Sample
+	for t := interval; t <= duration; t += interval {
+		value := initial
+		if t > deadTime {
+			value += change * (1 - math.Exp(-(t-deadTime).Seconds()/timeConstant.Seconds()))
+		}
+		samples = append(samples, Sample{Time: t, Value: value})
+	}
+	return samples
+}
+
+func TestAnalyzeStepResponse(t *testing.T) {
+	// GIVEN
+	samples := createStepResponse(800, 1000, 500*time.Millisecond, 3*time.Second, 100*time.Millisecond, 30*time.Second)
+
+	// WHEN
+	result, err := AnalyzeStepResponse(800, 100, samples)
+
+	// THEN
+	assert.NoError(t, err)
+	assert.InDelta(t, 10, result.Gain, 0.1)
+	assert.InDelta(t, 500*time.Millisecond, result.DeadTime, float64(150*time.Millisecond))
+	assert.InDelta(t, 3*time.Second, result.TimeConstant, float64(150*time.Millisecond))
+}
+
+func TestAnalyzeStepResponseNoReaction(t *testing.T) {
+	// GIVEN
+	samples := createStepResponse(800, 0, 0, time.Second, time.Second, 10*time.Second)
+
+	// WHEN
+	_, err := AnalyzeStepResponse(800, 100, samples)
+
+	// THEN
+	assert.EqualError(t, err, "the process did not react to the change of the output")
+}
+
+func TestControlLoopGains(t *testing.T) {
+	// GIVEN
+	response := StepResponse{
+		Gain:         10,
+		DeadTime:     500 * time.Millisecond,
+		TimeConstant: 3 * time.Second,
+	}
+
+	// WHEN
+	result := response.ControlLoopGains(200 * time.Millisecond)
+
+	// THEN
+	// similar to the default control loop (0.03, 0.002, 0.0005)
+	assert.InDelta(t, 0.02857, result.P, 0.00001)
+	assert.InDelta(t, 0.00204, result.I, 0.00001)
+	assert.Equal(t, 0.0, result.D)
+
+	// WHEN
+	slowFan := StepResponse{Gain: 10, DeadTime: time.Second, TimeConstant: 9 * time.Second}
+	slowResult := slowFan.ControlLoopGains(200 * time.Millisecond)
+
+	// THEN
+	assert.Less(t, slowResult.P, result.P)
+	assert.Less(t, slowResult.I, result.I)
+}
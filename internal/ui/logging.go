@@ -3,33 +3,49 @@ package ui
 import (
 	"fmt"
 	"github.com/pterm/pterm"
+	"sync"
 )
 
+// serializes access to the pterm printers, which are not safe for concurrent use
+var printMutex sync.Mutex
+
 func SetDebugEnabled(enabled bool) {
 	pterm.PrintDebugMessages = enabled
 }
 
 func Printf(format string, a ...interface{}) {
+	printMutex.Lock()
+	defer printMutex.Unlock()
 	pterm.Printf(format, a...)
 }
 
 func Printfln(format string, a ...interface{}) {
+	printMutex.Lock()
+	defer printMutex.Unlock()
 	pterm.Printfln(format, a...)
 }
 
 func Debug(format string, a ...interface{}) {
+	printMutex.Lock()
+	defer printMutex.Unlock()
 	pterm.Debug.Printfln(format, a...)
 }
 
 func Success(format string, a ...interface{}) {
+	printMutex.Lock()
+	defer printMutex.Unlock()
 	pterm.Success.Printfln(format, a...)
 }
 
 func Info(format string, a ...interface{}) {
+	printMutex.Lock()
+	defer printMutex.Unlock()
 	pterm.Info.Printfln(format, a...)
 }
 
 func Warning(format string, a ...interface{}) {
+	printMutex.Lock()
+	defer printMutex.Unlock()
 	pterm.Warning.Printfln(format, a...)
 }
 
@@ -39,6 +55,8 @@ func WarningAndNotify(title string, format string, a ...interface{}) {
 }
 
 func Error(format string, a ...interface{}) {
+	printMutex.Lock()
+	defer printMutex.Unlock()
 	pterm.Error.Printfln(format, a...)
 }
 
@@ -49,5 +67,7 @@ func ErrorAndNotify(title string, format string, a ...interface{}) {
 
 func Fatal(format string, a ...interface{}) {
 	NotifyError("Fatal Error", fmt.Sprintf(format, a...))
+	printMutex.Lock()
+	defer printMutex.Unlock()
 	pterm.Fatal.Printfln(format, a...)
 }
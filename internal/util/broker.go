@@ -0,0 +1,56 @@
+package util
+
+import "sync"
+
+// Broker distributes published values to all of its subscribers.
+// Subscribers which are not able to keep up miss values instead of blocking the publisher.
+type Broker[T any] struct {
+	mutex       sync.RWMutex
+	subscribers map[chan T]struct{}
+}
+
+func NewBroker[T any]() *Broker[T] {
+	return &Broker[T]{
+		subscribers: map[chan T]struct{}{},
+	}
+}
+
+// Subscribe returns a channel which receives all values published after this call,
+// as well as a function to end the subscription
+func (b *Broker[T]) Subscribe(bufferSize int) (<-chan T, func()) {
+	ch := make(chan T, bufferSize)
+
+	b.mutex.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mutex.Unlock()
+
+	unsubscribe := func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		if _, exists := b.subscribers[ch]; exists {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+	return ch, unsubscribe
+}
+
+// HasSubscribers indicates whether anyone is listening for published values
+func (b *Broker[T]) HasSubscribers() bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return len(b.subscribers) > 0
+}
+
+// Publish sends the given value to all subscribers without blocking
+func (b *Broker[T]) Publish(value T) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- value:
+		default:
+			// subscriber is too slow, skip this value
+		}
+	}
+}
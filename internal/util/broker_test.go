@@ -0,0 +1,28 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBrokerPublish(t *testing.T) {
+	// GIVEN
+	broker := NewBroker[int]()
+	ch1, unsubscribe1 := broker.Subscribe(2)
+	ch2, unsubscribe2 := broker.Subscribe(1)
+	defer unsubscribe1()
+
+	// WHEN
+	broker.Publish(1)
+	broker.Publish(2)
+	unsubscribe2()
+	broker.Publish(3)
+
+	// THEN
+	assert.True(t, broker.HasSubscribers())
+	assert.Equal(t, 1, <-ch1)
+	assert.Equal(t, 2, <-ch1)
+	assert.Equal(t, 1, <-ch2)
+	_, open := <-ch2
+	assert.False(t, open)
+}
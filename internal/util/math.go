@@ -3,12 +3,19 @@ package util
 import (
 	"fmt"
 	"github.com/markusressel/fan2go/internal/ui"
+	"math"
 	"sort"
 	"strconv"
 )
 
 const (
 	InterpolationTypeLinear = "linear"
+	// InterpolationTypeCubic uses a smooth cubic spline, which may overshoot between steps
+	InterpolationTypeCubic = "cubic"
+	// InterpolationTypeMonotone uses a smooth cubic spline, which never overshoots between steps
+	InterpolationTypeMonotone = "monotone"
+	// InterpolationTypeStep keeps the value of a step until the next step is reached
+	InterpolationTypeStep = "step"
 )
 
 // Coerce returns a value that is at least min and at most max, otherwise value
@@ -97,9 +104,21 @@ func CalculateInterpolatedCurveValue(steps map[int]float64, interpolationType st
 			currentY := steps[currentX]
 			nextY := steps[nextX]
 
-			ratio := Ratio(input, float64(currentX), float64(nextX))
-			interpolation := currentY + ratio*(nextY-currentY)
-			return interpolation
+			switch interpolationType {
+			case InterpolationTypeStep:
+				return currentY
+			case InterpolationTypeCubic, InterpolationTypeMonotone:
+				tangents := calculateTangents(steps, xValues, interpolationType == InterpolationTypeMonotone)
+				return interpolateHermite(
+					float64(currentX), currentY, tangents[i],
+					float64(nextX), nextY, tangents[i+1],
+					input,
+				)
+			default:
+				ratio := Ratio(input, float64(currentX), float64(nextX))
+				interpolation := currentY + ratio*(nextY-currentY)
+				return interpolation
+			}
 		}
 	}
 
@@ -108,6 +127,60 @@ func CalculateInterpolatedCurveValue(steps map[int]float64, interpolationType st
 	return steps[xValues[len(xValues)-1]]
 }
 
+// calculateTangents returns the slope of a cubic spline at each of the given (sorted) x-values.
+// If monotone is true, the slopes are limited so the spline never overshoots (Fritsch-Carlson method).
+func calculateTangents(steps map[int]float64, xValues []int, monotone bool) []float64 {
+	n := len(xValues)
+	// slopes of the straight lines between neighbouring steps
+	secants := make([]float64, n-1)
+	for i := 0; i < n-1; i++ {
+		secants[i] = (steps[xValues[i+1]] - steps[xValues[i]]) / float64(xValues[i+1]-xValues[i])
+	}
+
+	tangents := make([]float64, n)
+	tangents[0] = secants[0]
+	tangents[n-1] = secants[n-2]
+	for i := 1; i < n-1; i++ {
+		if monotone && secants[i-1]*secants[i] <= 0 {
+			// local minimum or maximum, the spline has to be flat here
+			tangents[i] = 0
+		} else {
+			tangents[i] = (steps[xValues[i+1]] - steps[xValues[i-1]]) / float64(xValues[i+1]-xValues[i-1])
+		}
+	}
+
+	if monotone {
+		for i := 0; i < n-1; i++ {
+			if secants[i] == 0 {
+				tangents[i] = 0
+				tangents[i+1] = 0
+				continue
+			}
+			alpha := tangents[i] / secants[i]
+			beta := tangents[i+1] / secants[i]
+			if sum := alpha*alpha + beta*beta; sum > 9 {
+				tau := 3 / math.Sqrt(sum)
+				tangents[i] = tau * alpha * secants[i]
+				tangents[i+1] = tau * beta * secants[i]
+			}
+		}
+	}
+
+	return tangents
+}
+
+// interpolateHermite evaluates the cubic hermite spline between (x0, y0) and (x1, y1) with the given slopes at x
+func interpolateHermite(x0 float64, y0 float64, m0 float64, x1 float64, y1 float64, m1 float64, x float64) float64 {
+	h := x1 - x0
+	t := (x - x0) / h
+	t2 := t * t
+	t3 := t2 * t
+	return (2*t3-3*t2+1)*y0 +
+		(t3-2*t2+t)*h*m0 +
+		(-2*t3+3*t2)*y1 +
+		(t3-t2)*h*m1
+}
+
 // FindClosest finds the closest value to target in options.
 func FindClosest(target int, arr []int) int {
 	n := len(arr)
@@ -31,6 +31,67 @@ func TestCalculateInterpolatedCurveValue(t *testing.T) {
 	}
 }
 
+func TestCalculateInterpolatedCurveValueStep(t *testing.T) {
+	// GIVEN
+	expectedInputOutput := map[float64]float64{
+		0:      0.0,
+		99.0:   0.0,
+		100.0:  100.0,
+		999.0:  100.0,
+		2000.0: 1000.0,
+	}
+	steps := map[int]float64{
+		0:    0,
+		100:  100,
+		1000: 1000,
+	}
+
+	for input, output := range expectedInputOutput {
+		// WHEN
+		result := CalculateInterpolatedCurveValue(steps, InterpolationTypeStep, input)
+
+		// THEN
+		assert.Equal(t, output, result, "input: %v", input)
+	}
+}
+
+func TestCalculateInterpolatedCurveValueSplines(t *testing.T) {
+	// GIVEN
+	steps := map[int]float64{
+		40: 0,
+		50: 0,
+		60: 200,
+		70: 255,
+	}
+
+	for _, interpolationType := range []string{InterpolationTypeCubic, InterpolationTypeMonotone} {
+		// WHEN
+		var results []float64
+		for input := 40.0; input <= 70; input++ {
+			results = append(results, CalculateInterpolatedCurveValue(steps, interpolationType, input))
+		}
+
+		// THEN
+		// all steps are part of the curve
+		assert.Equal(t, 0.0, results[0], interpolationType)
+		assert.Equal(t, 0.0, results[10], interpolationType)
+		assert.Equal(t, 200.0, results[20], interpolationType)
+		assert.Equal(t, 255.0, results[30], interpolationType)
+
+		if interpolationType == InterpolationTypeCubic {
+			// the cubic spline undershoots before the steep increase
+			assert.Less(t, results[5], 0.0)
+		} else {
+			// the monotone spline never overshoots
+			for i := 1; i < len(results); i++ {
+				assert.GreaterOrEqual(t, results[i], results[i-1], "input: %d", 40+i)
+				assert.LessOrEqual(t, results[i], 255.0)
+			}
+			assert.Equal(t, 0.0, results[5])
+		}
+	}
+}
+
 func TestRatio(t *testing.T) {
 	// GIVEN
 	a := 0.0
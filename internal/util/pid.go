@@ -2,6 +2,20 @@ package util
 
 import "time"
 
+// PidTerms contains the individual parts of the last output of a PidLoop
+type PidTerms struct {
+	// Error is the difference between target and measured value
+	Error float64 `json:"error"`
+	// P is the proportional part of the output
+	P float64 `json:"p"`
+	// I is the integral part of the output
+	I float64 `json:"i"`
+	// D is the derivative part of the output
+	D float64 `json:"d"`
+	// Output is the sum of all parts, limited to the output range (if any)
+	Output float64 `json:"output"`
+}
+
 type PidLoop struct {
 	// Proptional Constant
 	p float64
@@ -10,12 +24,23 @@ type PidLoop struct {
 	// Derivative Constant
 	d float64
 
+	// limits of the output, only applied if limitOutput is true
+	outputMin   float64
+	outputMax   float64
+	limitOutput bool
+	// time constant of the low-pass filter applied to the derivative, 0 = no filter
+	derivativeFilter time.Duration
+	// reverse inverts the error, so that the output rises when the measured value is above the target
+	reverse bool
+
 	// error from previous loop
 	error float64
 	// integral from previous loop + error, i.e. integral error
 	integral float64
-	// error - error from previous loop, i.e. differential error
-	differentialError float64
+	// (filtered) change of the error per second
+	derivative float64
+	// terms of the previous loop
+	terms PidTerms
 	// last execution time of the loop
 	lastTime time.Time
 }
@@ -28,25 +53,93 @@ func NewPidLoop(p float64, i float64, d float64) *PidLoop {
 	}
 }
 
+// SetOutputLimits limits the output of the loop to [min..max].
+// This also prevents integral windup, the integral part stops growing while the output is saturated.
+func (p *PidLoop) SetOutputLimits(min float64, max float64) {
+	p.outputMin = min
+	p.outputMax = max
+	p.limitOutput = true
+}
+
+// SetDerivativeFilter smooths the derivative using a low-pass filter with the given time constant,
+// which reduces the effect of sensor noise. A value of 0 disables the filter.
+func (p *PidLoop) SetDerivativeFilter(timeConstant time.Duration) {
+	p.derivativeFilter = timeConstant
+}
+
+// SetReverse enables reverse action, i.e. the error is calculated as measured - target,
+// so positive constants increase the output while the measured value is above the target.
+func (p *PidLoop) SetReverse(reverse bool) {
+	p.reverse = reverse
+}
+
+// Terms returns the individual parts of the last output
+func (p *PidLoop) Terms() PidTerms {
+	return p.terms
+}
+
 // Loop advances the pid loop
 func (p *PidLoop) Loop(target float64, measured float64) float64 {
-	output := 0.0
+	return p.advance(target, measured, time.Now())
+}
+
+func (p *PidLoop) advance(target float64, measured float64, now time.Time) float64 {
 	err := target - measured
+	if p.reverse {
+		err = -err
+	}
 
-	loopTime := time.Now()
 	if p.lastTime.IsZero() {
-		p.lastTime = loopTime
+		p.error = err
+		p.lastTime = now
+		p.terms = PidTerms{Error: err}
+		return 0
+	}
+
+	dt := now.Sub(p.lastTime).Seconds()
+	if dt <= 0 {
+		return p.terms.Output
+	}
+
+	rawDerivative := (err - p.error) / dt
+	if p.derivativeFilter > 0 {
+		alpha := dt / (p.derivativeFilter.Seconds() + dt)
+		p.derivative = p.derivative + alpha*(rawDerivative-p.derivative)
 	} else {
-		dt := loopTime.Sub(p.lastTime).Seconds()
+		p.derivative = rawDerivative
+	}
+
+	proportional := p.p * err
+	derivative := p.d * p.derivative
+	integral := p.i * (p.integral + err*dt)
+	output := proportional + integral + derivative
+
+	if p.limitOutput {
+		// the integral part alone may never exceed the output range
+		previousIntegral := Coerce(p.i*p.integral, p.outputMin, p.outputMax)
+		integral = Coerce(integral, p.outputMin, p.outputMax)
+		output = proportional + integral + derivative
 
-		proportional := err
-		p.integral = p.integral + err*dt
-		derivative := (err - p.error) / dt
-		output = p.p*proportional + p.i*p.integral + p.d*derivative
+		// don't integrate any further while the output is saturated in the same direction
+		if (output > p.outputMax && integral > previousIntegral) || (output < p.outputMin && integral < previousIntegral) {
+			integral = previousIntegral
+			output = proportional + integral + derivative
+		}
+		output = Coerce(output, p.outputMin, p.outputMax)
 	}
 
+	if p.i != 0 {
+		p.integral = integral / p.i
+	}
 	p.error = err
-	p.lastTime = loopTime
+	p.lastTime = now
+	p.terms = PidTerms{
+		Error:  err,
+		P:      proportional,
+		I:      integral,
+		D:      derivative,
+		Output: output,
+	}
 
 	return output
 }
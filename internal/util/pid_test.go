@@ -0,0 +1,94 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var pidTestStart = time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// runPidLoop advances the loop once per second and returns all outputs
+func runPidLoop(pidLoop *PidLoop, target float64, measured []float64) []float64 {
+	var result []float64
+	for idx, value := range measured {
+		now := pidTestStart.Add(time.Duration(idx) * time.Second)
+		result = append(result, pidLoop.advance(target, value, now))
+	}
+	return result
+}
+
+func TestPidLoop(t *testing.T) {
+	// GIVEN
+	pidLoop := NewPidLoop(1, 0.5, 2)
+
+	// WHEN
+	result := runPidLoop(pidLoop, 10, []float64{8, 8, 9})
+
+	// THEN
+	assert.Equal(t, []float64{0, 3, 0.5}, result)
+	assert.Equal(t, PidTerms{Error: 1, P: 1, I: 1.5, D: -2, Output: 0.5}, pidLoop.Terms())
+}
+
+func TestPidLoopReverse(t *testing.T) {
+	// GIVEN
+	pidLoop := NewPidLoop(1, 0, 0)
+	pidLoop.SetReverse(true)
+
+	// WHEN
+	result := runPidLoop(pidLoop, 10, []float64{12, 12})
+
+	// THEN
+	assert.Equal(t, []float64{0, 2}, result)
+}
+
+func TestPidLoopOutputLimits(t *testing.T) {
+	// GIVEN
+	pidLoop := NewPidLoop(1, 0, 0)
+	pidLoop.SetOutputLimits(0, 1)
+
+	// WHEN
+	result := runPidLoop(pidLoop, 10, []float64{5, 5, 15})
+
+	// THEN
+	assert.Equal(t, []float64{0, 1, 0}, result)
+}
+
+func TestPidLoopAntiWindup(t *testing.T) {
+	// GIVEN
+	limited := NewPidLoop(0, 0.1, 0)
+	limited.SetOutputLimits(0, 1)
+	unlimited := NewPidLoop(0, 0.1, 0)
+
+	// a long period far below the target, followed by a small overshoot
+	measured := []float64{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 12}
+
+	// WHEN
+	limitedResult := runPidLoop(limited, 10, measured)
+	unlimitedResult := runPidLoop(unlimited, 10, measured)
+
+	// THEN
+	// without anti-windup the integral keeps the output high long after the overshoot
+	assert.InDelta(t, 18.8, unlimitedResult[len(measured)-1], 0.000001)
+	// with anti-windup the integral never exceeds the output range, so it starts to recover immediately
+	assert.InDelta(t, 0.8, limitedResult[len(measured)-1], 0.000001)
+	assert.InDelta(t, 0.8, limited.Terms().I, 0.000001)
+}
+
+func TestPidLoopDerivativeFilter(t *testing.T) {
+	// GIVEN
+	unfiltered := NewPidLoop(0, 0, 1)
+	filtered := NewPidLoop(0, 0, 1)
+	filtered.SetDerivativeFilter(3 * time.Second)
+
+	measured := []float64{10, 14, 14, 14}
+
+	// WHEN
+	unfilteredResult := runPidLoop(unfiltered, 10, measured)
+	filteredResult := runPidLoop(filtered, 10, measured)
+
+	// THEN
+	assert.Equal(t, []float64{0, -4, 0, 0}, unfilteredResult)
+	// alpha = dt / (timeConstant + dt) = 0.25
+	assert.Equal(t, []float64{0, -1, -0.75, -0.5625}, filteredResult)
+}